@@ -0,0 +1,138 @@
+package mockfs
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Fault is a fault a Chaos injected.
+type Fault struct {
+	Draw uint64    // Number of the draw that injected the fault, counted from 1 over all draws of the Chaos.
+	Op   Operation // Operation that failed.
+	Path string    // Path the operation was called on.
+	Err  error     // Error that was injected.
+}
+
+// String returns a one-line description of the fault.
+func (f Fault) String() string {
+	return fmt.Sprintf("#%d %s %s: %v", f.Draw, f.Op, f.Path, f.Err)
+}
+
+// Chaos is the seeded random source behind probabilistic fault injection.
+// Every random rule created from it draws from its single generator, so
+// running the same operations in the same order with the same seed injects
+// the same faults. Chaos also records every fault it injects.
+// It is safe for concurrent use, though concurrent operations only replay
+// exactly if they reach the injector in the same order.
+type Chaos struct {
+	seed   uint64
+	mu     sync.Mutex
+	rng    *rand.Rand
+	draws  uint64
+	faults []Fault
+}
+
+// NewChaos returns a Chaos seeded with seed.
+func NewChaos(seed uint64) *Chaos {
+	return &Chaos{
+		seed: seed,
+		rng:  rand.New(rand.NewPCG(seed, seed)), //nolint:gosec // deterministic fault injection, not security
+	}
+}
+
+// Seed returns the seed the Chaos was created with.
+func (c *Chaos) Seed() uint64 {
+	return c.seed
+}
+
+// Faults returns the faults injected so far, in order.
+func (c *Chaos) Faults() []Fault {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.faults)
+}
+
+// ReportOnFailure registers a cleanup with t that, if the test has failed,
+// logs the seed and the faults injected, so the run can be replayed with
+// NewChaos(seed). t must be a CleanupReporter, as *testing.T is; any other
+// TestReporter is reported as a test failure.
+func (c *Chaos) ReportOnFailure(t TestReporter) {
+	t.Helper()
+
+	ct, ok := t.(CleanupReporter)
+	if !ok {
+		t.Errorf("mockfs: ReportOnFailure: %T is not a CleanupReporter", t)
+		return
+	}
+	ct.Cleanup(func() {
+		if ct.Failed() {
+			ct.Logf("%s", c.report())
+		}
+	})
+}
+
+// report describes the seed and the faults injected.
+func (c *Chaos) report() string {
+	faults := c.Faults()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "mockfs: chaos seed %d injected %d faults", c.seed, len(faults))
+	for _, f := range faults {
+		b.WriteString("\n\t")
+		b.WriteString(f.String())
+	}
+
+	return b.String()
+}
+
+// chaosDraw is the per-rule configuration of ErrorModeRandom.
+type chaosDraw struct {
+	chaos *Chaos  // Shared generator and fault log.
+	p     float64 // Probability that a matching call fails.
+	errs  []error // Pool the injected error is drawn from.
+}
+
+// draw decides whether a matching call of op on path fails, and with which
+// error, recording the fault.
+func (d *chaosDraw) draw(op Operation, path string) (bool, error) {
+	c := d.chaos
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.draws++
+	if c.rng.Float64() >= d.p {
+		return false, nil
+	}
+
+	err := d.errs[c.rng.IntN(len(d.errs))]
+	c.faults = append(c.faults, Fault{Draw: c.draws, Op: op, Path: path, Err: err})
+
+	return true, err
+}
+
+// NewChaosRule creates a rule that fails each matching call with probability
+// p, returning an error drawn from errs. The draws come from c, so rules
+// sharing c replay together from its seed. The rule's Mode is ErrorModeRandom.
+//
+// Returns an error wrapping ErrUsage if c is nil, p is outside [0, 1], or
+// errs is empty.
+func NewChaosRule(c *Chaos, p float64, errs []error, matchers ...PathMatcher) (*ErrorRule, error) {
+	switch {
+	case c == nil:
+		return nil, fmt.Errorf("mockfs: %w: nil Chaos", ErrUsage)
+	case p < 0 || p > 1:
+		return nil, fmt.Errorf("mockfs: %w: invalid probability %v — must be in [0, 1]", ErrUsage, p)
+	case len(errs) == 0:
+		return nil, fmt.Errorf("mockfs: %w: empty error pool", ErrUsage)
+	}
+
+	rule := newValidatedErrorRule(nil, ErrorModeRandom, 0, matchers...)
+	rule.chaos = &chaosDraw{chaos: c, p: p, errs: slices.Clone(errs)}
+
+	return rule, nil
+}
@@ -0,0 +1,162 @@
+package mockfs_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// fakeReporter records what is reported through a CleanupReporter.
+type fakeReporter struct {
+	failed   bool
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (r *fakeReporter) Helper()          {}
+func (r *fakeReporter) Failed() bool     { return r.failed }
+func (r *fakeReporter) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+func (r *fakeReporter) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+func (r *fakeReporter) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// finish runs the registered cleanups, last first, as testing does.
+func (r *fakeReporter) finish() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+// chaosRun performs a fixed sequence of operations under chaos and returns
+// the error of each.
+func chaosRun(t *testing.T, seed uint64) ([]error, *mockfs.Chaos) {
+	t.Helper()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "a"), mockfs.File("b", "b"))
+	c, err := mfs.InjectChaos(seed, map[mockfs.Operation]float64{mockfs.OpUnknown: 0.3}, mockfs.ErrTimeout, mockfs.ErrDiskFull)
+	requireNoError(t, err)
+
+	var errs []error
+	for i := range 50 {
+		_, err := mfs.Stat([]string{"a", "b"}[i%2])
+		errs = append(errs, err)
+	}
+	return errs, c
+}
+
+func TestMockFS_InjectChaos_Replay(t *testing.T) {
+	t.Parallel()
+
+	first, c1 := chaosRun(t, 42)
+	second, c2 := chaosRun(t, 42)
+
+	var failures int
+	for i := range first {
+		if fmt.Sprint(first[i]) != fmt.Sprint(second[i]) {
+			t.Fatalf("call %d: %v != %v", i, first[i], second[i])
+		}
+		if first[i] != nil {
+			failures++
+		}
+	}
+
+	if failures == 0 || failures == len(first) {
+		t.Errorf("failures = %d of %d, want some", failures, len(first))
+	}
+	if got := len(c1.Faults()); got != failures {
+		t.Errorf("len(Faults()) = %d, want %d", got, failures)
+	}
+	if c1.Seed() != 42 || fmt.Sprint(c1.Faults()) != fmt.Sprint(c2.Faults()) {
+		t.Error("same seed recorded different faults")
+	}
+
+	// The pool is drawn from
+	seen := map[error]bool{}
+	for _, f := range c1.Faults() {
+		seen[f.Err] = true
+		if f.Op != mockfs.OpStat {
+			t.Errorf("fault op = %v, want Stat", f.Op)
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("errors drawn = %v, want both pool entries", seen)
+	}
+}
+
+func TestMockFS_InjectChaos_PerOperation(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "a"))
+	_, err := mfs.InjectChaos(1, map[mockfs.Operation]float64{
+		mockfs.OpUnknown: 1,
+		mockfs.OpStat:    0,
+	}, mockfs.ErrTimeout)
+	requireNoError(t, err)
+
+	for range 10 {
+		_, err := mfs.Stat("a")
+		requireNoError(t, err, "stat overridden to never fail")
+	}
+	_, err = mfs.Open("a")
+	assertError(t, err, mockfs.ErrTimeout)
+}
+
+func TestMockFS_InjectChaos_Validation(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS()
+
+	_, err := mfs.InjectChaos(1, map[mockfs.Operation]float64{mockfs.OpUnknown: 0.5})
+	assertError(t, err, mockfs.ErrUsage, "empty pool")
+
+	_, err = mfs.InjectChaos(1, map[mockfs.Operation]float64{mockfs.OpRead: 1.5}, mockfs.ErrTimeout)
+	assertError(t, err, mockfs.ErrUsage, "probability above 1")
+
+	_, err = mockfs.NewChaosRule(nil, 0.5, []error{mockfs.ErrTimeout}, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "nil chaos")
+
+	_, err = mockfs.NewErrorRule(mockfs.ErrTimeout, mockfs.ErrorModeRandom, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "random mode without a chaos")
+
+	if n := len(mfs.ErrorInjector().GetAll()); n != 0 {
+		t.Errorf("rules added by failed calls: %d", n)
+	}
+}
+
+func TestChaos_ReportOnFailure(t *testing.T) {
+	t.Parallel()
+
+	c := mockfs.NewChaos(7)
+	rule, err := mockfs.NewChaosRule(c, 1, []error{mockfs.ErrTimeout}, mockfs.NewExactMatcher("db"))
+	requireNoError(t, err)
+	inj := mockfs.NewErrorInjector()
+	inj.Add(mockfs.OpWrite, rule)
+	assertError(t, inj.CheckAndApply(mockfs.OpWrite, "db"), mockfs.ErrTimeout)
+
+	passing := &fakeReporter{}
+	c.ReportOnFailure(passing)
+	passing.finish()
+	if len(passing.logs) != 0 {
+		t.Errorf("passing test logged %q", passing.logs)
+	}
+
+	failing := &fakeReporter{failed: true}
+	c.ReportOnFailure(failing)
+	failing.finish()
+	if len(failing.logs) != 1 || !strings.Contains(failing.logs[0], "seed 7") ||
+		!strings.Contains(failing.logs[0], "#1 Write db: operation timeout") {
+		t.Errorf("report = %q, want the seed and the fault", failing.logs)
+	}
+
+	plain := &mockReporter{}
+	c.ReportOnFailure(plain)
+	if len(plain.errors) != 1 || !strings.Contains(plain.errors[0], "not a CleanupReporter") {
+		t.Errorf("errors = %q, want one usage report", plain.errors)
+	}
+}
@@ -0,0 +1,387 @@
+package mockfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"testing/fstest"
+	"time"
+)
+
+// GeneratedContent is the content of a generated file, produced on demand by
+// a generator instead of being held in memory as a whole, so a test can use
+// files of many gigabytes. Create it with Generated and pass it to File or
+// AddFile. Reads through a MockFile stream from the generator; writes are
+// kept as overlays of the ranges they change, and the generator is never
+// written to.
+type GeneratedContent struct {
+	gen  io.ReaderAt
+	size int64
+}
+
+// Generated returns content of size bytes produced by gen, such as Zeros,
+// Pattern, or Random. Byte i of the content is byte i of gen. gen is read
+// lazily, for as long as the file exists, so its content must not change.
+//
+//	mockfs.File("disk.img", mockfs.Generated(10<<30, mockfs.Zeros()))
+func Generated(size int64, gen io.ReaderAt) GeneratedContent {
+	return GeneratedContent{gen: gen, size: size}
+}
+
+// Zeros returns a generator of zero bytes.
+func Zeros() io.ReaderAt {
+	return zeros{}
+}
+
+// Pattern returns a generator that repeats p from offset 0 on. An empty p
+// generates zero bytes.
+func Pattern(p []byte) io.ReaderAt {
+	if len(p) == 0 {
+		return zeros{}
+	}
+	return pattern(slices.Clone(p))
+}
+
+// Random returns a generator of pseudo-random bytes determined by seed. Every
+// read of the same offset returns the same byte, so the content can be
+// checked against a second generator with the same seed.
+func Random(seed uint64) io.ReaderAt {
+	return random(seed)
+}
+
+// zeros generates zero bytes.
+type zeros struct{}
+
+// ReadAt implements io.ReaderAt.
+func (zeros) ReadAt(p []byte, _ int64) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// pattern generates its bytes over and over.
+type pattern []byte
+
+// ReadAt implements io.ReaderAt.
+func (g pattern) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	n := 0
+	for i := off % int64(len(g)); n < len(p); i = 0 {
+		n += copy(p[n:], g[i:])
+	}
+	return n, nil
+}
+
+// random generates pseudo-random bytes, eight at a time from a hash of the
+// seed and the offset, so that any range can be generated directly.
+type random uint64
+
+// ReadAt implements io.ReaderAt.
+func (g random) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	var word [8]byte
+	for n := 0; n < len(p); {
+		pos := off + int64(n)
+		//nolint:gosec // pos is not negative, so it converts to uint64 unchanged.
+		binary.LittleEndian.PutUint64(word[:], mix64(uint64(g)^mix64(uint64(pos/8))))
+		n += copy(p[n:], word[pos%8:])
+	}
+	return len(p), nil
+}
+
+// mix64 is the SplitMix64 finalizer, a bijective hash of 64-bit words.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// fileData is the content of a new file.
+type fileData struct {
+	data []byte              // Content held in memory.
+	virt *virtualData        // Generated content, in place of data.
+	dyn  func() (any, error) // Function computing the content of a dynamic file at every Open.
+}
+
+// size returns the size of the content.
+func (c fileData) size() int64 {
+	if c.virt != nil {
+		return c.virt.size
+	}
+	return int64(len(c.data))
+}
+
+// fileContent converts the content argument of File and AddFile:
+// GeneratedContent becomes virtual content, DynamicContent the function of a
+// dynamic file, and anything else is converted by toBytes.
+func fileContent(content any) (fileData, error) {
+	switch v := content.(type) {
+	case GeneratedContent:
+		if v.size < 0 {
+			return fileData{}, fmt.Errorf("negative size %d", v.size)
+		}
+		if v.gen == nil {
+			return fileData{}, errors.New("nil generator")
+		}
+		return fileData{virt: &virtualData{src: v.gen, size: v.size, limit: v.size}}, nil
+
+	case DynamicContent:
+		if v.fn == nil {
+			return fileData{}, errors.New("nil content function")
+		}
+		return fileData{dyn: v.fn}, nil
+	}
+
+	data, err := toBytes(content)
+	return fileData{data: data}, err
+}
+
+// newFile allocates the inode of a regular file with content c and
+// permissions perm; see newInode. Caller must hold the write lock.
+func (m *MockFS) newFile(c fileData, perm FileMode) *inode {
+	n := m.newInode(&fstest.MapFile{
+		Data:    c.data, // already deep copied by toBytes
+		Mode:    (perm & ModePerm) &^ ModeDir,
+		ModTime: time.Now(),
+	})
+	n.virt, n.dyn = c.virt, c.dyn
+	return n
+}
+
+// --- Virtual content ---
+
+// virtualData is the content of a generated file: the bytes of a generator,
+// overlaid with the ranges written since.
+type virtualData struct {
+	src   io.ReaderAt // Generator of the bytes not written to.
+	size  int64       // Size of the content.
+	limit int64       // Bytes from limit on come from src no more; those not written to are zero.
+	dirty []extent    // Written ranges, ordered by offset, neither overlapping nor adjacent.
+}
+
+// extent is a range of written bytes.
+type extent struct {
+	off  int64
+	data []byte
+}
+
+// end returns the offset just past the extent.
+func (e extent) end() int64 {
+	return e.off + int64(len(e.data))
+}
+
+// clone returns a copy of v that does not share written bytes with it.
+// A nil v yields nil.
+func (v *virtualData) clone() *virtualData {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	c.dirty = make([]extent, len(v.dirty))
+	for i, e := range v.dirty {
+		c.dirty[i] = extent{off: e.off, data: slices.Clone(e.data)}
+	}
+	return &c
+}
+
+// readAt fills p with the content at off, up to the end of the content,
+// and returns the number of bytes filled.
+func (v *virtualData) readAt(p []byte, off int64) (int, error) {
+	if off >= v.size {
+		return 0, nil
+	}
+
+	//nolint:gosec // the result lies in [0, len(p)], so it fits in an int.
+	n := int(min(int64(len(p)), v.size-off))
+	p = p[:n]
+
+	// The generator covers the bytes below limit, zeros the rest
+	k := 0
+	if off < v.limit {
+		//nolint:gosec // the result lies in [0, n], so it fits in an int.
+		k = int(min(int64(n), v.limit-off))
+		if got, err := v.src.ReadAt(p[:k], off); got < k {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+	}
+	clear(p[k:])
+
+	end := off + int64(n)
+	i := sort.Search(len(v.dirty), func(i int) bool { return v.dirty[i].end() > off })
+	for _, e := range v.dirty[i:] {
+		if e.off >= end {
+			break
+		}
+		if e.off >= off {
+			copy(p[e.off-off:], e.data)
+		} else {
+			copy(p, e.data[off-e.off:])
+		}
+	}
+
+	return n, nil
+}
+
+// writeAt overlays b on the content at off, extending the content as needed.
+func (v *virtualData) writeAt(b []byte, off int64) {
+	end := off + int64(len(b))
+	v.size = max(v.size, end)
+	if len(b) == 0 {
+		return
+	}
+
+	// Extents i to j-1 overlap or touch the written range
+	i := sort.Search(len(v.dirty), func(i int) bool { return v.dirty[i].end() >= off })
+	j := i
+	for j < len(v.dirty) && v.dirty[j].off <= end {
+		j++
+	}
+
+	switch {
+	case j == i:
+		v.dirty = slices.Insert(v.dirty, i, extent{off: off, data: slices.Clone(b)})
+
+	case j == i+1 && v.dirty[i].off <= off:
+		// Write into or at the end of one extent
+		e := &v.dirty[i]
+		if end <= e.end() {
+			copy(e.data[off-e.off:], b)
+		} else {
+			e.data = append(e.data[:off-e.off], b...)
+		}
+
+	default:
+		lo, hi := min(off, v.dirty[i].off), max(end, v.dirty[j-1].end())
+		data := make([]byte, hi-lo)
+		for _, e := range v.dirty[i:j] {
+			copy(data[e.off-lo:], e.data)
+		}
+		copy(data[off-lo:], b)
+		v.dirty = slices.Replace(v.dirty, i, j, extent{off: lo, data: data})
+	}
+}
+
+// resize changes the size of the content, dropping the written ranges past
+// a smaller size. Bytes past the old size read as zeros.
+func (v *virtualData) resize(size int64) {
+	if size < v.size {
+		i := sort.Search(len(v.dirty), func(i int) bool { return v.dirty[i].end() > size })
+		if i < len(v.dirty) && v.dirty[i].off < size {
+			v.dirty[i].data = v.dirty[i].data[:size-v.dirty[i].off]
+			i++
+		}
+		v.dirty = v.dirty[:i]
+	}
+
+	v.limit = min(v.limit, size)
+	v.size = size
+}
+
+// bytes returns the whole content.
+func (v *virtualData) bytes() ([]byte, error) {
+	data := make([]byte, v.size)
+	if _, err := v.readAt(data, 0); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// --- Inode content ---
+
+// maxZeroFill is the largest gap of zero bytes an in-memory file is extended
+// by; a file growing by more is made sparse instead.
+const maxZeroFill = 1 << 20
+
+// makeSparse turns the in-memory content into zero-generated content with
+// the data written over it, so the file can grow without allocating the
+// zero bytes it gains.
+func (n *inode) makeSparse() {
+	n.virt = &virtualData{src: zeros{}, size: int64(len(n.Data))}
+	if len(n.Data) > 0 {
+		n.virt.dirty = []extent{{data: n.Data}}
+	}
+	n.Data = nil
+}
+
+// size returns the size of the inode's content.
+func (n *inode) size() int64 {
+	if n.virt != nil {
+		return n.virt.size
+	}
+	return int64(len(n.Data))
+}
+
+// readAt fills p with the content at off, up to the end of the content,
+// and returns the number of bytes filled. It fails only when the generator
+// of generated content does.
+func (n *inode) readAt(p []byte, off int64) (int, error) {
+	if n.virt != nil {
+		return n.virt.readAt(p, off)
+	}
+	if off >= int64(len(n.Data)) {
+		return 0, nil
+	}
+	return copy(p, n.Data[off:]), nil
+}
+
+// writeAt copies b into the content at off, zero-filling any gap and
+// extending the content as needed.
+func (n *inode) writeAt(b []byte, off int64) {
+	if n.virt == nil && off-int64(len(n.Data)) > maxZeroFill {
+		n.makeSparse()
+	}
+	if n.virt != nil {
+		n.virt.writeAt(b, off)
+		return
+	}
+
+	//nolint:gosec // the end of a write lies within the memory of the file.
+	if needed := int(off) + len(b); needed > len(n.Data) {
+		n.Data = append(n.Data, make([]byte, needed-len(n.Data))...)
+	}
+	copy(n.Data[off:], b)
+}
+
+// replace makes a copy of b the whole content, which is no longer generated.
+func (n *inode) replace(b []byte) {
+	n.virt = nil
+	n.Data = append([]byte{}, b...)
+}
+
+// resize changes the size of the content, dropping the tail or extending it
+// with zero bytes.
+func (n *inode) resize(size int64) {
+	if n.virt == nil && size-int64(len(n.Data)) > maxZeroFill {
+		n.makeSparse()
+	}
+	if n.virt != nil {
+		n.virt.resize(size)
+		return
+	}
+
+	data := make([]byte, size)
+	copy(data, n.Data)
+	n.Data = data
+}
+
+// content returns the whole content, which for generated content means
+// reading all of it from its generator.
+func (n *inode) content() ([]byte, error) {
+	if n.virt != nil {
+		return n.virt.bytes()
+	}
+	return n.Data, nil
+}
@@ -0,0 +1,310 @@
+package mockfs_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// failingReader is a generator whose reads fail.
+type failingReader struct{ err error }
+
+func (r failingReader) ReadAt(_ []byte, _ int64) (int, error) { return 0, r.err }
+
+func TestGenerators(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		gen  io.ReaderAt
+		off  int64
+		want []byte
+	}{
+		{name: "zeros", gen: mockfs.Zeros(), off: 1 << 40, want: make([]byte, 5)},
+		{name: "pattern from start", gen: mockfs.Pattern([]byte("abc")), off: 0, want: []byte("abcabca")},
+		{name: "pattern mid cycle", gen: mockfs.Pattern([]byte("abc")), off: 4, want: []byte("bcabc")},
+		{name: "empty pattern", gen: mockfs.Pattern(nil), off: 3, want: make([]byte, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make([]byte, len(tt.want))
+			n, err := tt.gen.ReadAt(got, tt.off)
+			requireNoError(t, err)
+			if n != len(tt.want) || !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadAt = %d, %q, want %q", n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	t.Parallel()
+
+	whole := make([]byte, 100)
+	_, err := mockfs.Random(7).ReadAt(whole, 0)
+	requireNoError(t, err)
+
+	// Any range reads the same bytes, whatever its alignment
+	part := make([]byte, 50)
+	_, err = mockfs.Random(7).ReadAt(part, 37)
+	requireNoError(t, err)
+	if !bytes.Equal(part, whole[37:87]) {
+		t.Errorf("ReadAt(37) = %x, want %x", part, whole[37:87])
+	}
+
+	other := make([]byte, 100)
+	_, err = mockfs.Random(8).ReadAt(other, 0)
+	requireNoError(t, err)
+	if bytes.Equal(other, whole) {
+		t.Error("seeds 7 and 8 generate the same bytes")
+	}
+}
+
+func TestGenerated_LargeFile(t *testing.T) {
+	t.Parallel()
+
+	const size = 10 << 30
+	mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", mockfs.Generated(size, mockfs.Pattern([]byte("0123456789")))))
+
+	info, err := mfs.Stat("disk.img")
+	requireNoError(t, err)
+	if info.Size() != size {
+		t.Errorf("Size() = %d, want %d", info.Size(), size)
+	}
+	if u := mfs.Usage(); u.Bytes != size {
+		t.Errorf("Usage().Bytes = %d, want %d", u.Bytes, size)
+	}
+
+	f, err := mfs.OpenFile("disk.img", os.O_RDWR, 0)
+	requireNoError(t, err)
+	defer f.Close()
+
+	pos, err := f.Seek(-4, io.SeekEnd)
+	requireNoError(t, err)
+	if pos != size-4 {
+		t.Errorf("Seek = %d, want %d", pos, size-4)
+	}
+	buf := make([]byte, 10)
+	n, err := f.Read(buf)
+	requireNoError(t, err)
+	if got := string(buf[:n]); got != "6789" {
+		t.Errorf("Read at the end = %q, want %q", got, "6789")
+	}
+
+	// A write changes only its range
+	_, err = f.WriteAt([]byte("XY"), 1<<30+5)
+	requireNoError(t, err)
+	n, err = f.ReadAt(buf, 1<<30)
+	requireNoError(t, err)
+	if got := string(buf[:n]); got != "45678XY123" {
+		t.Errorf("ReadAt after write = %q, want %q", got, "45678XY123")
+	}
+}
+
+func TestGenerated_MatchesPlainFile(t *testing.T) {
+	t.Parallel()
+
+	const size = 64
+	gen := mockfs.Random(1)
+	plain := make([]byte, size)
+	_, err := gen.ReadAt(plain, 0)
+	requireNoError(t, err)
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.File("plain", plain),
+		mockfs.File("generated", mockfs.Generated(size, gen)),
+	)
+
+	// Every change is applied to both files, which must stay equal
+	steps := []struct {
+		name  string
+		apply func(f *mockfs.MockFile) error
+	}{
+		{name: "write inside", apply: func(f *mockfs.MockFile) error { return writeString(f, "aaaa", 10) }},
+		{name: "write before", apply: func(f *mockfs.MockFile) error { return writeString(f, "bb", 4) }},
+		{name: "write adjacent", apply: func(f *mockfs.MockFile) error { return writeString(f, "cc", 14) }},
+		{name: "write within", apply: func(f *mockfs.MockFile) error { return writeString(f, "d", 12) }},
+		{name: "write spanning", apply: func(f *mockfs.MockFile) error { return writeString(f, "eeeeeeeeeeeeee", 2) }},
+		{name: "write apart", apply: func(f *mockfs.MockFile) error { return writeString(f, "ff", 40) }},
+		{name: "write past the end", apply: func(f *mockfs.MockFile) error { return writeString(f, "gg", 70) }},
+		{name: "shrink", apply: func(f *mockfs.MockFile) error { return f.Truncate(41) }},
+		{name: "grow", apply: func(f *mockfs.MockFile) error { return f.Truncate(60) }},
+		{name: "write in the zeroed tail", apply: func(f *mockfs.MockFile) error { return writeString(f, "hh", 50) }},
+	}
+
+	files := map[string]*mockfs.MockFile{}
+	for _, name := range []string{"plain", "generated"} {
+		f, err := mfs.OpenMockFile(name)
+		requireNoError(t, err)
+		defer f.Close()
+		files[name] = f
+	}
+
+	for _, step := range steps {
+		for name, f := range files {
+			if err := step.apply(f); err != nil {
+				t.Fatalf("%s on %s: %v", step.name, name, err)
+			}
+		}
+		want, got := mustReadFile(t, mfs, "plain"), mustReadFile(t, mfs, "generated")
+		if !bytes.Equal(got, want) {
+			t.Fatalf("after %s: generated = %q, want %q", step.name, got, want)
+		}
+	}
+}
+
+// writeString writes s at off through f.
+func writeString(f *mockfs.MockFile, s string, off int64) error {
+	_, err := f.WriteAt([]byte(s), off)
+	return err
+}
+
+func TestGenerated_Writes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		opts  []mockfs.FsOption
+		write func(m *mockfs.MockFS) error
+		want  string
+	}{
+		{
+			name:  "write file replaces the content",
+			write: func(m *mockfs.MockFS) error { return m.WriteFile("f", []byte("new"), 0o644) },
+			want:  "new",
+		},
+		{
+			name:  "write file appends",
+			opts:  []mockfs.FsOption{mockfs.WithAppend()},
+			write: func(m *mockfs.MockFS) error { return m.WriteFile("f", []byte("!"), 0o644) },
+			want:  "abcab!",
+		},
+		{
+			name: "append handle",
+			write: func(m *mockfs.MockFS) error {
+				f, err := m.OpenFile("f", os.O_WRONLY|os.O_APPEND, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				_, err = f.Write([]byte("12"))
+				return err
+			},
+			want: "abcab12",
+		},
+		{
+			name: "truncating open",
+			write: func(m *mockfs.MockFS) error {
+				f, err := m.OpenFile("f", os.O_WRONLY|os.O_TRUNC, 0)
+				requireNoError(t, err)
+				return f.Close()
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]mockfs.FsOption{mockfs.File("f", mockfs.Generated(5, mockfs.Pattern([]byte("abc"))))}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			requireNoError(t, tt.write(mfs))
+			if got := string(mustReadFile(t, mfs, "f")); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerated_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.Dir("d", mockfs.File("f", mockfs.Generated(6, mockfs.Pattern([]byte("ab"))))))
+
+	// A synced write survives a crash; a later one does not
+	f, err := mfs.OpenMockFile("d/f")
+	requireNoError(t, err)
+	requireNoError(t, writeString(f, "X", 1))
+	requireNoError(t, f.Sync())
+	requireNoError(t, writeString(f, "Y", 3))
+	requireNoError(t, f.Close())
+	requireNoError(t, mfs.Crash())
+	if got := string(mustReadFile(t, mfs, "d/f")); got != "aXabab" {
+		t.Errorf("content after crash = %q, want %q", got, "aXabab")
+	}
+
+	// A sub-filesystem gets a copy
+	subFS, err := mfs.Sub("d")
+	requireNoError(t, err)
+	sub := subFS.(*mockfs.MockFS)
+	requireNoError(t, sub.Truncate("f", 2))
+	if got := string(mustReadFile(t, mfs, "d/f")); got != "aXabab" {
+		t.Errorf("content after truncating the copy = %q, want %q", got, "aXabab")
+	}
+	if got := string(mustReadFile(t, sub, "f")); got != "aX" {
+		t.Errorf("copy = %q, want %q", got, "aX")
+	}
+
+	// AddFile replaces the file with generated content
+	requireNoError(t, mfs.AddFile("d/f", mockfs.Generated(3, mockfs.Zeros())))
+	if got := mustReadFile(t, mfs, "d/f"); !bytes.Equal(got, make([]byte, 3)) {
+		t.Errorf("content after AddFile = %q, want 3 zero bytes", got)
+	}
+	assertAnyError(t, mfs.AddFile("d/g", mockfs.Generated(-1, mockfs.Zeros())))
+}
+
+func TestGenerated_GeneratorError(t *testing.T) {
+	t.Parallel()
+
+	errGenerator := errors.New("generator failed")
+	mfs := mockfs.MustNewMockFS(mockfs.File("f", mockfs.Generated(10, failingReader{err: errGenerator})))
+
+	_, err := mfs.ReadFile("f")
+	assertError(t, err, errGenerator)
+	assertAnyError(t, mfs.AddFile("g", mockfs.Generated(1, nil)))
+}
+
+func TestFile_ReaderAtContent(t *testing.T) {
+	t.Parallel()
+
+	// A reader is read once, from its current offset, like any io.Reader
+	r := strings.NewReader("hello world")
+	_, err := io.ReadFull(r, make([]byte, len("hello ")))
+	requireNoError(t, err)
+	buf := []byte("data")
+	mfs := mockfs.MustNewMockFS(mockfs.File("partial", r), mockfs.File("buffer", bytes.NewReader(buf)))
+
+	if got := string(mustReadFile(t, mfs, "partial")); got != "world" {
+		t.Errorf("content of a partly read reader = %q, want %q", got, "world")
+	}
+	buf[0] = 'X'
+	if got := string(mustReadFile(t, mfs, "buffer")); got != "data" {
+		t.Errorf("content after changing the reader's buffer = %q, want %q", got, "data")
+	}
+}
+
+func TestGenerated_Corruption(t *testing.T) {
+	t.Parallel()
+
+	rule, err := mockfs.NewCorruptionRule(mockfs.StaleContent(), mockfs.ErrorModeAlways, 0, mockfs.NewExactMatcher("f"))
+	requireNoError(t, err)
+	mfs := mockfs.MustNewMockFS(mockfs.File("f", mockfs.Generated(4, mockfs.Pattern([]byte("ab")))))
+	mfs.ErrorInjector().Add(mockfs.OpRead, rule)
+
+	f, err := mfs.OpenMockFile("f")
+	requireNoError(t, err)
+	requireNoError(t, writeString(f, "XY", 0))
+	requireNoError(t, f.Close())
+
+	if got := string(mustReadFile(t, mfs, "f")); got != "abab" {
+		t.Errorf("stale content = %q, want %q", got, "abab")
+	}
+}
@@ -0,0 +1,202 @@
+package mockfs
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing/fstest"
+)
+
+// Corruption rewrites the bytes a read sees, without the read reporting an
+// error. It receives a copy of the file's data, which it may modify in place,
+// and the file's previous version (see StaleContent), and returns the data to
+// read from. The result may be shorter or longer than data.
+type Corruption func(data, previous []byte) []byte
+
+// FlipBits returns a Corruption that flips each bit with probability rate,
+// using a generator seeded with seed. The same seed flips the same bits of
+// the same data on every read, like rot on a disk, so a failing run can be
+// replayed. A rate outside [0, 1] is clamped.
+func FlipBits(rate float64, seed uint64) Corruption {
+	rate = min(max(rate, 0), 1)
+
+	return func(data, _ []byte) []byte {
+		rng := rand.New(rand.NewPCG(seed, seed)) //nolint:gosec // deterministic fault injection, not security
+		for i := range data {
+			for bit := range 8 {
+				if rng.Float64() < rate {
+					data[i] ^= 1 << bit
+				}
+			}
+		}
+		return data
+	}
+}
+
+// ZeroRange returns a Corruption that reads the length bytes starting at off
+// as zeros, like a page that was never written. The part of the range past
+// the end of the data is ignored; a negative off or length is treated as 0.
+func ZeroRange(off, length int64) Corruption {
+	return func(data, _ []byte) []byte {
+		size := int64(len(data))
+		start := min(max(off, 0), size)
+		end := start + min(max(length, 0), size-start)
+		clear(data[start:end])
+		return data
+	}
+}
+
+// TruncateTail returns a Corruption that drops the last n bytes of the data,
+// like a file whose tail was lost. A negative n is treated as 0.
+func TruncateTail(n int64) Corruption {
+	return func(data, _ []byte) []byte {
+		return data[:int64(len(data))-min(max(n, 0), int64(len(data)))]
+	}
+}
+
+// StaleContent returns a Corruption that reads the previous version of the
+// file, like a lost write or a stale cache. The previous version is the data
+// the file had before its latest change: the last WriteFile or Truncate, or
+// the first write or truncation through the most recent handle that changed
+// it. A newly created file's previous version is empty. A file that was never
+// changed, such as a standalone MockFile, reads unchanged.
+//
+// Previous versions are only kept while the injector has a corruption rule,
+// so that files cost no extra memory otherwise: add the rule before the
+// change it is to lose. A file last changed while there was none reads
+// unchanged.
+func StaleContent() Corruption {
+	return func(data, previous []byte) []byte {
+		if previous == nil {
+			return data
+		}
+		return bytes.Clone(previous)
+	}
+}
+
+// NewCorruptionRule creates a rule for silent data corruption. Added for
+// OpRead, it matches like any other rule, and when it fires the Read or ReadAt
+// (and so MockFS.ReadFile) succeeds with the data rewritten by c. Partial
+// transfers and range rules then apply to the corrupted data. A read that
+// finds no data, at the end of the file, does not count as a call for
+// corruption rules. Other operations skip corruption rules.
+//
+// Returns an error wrapping ErrUsage if c is nil, mode is invalid, or after
+// is negative for ErrorModeAfterSuccesses or ErrorModeNext.
+func NewCorruptionRule(c Corruption, mode ErrorMode, after int, matchers ...PathMatcher) (*ErrorRule, error) {
+	if c == nil {
+		return nil, fmt.Errorf("mockfs: %w: nil Corruption", ErrUsage)
+	}
+
+	rule, err := NewErrorRule(nil, mode, after, matchers...)
+	if err != nil {
+		return nil, err
+	}
+	rule.corrupt = c
+
+	return rule, nil
+}
+
+// corrupts reports whether the rule is a corruption rule.
+func (r *ErrorRule) corrupts() bool {
+	return r.corrupt != nil
+}
+
+// corrupter is implemented by injectors that support corruption rules.
+type corrupter interface {
+	corruption(op Operation, path string) Corruption
+	hasCorruption() bool
+}
+
+// Ensure errorInjector implements corrupter.
+var _ corrupter = (*errorInjector)(nil)
+
+// corruption returns the Corruption of the first firing corruption rule,
+// or nil if none fires.
+func (ei *errorInjector) corruption(op Operation, path string) Corruption {
+	if r, _ := ei.fire(op, path, (*ErrorRule).corrupts); r != nil {
+		return r.corrupt
+	}
+	return nil
+}
+
+// corruptionFor returns the Corruption to apply to an operation, or nil if
+// there is none or the injector does not support corruption rules.
+func corruptionFor(injector ErrorInjector, op Operation, path string) Corruption {
+	if c, ok := injector.(corrupter); ok {
+		return c.corruption(op, path)
+	}
+	return nil
+}
+
+// keepsVersions reports whether injector has a corruption rule, the only
+// reader of previous versions. Without one, no version is copied.
+func keepsVersions(injector ErrorInjector) bool {
+	c, ok := injector.(corrupter)
+	return ok && c.hasCorruption()
+}
+
+// hasCorruption reports whether any corruption rule is registered.
+func (ei *errorInjector) hasCorruption() bool {
+	ei.mu.RLock()
+	defer ei.mu.RUnlock()
+
+	for _, rules := range ei.configs {
+		if slices.ContainsFunc(rules, (*ErrorRule).corrupts) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveVersion keeps the current data as the inode's previous version if
+// injector has a corruption rule, and forgets the previous version otherwise,
+// so a rule added later does not read a version older than the latest change.
+// Call it before a change that starts a new version of the file.
+func (n *inode) saveVersion(injector ErrorInjector) {
+	if !keepsVersions(injector) {
+		n.previous, n.previousVirt = nil, nil
+		return
+	}
+	n.previous = append([]byte{}, n.Data...)
+	n.previousVirt = n.virt.clone()
+}
+
+// beginVersion saves the file's previous version before the first change
+// made through this handle. Caller must hold f.mu.
+func (f *MockFile) beginVersion() {
+	if !f.versioned {
+		f.node.saveVersion(f.injector)
+		f.versioned = true
+	}
+}
+
+// readSource returns the inode a read of n bytes at off sees: the file's own,
+// or a copy with corrupted content if a corruption rule fires. A read that
+// finds no data, at or past the end of the file, fires no corruption rule.
+// Corrupting generated content reads all of it.
+// Caller must hold f.mu.
+func (f *MockFile) readSource(off int64, n int) (*inode, error) {
+	if n == 0 || off < 0 || off >= f.node.size() {
+		return f.node, nil
+	}
+
+	c := corruptionFor(f.injector, OpRead, f.name)
+	if c == nil {
+		return f.node, nil
+	}
+
+	data, err := f.node.content()
+	if err != nil {
+		return nil, err
+	}
+	previous := f.node.previous
+	if f.node.previousVirt != nil {
+		if previous, err = f.node.previousVirt.bytes(); err != nil {
+			return nil, err
+		}
+	}
+
+	return newStandaloneInode(&fstest.MapFile{Data: c(bytes.Clone(data), previous)}), nil
+}
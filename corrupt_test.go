@@ -0,0 +1,275 @@
+package mockfs_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestCorruptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		c        mockfs.Corruption
+		data     string
+		previous []byte
+		want     string
+	}{
+		{name: "zero range", c: mockfs.ZeroRange(2, 3), data: "abcdefg", want: "ab\x00\x00\x00fg"},
+		{name: "zero range past end", c: mockfs.ZeroRange(5, 100), data: "abcdefg", want: "abcde\x00\x00"},
+		{name: "zero range beyond data", c: mockfs.ZeroRange(10, 2), data: "abc", want: "abc"},
+		{name: "zero range negative", c: mockfs.ZeroRange(-1, -1), data: "abc", want: "abc"},
+		{name: "truncate tail", c: mockfs.TruncateTail(3), data: "abcdefg", want: "abcd"},
+		{name: "truncate whole", c: mockfs.TruncateTail(100), data: "abc", want: ""},
+		{name: "truncate negative", c: mockfs.TruncateTail(-1), data: "abc", want: "abc"},
+		{name: "stale content", c: mockfs.StaleContent(), data: "new", previous: []byte("old"), want: "old"},
+		{name: "stale content without previous", c: mockfs.StaleContent(), data: "new", want: "new"},
+		{name: "flip no bits", c: mockfs.FlipBits(0, 1), data: "abc", want: "abc"},
+		{name: "flip every bit", c: mockfs.FlipBits(2, 1), data: "\x00\xff", want: "\xff\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := string(tt.c([]byte(tt.data), tt.previous)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlipBits_Replayable(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte{0}, 1024)
+	first := mockfs.FlipBits(0.01, 7)(bytes.Clone(data), nil)
+	second := mockfs.FlipBits(0.01, 7)(bytes.Clone(data), nil)
+
+	if !bytes.Equal(first, second) {
+		t.Error("same seed flipped different bits")
+	}
+	if bytes.Equal(first, data) {
+		t.Error("no bits flipped at rate 0.01 over 8192 bits")
+	}
+}
+
+func TestNewCorruptionRule_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := mockfs.NewCorruptionRule(nil, mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "nil corruption")
+
+	_, err = mockfs.NewCorruptionRule(mockfs.StaleContent(), mockfs.ErrorMode(99), 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "invalid mode")
+
+	assertError(t, mockfs.MustNewMockFS().CorruptRead("blob", nil), mockfs.ErrUsage)
+}
+
+func TestMockFS_CorruptRead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("read file succeeds with wrong bytes", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("blob", "0123456789"))
+		requireNoError(t, mfs.CorruptRead("blob", mockfs.ZeroRange(4, 2)))
+
+		got, err := mfs.ReadFile("blob")
+		requireNoError(t, err)
+		if string(got) != "0123\x00\x006789" {
+			t.Errorf("ReadFile = %q, want %q", got, "0123\x00\x006789")
+		}
+
+		// The stored data is untouched
+		mfs.ClearErrors()
+		if got := string(mustReadFile(t, mfs, "blob")); got != "0123456789" {
+			t.Errorf("stored = %q, want %q", got, "0123456789")
+		}
+	})
+
+	t.Run("read at sees a truncated tail", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("blob", "0123456789"))
+		requireNoError(t, mfs.CorruptRead("blob", mockfs.TruncateTail(4)))
+
+		f, err := mfs.OpenMockFile("blob")
+		requireNoError(t, err)
+		defer f.Close()
+
+		buf := make([]byte, 8)
+		n, err := f.ReadAt(buf, 2)
+		assertError(t, err, io.EOF)
+		if string(buf[:n]) != "2345" {
+			t.Errorf("ReadAt = %q, want %q", buf[:n], "2345")
+		}
+	})
+
+	t.Run("only matching paths", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("blob", "data"), mockfs.File("other", "data"))
+		requireNoError(t, mfs.CorruptRead("blob", mockfs.TruncateTail(4)))
+
+		if got := string(mustReadFile(t, mfs, "other")); got != "data" {
+			t.Errorf("other = %q, want %q", got, "data")
+		}
+	})
+
+	t.Run("once mode corrupts a single read", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("blob", "abcd")
+		rule, err := mockfs.NewCorruptionRule(mockfs.ZeroRange(0, 4), mockfs.ErrorModeOnce, 0, mockfs.NewExactMatcher("blob"))
+		requireNoError(t, err)
+		f.ErrorInjector().Add(mockfs.OpRead, rule)
+
+		buf := make([]byte, 4)
+		_, err = f.ReadAt(buf, 0)
+		requireNoError(t, err)
+		if !bytes.Equal(buf, make([]byte, 4)) {
+			t.Errorf("first read = %q, want zeros", buf)
+		}
+		_, err = f.ReadAt(buf, 0)
+		requireNoError(t, err)
+		if string(buf) != "abcd" {
+			t.Errorf("second read = %q, want %q", buf, "abcd")
+		}
+	})
+
+	t.Run("other operations skip corruption rules", func(t *testing.T) {
+		t.Parallel()
+
+		inj := mockfs.NewErrorInjector()
+		rule, err := mockfs.NewCorruptionRule(mockfs.StaleContent(), mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+		requireNoError(t, err)
+		inj.Add(mockfs.OpRead, rule)
+
+		assertNoError(t, inj.CheckAndApply(mockfs.OpRead, "any"))
+	})
+}
+
+func TestMockFS_CorruptRead_StaleContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, m *mockfs.MockFS)
+		want   string
+	}{
+		{
+			name: "never changed",
+			want: "v1",
+		},
+		{
+			name: "write file",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.WriteFile("manifest", []byte("v2"), 0o644))
+			},
+			want: "v1",
+		},
+		{
+			name: "newly created",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.RemoveEntry("manifest"))
+				requireNoError(t, m.WriteFile("manifest", []byte("v2"), 0o644))
+			},
+			want: "",
+		},
+		{
+			name: "several writes through one handle",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				f, err := m.OpenFile("manifest", os.O_RDWR, 0)
+				requireNoError(t, err)
+				for _, s := range []string{"v", "2", "-final"} {
+					_, err = f.Write([]byte(s))
+					requireNoError(t, err)
+				}
+				requireNoError(t, f.Close())
+			},
+			want: "v1",
+		},
+		{
+			name: "truncate on open",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				f, err := m.Create("manifest")
+				requireNoError(t, err)
+				_, err = f.Write([]byte("v2"))
+				requireNoError(t, err)
+				requireNoError(t, f.Close())
+			},
+			want: "v1",
+		},
+		{
+			name: "latest of two versions",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.WriteFile("manifest", []byte("v2"), 0o644))
+				requireNoError(t, m.Truncate("manifest", 1))
+			},
+			want: "v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.WithCreateIfMissing(true), mockfs.File("manifest", "v1"))
+			requireNoError(t, mfs.CorruptRead("manifest", mockfs.StaleContent()))
+			if tt.change != nil {
+				tt.change(t, mfs)
+			}
+
+			if got := string(mustReadFile(t, mfs, "manifest")); got != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockFS_CorruptRead_VersionsNeedARule(t *testing.T) {
+	t.Parallel()
+
+	// Without a corruption rule at the time of the write, no version is kept
+	mfs := mockfs.MustNewMockFS(mockfs.File("manifest", "v1"))
+	requireNoError(t, mfs.WriteFile("manifest", []byte("v2"), 0o644))
+	requireNoError(t, mfs.CorruptRead("manifest", mockfs.StaleContent()))
+	if got := string(mustReadFile(t, mfs, "manifest")); got != "v2" {
+		t.Errorf("read %q, want %q", got, "v2")
+	}
+}
+
+func TestMockFS_CorruptRead_EOFDoesNotFire(t *testing.T) {
+	t.Parallel()
+
+	rule, err := mockfs.NewCorruptionRule(mockfs.ZeroRange(0, 2), mockfs.ErrorModeOnce, 0, mockfs.NewExactMatcher("f"))
+	requireNoError(t, err)
+	mfs := mockfs.MustNewMockFS(mockfs.File("f", "data"))
+	mfs.ErrorInjector().Add(mockfs.OpRead, rule)
+
+	f, err := mfs.OpenMockFile("f")
+	requireNoError(t, err)
+	defer f.Close()
+
+	// A read at the end returns no data and leaves the rule armed
+	buf := make([]byte, 4)
+	n, err := f.ReadAt(buf, 4)
+	assertError(t, err, io.EOF)
+	if n != 0 {
+		t.Errorf("ReadAt at the end = %d bytes, want 0", n)
+	}
+	n, err = f.ReadAt(buf, 0)
+	requireNoError(t, err)
+	if got := string(buf[:n]); got != "\x00\x00ta" {
+		t.Errorf("ReadAt = %q, want %q", got, "\x00\x00ta")
+	}
+}
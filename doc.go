@@ -6,7 +6,7 @@
 //   - Configurable error injection for any filesystem operation and path.
 //   - Simulated latency to test timeout and race condition handling.
 //   - Operation counters for verifying filesystem access patterns.
-//   - Comprehensive [io/fs] interface implementation: fs.FS, fs.ReadDirFS, fs.ReadFileFS, fs.StatFS, fs.SubFS
+//   - Comprehensive [io/fs] interface implementation: fs.FS, fs.ReadDirFS, fs.ReadFileFS, fs.StatFS, fs.SubFS, fs.ReadLinkFS
 //   - Writable filesystem operations (Mkdir, Remove, Rename, WriteFile, etc.).
 //   - Full concurrency safety.
 //
@@ -65,6 +65,14 @@
 //	f, _ := mfs.Open("file.txt")
 //	fileStats = f.(*mockfs.MockFile).Stats()
 //
+// HandleStats sums the statistics of every handle the filesystem has opened,
+// closed or not, including the handle ReadFile uses internally; AllStats adds
+// the filesystem-level operations to them:
+//
+//	_, _ = mfs.ReadFile("file.txt")
+//	mfs.HandleStats().BytesRead()        // 7
+//	mfs.AllStats().Count(mockfs.OpOpen)  // 1
+//
 // # Error Injection
 //
 // Inject errors to simulate I/O failures. Use convenience methods for common cases:
@@ -100,6 +108,55 @@
 //   - ErrorModeOnce: Error returned once, then rule becomes inactive
 //   - ErrorModeAfterSuccesses: Error returned after N successful operations
 //   - ErrorModeNext: Error returned next N times, then rule becomes inactive
+//   - ErrorModeEvery: Error returned on every Nth operation
+//   - ErrorModeScript: Outcomes follow a script (NewScriptRule, NewCallsRule)
+//   - ErrorModeRandom: Error returned with a seeded probability (NewChaosRule)
+//
+// Scripts model flaky behaviour that the fixed modes cannot. Each outcome is
+// used for one call; nil succeeds, and a trailing ErrRepeat loops the script:
+//
+//	_ = mfs.FailScript(mockfs.OpRead, "data.bin", errAgain, errAgain, mockfs.ErrTimeout) // then succeed
+//	_ = mfs.FailScript(mockfs.OpWrite, "out.bin", nil, mockfs.ErrDiskFull, mockfs.ErrRepeat)
+//	_ = mfs.FailCalls(mockfs.OpOpen, "db", mockfs.ErrTimeout, 3, 7, 8)
+//	_ = mfs.FailEvery(mockfs.OpSync, "db", mockfs.ErrTimeout, 4)
+//
+// Chaos tests fail operations at random from a seed, so a failing run can be
+// replayed exactly. Every random rule built on the same Chaos draws from its
+// generator (ErrorModeRandom), and the Chaos records the faults it injects:
+//
+//	chaos, _ := mfs.InjectChaos(seed, map[mockfs.Operation]float64{
+//		mockfs.OpUnknown: 0.01, // every operation...
+//		mockfs.OpWrite:   0.1,  // ...but writes fail more often
+//	}, mockfs.ErrTimeout, mockfs.ErrDiskFull)
+//	chaos.ReportOnFailure(t) // logs the seed and the faults if t fails
+//
+// Partial transfers model reads and writes that move fewer bytes than asked
+// for. NewShortRule builds them from a TransferLimit (LimitBytes, LimitRandom)
+// and works with every matcher and mode; Stats record the bytes actually moved:
+//
+//	_ = mfs.ShortRead("data.bin", 3)                      // Read returns at most 3 bytes, no error
+//	_ = mfs.ShortReadRandom("data.bin", seed)             // seeded random-length prefixes
+//	_ = mfs.ShortWrite("out.bin", 4, mockfs.ErrDiskFull)  // write 4 bytes, then fail
+//
+//	rule, _ := mockfs.NewShortRule(mockfs.LimitBytes(1), nil, mockfs.ErrorModeNext, 3,
+//		mockfs.NewExactMatcher("data.bin"))
+//	injector.Add(mockfs.OpRead, rule)
+//
+// Range rules (NewRangeRule) fire only when a transfer touches a given byte
+// range, like a bad sector: the bytes before the range are moved and then the
+// error is returned.
+//
+//	_ = mfs.FailReadRange("disk.img", 4096, 512, mockfs.ErrCorrupted)
+//	_ = mfs.FailWriteRange("disk.img", 8192, 512, mockfs.ErrDiskFull)
+//
+// Corruption rules (NewCorruptionRule) are silent: the read succeeds, but
+// with wrong bytes. FlipBits, ZeroRange, TruncateTail, and StaleContent cover
+// bit rot, unwritten pages, lost tails, and lost writes. Previous versions
+// are only kept while a corruption rule exists, so add StaleContent before
+// the write it loses:
+//
+//	_ = mfs.CorruptRead("blob", mockfs.FlipBits(0.001, seed))
+//	_ = mfs.CorruptRead("manifest.json", mockfs.StaleContent())
 //
 // # Latency Simulation
 //
@@ -136,12 +193,176 @@
 //	mfs.RemoveAll("cache")
 //	mfs.Rename("old.txt", "new.txt")
 //
+// OpenFile and Create mirror os.OpenFile and os.Create, honouring the os.O_*
+// flags per handle (access mode, O_CREATE, O_EXCL, O_TRUNC, O_APPEND).
+// Without O_APPEND, their handles write at the current offset like os.File:
+//
+//	f, _ := mfs.OpenFile("app.log", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+//	f.Write([]byte("started\n"))
+//	f.Close()
+//
+// Chmod, Chtimes and Chown mirror their os counterparts and are also available
+// on open *MockFile handles. The access time and owner are reported through
+// FileInfo.Sys as an *InodeInfo:
+//
+//	mfs.Chmod("secret.key", 0o600)
+//	mfs.Chtimes("app.log", atime, mtime)
+//	mfs.Chown("data", 1000, 1000)
+//
+// Truncate shrinks a file or zero-extends it, by name or through a handle:
+//
+//	mfs.Truncate("wal.log", 0)
+//
 // Write modes:
 //   - WithOverwrite(): Replace existing content (default)
 //   - WithAppend(): Append to existing content
+//   - WithPositional(): Write at the handle's offset, like os.File
 //   - WithReadOnly(): Disable all writes
 //   - WithCreateIfMissing(true): Create files if they don't exist
 //
+// # Permissions
+//
+// Permission bits are not enforced by default. WithIdentity(uid, gids...)
+// simulates a user and enforces owner, group and other bits, including
+// search permission on every ancestor directory, returning ErrPermission
+// where a real filesystem would (see WithPermissionChecks for the rules):
+//
+//	mfs := mockfs.MustNewMockFS(
+//	    mockfs.WithIdentity(1000, 1000),
+//	    mockfs.File("secret.key", "key", 0o000),
+//	)
+//	_, err := mfs.ReadFile("secret.key") // ErrPermission
+//
+// # Disk Space
+//
+// WithCapacity sets the size of the simulated disk, WithInodeLimit the number
+// of entries it holds, and WithQuota the space available below a directory.
+// Writes that run out of space store what fits and fail with ErrDiskFull, as
+// a full disk does; entries that do not fit are not created. Removing a file
+// frees its space once its last handle is closed. Usage reports the space in
+// use:
+//
+//	mfs := mockfs.MustNewMockFS(mockfs.WithCapacity(10 << 20), mockfs.WithQuota("logs", 1 << 20))
+//	n, err := f.Write(buf) // n < len(buf) and ErrDiskFull once the disk is full
+//	mfs.Usage().Bytes
+//
+// # Generated Files
+//
+// A file whose content is made by Generated is produced on demand instead of
+// being held in memory, so tests can use files far larger than the memory
+// available. Generated pairs a size with a generator: Zeros, Pattern, or
+// Random for reproducible pseudo-random bytes. Reads stream from the
+// generator; writes keep only the ranges they change, and Truncate cuts or
+// zero-extends the content without reading it. A corruption rule that fires
+// on such a file reads the whole content.
+//
+//	mfs := mockfs.MustNewMockFS(
+//	    mockfs.File("disk.img", mockfs.Generated(10<<30, mockfs.Zeros())),
+//	    mockfs.File("noise.bin", mockfs.Generated(1<<20, mockfs.Random(42))),
+//	)
+//
+// # Dynamic Files
+//
+// A file whose content is a DynamicContent, made with Dynamic, computes its
+// content with a function at every Open, like the entries of /proc, and Stat
+// reports the size of the content computed by the latest Open. Otherwise it
+// is an ordinary file, listed by ReadDir and subject to statistics, error
+// injection, and latency:
+//
+//	mockfs.File("status", mockfs.Dynamic(func() (any, error) {
+//	    return fmt.Sprintf("requests: %d\n", requests.Load()), nil
+//	}))
+//
+// # Symbolic Links
+//
+// Symbolic links are created with the Symlink builder option or MockFS.Symlink.
+// Open, Stat, ReadDir, ReadFile, OpenFile and WriteFile follow links; Lstat and
+// ReadLink (fs.ReadLinkFS) inspect the link itself, and Remove and Rename act
+// on the link, not its target. A link cycle fails with ErrSymlinkLoop:
+//
+//	mfs := mockfs.MustNewMockFS(
+//	    mockfs.Dir("releases", mockfs.Dir("v2")),
+//	    mockfs.Symlink("current", "releases/v2"),
+//	)
+//	target, _ := mfs.ReadLink("current") // "releases/v2"
+//	info, _ := mfs.Stat("current")       // describes releases/v2
+//
+// # Inodes and Hard Links
+//
+// Every entry refers to an inode, and Link adds further names for the same
+// inode, mirroring os.Link. Handles follow the inode rather than the name they
+// were opened by, with POSIX semantics:
+//   - After Rename, open handles read and write the file under its new name.
+//   - After Remove, a file stays readable and writable through handles that
+//     are still open; its data is released with the last Close.
+//   - Rename onto an existing file replaces it atomically; handles open on the
+//     replaced file keep its old content.
+//
+// FileInfo.Sys reports the inode number and link count as an *InodeInfo:
+//
+//	mfs.Link("app.log", "app.log.1")
+//	info, _ := mfs.Stat("app.log")
+//	info.Sys().(*mockfs.InodeInfo).Nlink // 2
+//
+// # Durability and Crashes
+//
+// Writes change the filesystem immediately, but only (*MockFile).Sync makes
+// them durable. Crash simulates a power loss by rolling every file back to
+// its last-synced contents and every directory back to the entries it had
+// when it was last synced, so crash-safety protocols can be tested:
+//
+//	f, _ := mfs.Create("db.tmp")
+//	f.Write(data)
+//	f.Sync()                     // file contents are durable
+//	f.Close()
+//	mfs.Rename("db.tmp", "db")
+//	dir, _ := mfs.OpenMockFile(".")
+//	dir.Sync()                   // the rename is durable
+//	mfs.Crash()
+//
+// WithTornWrite keeps part of the last unsynced write of each file, to test
+// recovery from writes torn by the crash. The fixture counts as synced.
+//
+// # Fault Sweeps
+//
+// ForEachFault answers "what if this call fails?" for every call a piece of
+// code makes. It records the calls of one clean run, then reruns the code on
+// a fresh fixture once per call with exactly that call failing, and reports
+// the points that led to panics, hangs, or leaked handles:
+//
+//	mockfs.ForEachFault(t, newFixture, func(m *mockfs.MockFS) error {
+//		return saveConfig(m, cfg)
+//	}, mockfs.ErrDiskFull)
+//
+// FaultSweep adds a timeout and a Check callback that validates the
+// filesystem after each faulted run.
+//
+// For fuzzing, DecodeFaultSchedule turns the bytes of a fuzz input into a
+// deterministic FaultSchedule: which calls fail and with which sentinel, short
+// transfers, and delays. Its Injector wraps any ErrorInjector, so a failing
+// input is a replayable fault scenario:
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//		sched := mockfs.DecodeFaultSchedule(data)
+//		mfs := mockfs.MustNewMockFS(mockfs.WithErrorInjector(sched.Injector(nil)))
+//		if err := saveConfig(mfs, cfg); err == nil {
+//			verify(t, mfs)
+//		}
+//	})
+//
+// When a randomized or scheduled run fails, the error injector's record of
+// the faults it injected (MockFS.InjectedFaults) feeds ShrinkFaults, which
+// delta-debugs the list down to a minimal set that still reproduces the
+// failure. FaultsAsCode prints that set as FailXOnce, FailCalls, or
+// FailScript calls to paste into a regression test:
+//
+//	minimal := mockfs.ShrinkFaults(mfs.InjectedFaults(), func(faults []mockfs.InjectedFault) bool {
+//		m := newFixture()
+//		_ = m.InjectFaults(faults)
+//		return saveConfig(m, cfg) != nil && !recovered(m)
+//	})
+//	t.Logf("minimal faults:\n%s", mockfs.FaultsAsCode(minimal))
+//
 // # Standalone File Testing
 //
 // Create MockFile instances without a filesystem for testing functions that
@@ -219,9 +440,76 @@
 //	    NoFailures().
 //	    Assert(t)
 //
+//	// Per-path counters
+//	stats.ForPath("config.yaml").Count(mockfs.OpRead)
+//	cache, _ := mockfs.NewGlobMatcher("cache/*")
+//	mfs.Stats().Expect().
+//	    PathCount("config.yaml", mockfs.OpRead, 1).
+//	    MatcherCount(cache, mockfs.OpWrite, 0).
+//	    Assert(t)
+//
 //	// Reset counters
 //	mfs.ResetStats()
 //
+// # Operation Journal
+//
+// Stats count operations; the journal keeps their order. With WithJournal,
+// the filesystem and every handle it opens record each operation as a
+// JournalEntry: path (both paths for Rename and Link), handle ID, offset,
+// byte count, error, whether the error was injected, simulated latency, and
+// start time (the fake clock inside a testing/synctest bubble). Journal
+// returns the entries in completion order as an iter.Seq, filtered with
+// JournalPath and JournalOps:
+//
+//	var ops []mockfs.Operation
+//	for e := range mfs.Journal(mockfs.JournalPath("db.tmp")) {
+//		ops = append(ops, e.Op) // [Open Write Sync Close Rename]
+//	}
+//
+// Expect turns the journal into a gomock-style assertion on the order of the
+// calls. Calls are expected in order, each once unless Times, AtLeast, or
+// AnyTimes says otherwise; Unordered and InOrder group calls, and Match takes
+// a PathMatcher. An expected operation called too often or out of order
+// fails, and Strict also fails on operations that were not expected at all.
+// A mismatch is reported as a diff of the journal against the expectation:
+//
+//	mfs.Expect().
+//		Open("cfg.tmp").
+//		Write("cfg.tmp").AtLeast(1).
+//		Close("cfg.tmp").
+//		Rename("cfg.tmp", "cfg").
+//		Assert(t)
+//
+// # Watching Changes
+//
+// Watch reports changes below a path as fsnotify-style events (EventCreate,
+// EventWrite, EventRemove, EventRename, EventChmod) on a channel, so that
+// hot-reload and watcher code can be tested. WithCoalescing, WithEventBuffer,
+// and WithEventDelay make the watcher merge, drop, or delay events, to test
+// the handling of a lossy or slow watcher; dropped events are reported as
+// ErrEventOverflow on the Errors channel:
+//
+//	w, err := mfs.Watch("config", true, mockfs.WithEventBuffer(16))
+//	defer w.Close()
+//	_ = mfs.WriteFile("config/app.yaml", data, 0o644)
+//	ev := <-w.Events // WRITE "config/app.yaml"
+//
+// # Open Handles
+//
+// OpenHandles lists the handles that have not been closed, each with its
+// path, open flags, open time, and the call stack of the Open. CheckLeaks
+// fails the test at cleanup if any handle is left open, showing where each
+// one was opened:
+//
+//	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "content"))
+//	mockfs.CheckLeaks(t, mfs)
+//
+// WithMaxOpenFiles caps the number of open handles, like the file descriptor
+// limit of a process: once it is reached, Open and OpenFile fail with
+// ErrTooManyHandles (EMFILE) until a handle is closed:
+//
+//	mfs := mockfs.MustNewMockFS(mockfs.WithMaxOpenFiles(64))
+//
 // # Testing Philosophy
 //
 // MockFS is designed to expose bugs, not hide them:
@@ -272,12 +560,14 @@
 //
 // # Limitations
 //
-//   - Symlinks are not supported (mode can be set, but not followed).
-//   - File permissions (MapFile.Mode) are metadata only and not enforced.
-//     Use ErrorInjector to simulate permission errors explicitly.
+//   - Symlink targets are resolved within the MockFS only; ".." at the root
+//     stays at the root, and absolute targets are relative to the root.
+//   - File permissions (MapFile.Mode) are metadata only unless enabled with
+//     WithIdentity or WithPermissionChecks; there is no superuser, and
+//     ACLs and capabilities are not modelled.
 //   - Path cleaning uses lexical processing only (no filesystem queries).
-//   - Operations on open files may succeed even if the file is removed from the MockFS internal map
-//     (matching real filesystem behavior).
+//   - Hard links to directories are not supported (Link returns ErrPermission).
+//   - Crash rolls back data and directory entries, not mode, times, or ownership.
 //   - This package is not optimized for large filesystems.
 //   - ReadFile on a directory returns empty data without error (matches MapFS behaviour).
 //     Use Stat or Open+ReadDir to distinguish directories from empty files.
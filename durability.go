@@ -0,0 +1,300 @@
+package mockfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"testing/fstest"
+	"time"
+)
+
+// pendingWrite is a write made to an inode since its last sync.
+type pendingWrite struct {
+	off  int64  // Offset the data was written at.
+	data []byte // Copy of the written data.
+}
+
+// crashOptions holds the configuration of a simulated crash.
+type crashOptions struct {
+	tornBytes int // Bytes of each file's last unsynced write that survive.
+}
+
+// CrashOption is a function type for configuring MockFS.Crash.
+type CrashOption func(*crashOptions) error
+
+// WithTornWrite makes the first n bytes of each file's last unsynced write
+// survive the crash, on top of the file's synced contents, as if the power
+// failed part-way through that write. The rest of the unsynced data is lost.
+// Enumerating n from 0 to the length of the write covers every torn variant.
+// Returns an error wrapping ErrUsage from Crash if n is negative.
+func WithTornWrite(n int) CrashOption {
+	return func(o *crashOptions) error {
+		if n < 0 {
+			return fmt.Errorf("WithTornWrite: negative byte count %d", n)
+		}
+		o.tornBytes = n
+		return nil
+	}
+}
+
+// --- Inode durability ---
+
+// snapshot saves the synced data before the first change since the last sync.
+// Call it before changing Data.
+func (n *inode) snapshot() {
+	if !n.dirty {
+		n.synced = bytes.Clone(n.Data)
+		n.syncedVirt = n.virt.clone()
+		n.dirty = true
+	}
+}
+
+// recordWrite notes b, about to be written at off, as the inode's last
+// unsynced write. Call it before changing Data.
+func (n *inode) recordWrite(off int64, b []byte) {
+	n.snapshot()
+	n.lastWrite = &pendingWrite{off: off, data: bytes.Clone(b)}
+}
+
+// sync makes the inode's current data durable.
+func (n *inode) sync() {
+	n.dirty = false
+	n.synced = nil
+	n.syncedVirt = nil
+	n.lastWrite = nil
+}
+
+// durable returns a copy of the content the inode holds after a crash, as
+// an inode of its own: the synced content, with the first torn bytes of the
+// last unsynced write applied.
+func (n *inode) durable(torn int) *inode {
+	if !n.dirty {
+		return &inode{MapFile: &fstest.MapFile{Data: bytes.Clone(n.Data)}, virt: n.virt.clone()}
+	}
+
+	c := &inode{MapFile: &fstest.MapFile{Data: bytes.Clone(n.synced)}, virt: n.syncedVirt.clone()}
+	if w := n.lastWrite; w != nil && torn > 0 {
+		c.writeAt(w.data[:min(torn, len(w.data))], w.off)
+	}
+
+	return c
+}
+
+// --- MockFS durability ---
+
+// Crash simulates a power loss followed by a remount: every file, directory
+// entry, and rename is rolled back to its last-synced state.
+//
+// A file keeps the contents it had at its last (*MockFile).Sync; a file never
+// synced since it was created comes back empty. A directory keeps the entries
+// it had when a handle on it was last synced, so a created, removed, or
+// renamed entry only survives the crash once its directory has been synced,
+// as with fsync(2). Entries present when NewMockFS returned, and entries added
+// by AddFile and AddDir, count as synced. Mode, times, and ownership are not
+// rolled back. If the root directory itself was removed, it comes back empty.
+//
+// Handles open at the time of the crash are detached from the filesystem:
+// they keep working on the pre-crash contents, but nothing they do is visible
+// through the filesystem any more. Use WithTornWrite to keep part of the last
+// unsynced write of each file.
+//
+// Returns an error wrapping ErrUsage if an option is invalid, in which case
+// the filesystem is left unchanged.
+func (m *MockFS) Crash(opts ...CrashOption) error {
+	var o crashOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&o); err != nil {
+			return fmt.Errorf("mockfs: %w: failed to apply crash option: %w", ErrUsage, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files := make(map[string]*inode, len(m.files))
+	inodes := make(map[uint64]*inode, len(m.inodes))
+	restored := make(map[*inode]*inode)
+
+	// Surviving inodes are fresh copies, so detached handles cannot reach them
+	restore := func(n *inode) *inode {
+		durable := n.durable(o.tornBytes)
+		c := &inode{
+			MapFile: &fstest.MapFile{
+				Data:    durable.Data,
+				Mode:    n.Mode,
+				ModTime: n.ModTime,
+				Sys:     n.Sys,
+			},
+			virt:  durable.virt,
+			dyn:   n.dyn,
+			ino:   n.ino,
+			atime: n.atime,
+			uid:   n.uid,
+			gid:   n.gid,
+		}
+		restored[n] = c
+		inodes[c.ino] = c
+		return c
+	}
+
+	var walk func(dirPath string, dir *inode)
+	walk = func(dirPath string, dir *inode) {
+		for name, child := range dir.entries {
+			c, seen := restored[child]
+			if seen && child.Mode.IsDir() {
+				continue // stale snapshots can reach a directory twice
+			}
+			if !seen {
+				c = restore(child)
+			}
+
+			p := path.Join(dirPath, name)
+			files[p] = c
+			c.nlink++
+
+			if child.Mode.IsDir() {
+				walk(p, child)
+			}
+		}
+	}
+
+	root := m.files["."]
+	if root == nil {
+		// Removing "." removed the root entry; an empty root comes back
+		root = m.newInode(&fstest.MapFile{Mode: ModeDir | defaultDirPerm, ModTime: time.Now()})
+	}
+	files["."] = restore(root)
+	files["."].nlink++
+	walk(".", root)
+
+	// The synced entries of the survivors refer to the survivors
+	for n, c := range restored {
+		if n.entries != nil {
+			c.entries = make(map[string]*inode, len(n.entries))
+			for name, child := range n.entries {
+				c.entries[name] = restored[child]
+			}
+		}
+	}
+
+	m.files, m.inodes = files, inodes
+	return nil
+}
+
+// markDurable treats the whole current state of the filesystem as synced.
+// Caller must hold the write lock or own m exclusively.
+func (m *MockFS) markDurable() {
+	for _, n := range m.files {
+		n.sync()
+		if n.Mode.IsDir() {
+			n.entries = make(map[string]*inode)
+		}
+	}
+	for p, n := range m.files {
+		if p != "." {
+			m.files[path.Dir(p)].entries[path.Base(p)] = n
+		}
+	}
+}
+
+// persistPath makes the entry at cleanPath and the entries of its ancestors
+// durable, as if each had been synced along with its directory. It backs the
+// fixture helpers, whose changes survive Crash. Caller must hold the write lock.
+func (m *MockFS) persistPath(cleanPath string) {
+	for p := cleanPath; p != "."; p = path.Dir(p) {
+		n, exists := m.files[p]
+		parent, parentExists := m.files[path.Dir(p)]
+		if !exists || !parentExists {
+			return
+		}
+
+		n.sync()
+		if parent.entries == nil {
+			parent.entries = make(map[string]*inode)
+		}
+		parent.entries[path.Base(p)] = n
+	}
+}
+
+// unpersistPath durably removes the entry at cleanPath from its directory.
+// Caller must hold the write lock.
+func (m *MockFS) unpersistPath(cleanPath string) {
+	if parent, exists := m.files[path.Dir(cleanPath)]; exists && cleanPath != "." {
+		delete(parent.entries, path.Base(cleanPath))
+	}
+}
+
+// syncDir makes the current entries of the directory inode dir durable.
+// A directory that is no longer reachable has nothing to sync.
+// Caller must hold the write lock.
+func (m *MockFS) syncDir(dir *inode) {
+	dirPath, found := "", false
+	for p, n := range m.files {
+		if n == dir {
+			dirPath, found = p, true
+			break
+		}
+	}
+	if !found {
+		return
+	}
+
+	entries := make(map[string]*inode)
+	for p, n := range m.files {
+		if p != "." && path.Dir(p) == dirPath {
+			entries[path.Base(p)] = n
+		}
+	}
+	dir.entries = entries
+}
+
+// --- MockFile ---
+
+// Sync commits the file to stable storage, mirroring [os.File.Sync].
+//
+// On a regular file, the current contents become durable: they survive a
+// later MockFS.Crash. On a directory, the current entries become durable,
+// which is what makes a newly created, removed, or renamed entry survive.
+// As with fsync(2), syncing a file does not make its directory entry durable.
+// On a standalone MockFile, Sync only records stats and applies latency and
+// error injection.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (f *MockFile) Sync() (err error) {
+	<-f.mu
+	defer func() { f.mu <- struct{}{} }()
+
+	// Record the result of this operation on exit
+	defer func() { f.stats.RecordPath(OpSync, f.name, 0, err) }()
+	j := f.track(OpSync)
+	defer func() { j.end(0, err) }()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+
+	// Simulate latency before checking for errors (models real I/O timing)
+	j.simulate(f.latency, OpSync)
+
+	if err := f.injector.CheckAndApply(OpSync, f.name); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	unlock := f.lockInode(true)
+	defer unlock()
+
+	switch {
+	case !f.node.Mode.IsDir():
+		f.node.sync()
+	case f.fsys != nil:
+		f.fsys.syncDir(f.node)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package mockfs_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// syncFile returns an operation that opens name, syncs it, and closes it.
+func syncFile(name string) func(*mockfs.MockFS) error {
+	return func(m *mockfs.MockFS) error {
+		f, err := m.OpenMockFile(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return f.Sync()
+	}
+}
+
+// writeAt opens name for writing, writes data at off, and closes it.
+func writeAt(tb testing.TB, mfs *mockfs.MockFS, name string, flag int, data string, off int64) {
+	tb.Helper()
+
+	f, err := mfs.OpenFile(name, os.O_WRONLY|flag, 0o644)
+	requireNoError(tb, err, name)
+	_, err = f.WriteAt([]byte(data), off)
+	requireNoError(tb, err, name)
+	requireNoError(tb, f.Close(), name)
+}
+
+func TestMockFS_Crash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ops    func(t *testing.T, m *mockfs.MockFS)
+		want   map[string]string // Expected file contents after the crash.
+		absent []string          // Names expected not to exist after the crash.
+	}{
+		{
+			name: "unsynced write is lost",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				writeAt(t, m, "db", 0, "new", 0)
+			},
+			want: map[string]string{"db": "old-data"},
+		},
+		{
+			name: "synced write survives",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				writeAt(t, m, "db", 0, "new", 0)
+				requireNoError(t, syncFile("db")(m))
+			},
+			want: map[string]string{"db": "new-data"},
+		},
+		{
+			name: "synced file without directory sync vanishes",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				writeAt(t, m, "new", os.O_CREATE, "data", 0)
+				requireNoError(t, syncFile("new")(m))
+			},
+			absent: []string{"new"},
+		},
+		{
+			name: "directory sync without file sync leaves the file empty",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				writeAt(t, m, "new", os.O_CREATE, "data", 0)
+				requireNoError(t, syncFile(".")(m))
+			},
+			want: map[string]string{"new": ""},
+		},
+		{
+			name: "write temp, fsync, rename, fsync dir",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				writeAt(t, m, "db.tmp", os.O_CREATE, "replacement", 0)
+				requireNoError(t, syncFile("db.tmp")(m))
+				requireNoError(t, m.Rename("db.tmp", "db"))
+				requireNoError(t, syncFile(".")(m))
+			},
+			want:   map[string]string{"db": "replacement"},
+			absent: []string{"db.tmp"},
+		},
+		{
+			name: "rename without directory sync is rolled back",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Rename("db", "moved"))
+			},
+			want:   map[string]string{"db": "old-data"},
+			absent: []string{"moved"},
+		},
+		{
+			name: "remove without directory sync is rolled back",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Remove("db"))
+			},
+			want: map[string]string{"db": "old-data"},
+		},
+		{
+			name: "remove with directory sync",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Remove("db"))
+				requireNoError(t, syncFile(".")(m))
+			},
+			absent: []string{"db"},
+		},
+		{
+			name: "renamed directory keeps its synced entries",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Rename("data", "archive"))
+				requireNoError(t, syncFile(".")(m))
+			},
+			want:   map[string]string{"archive/log": "log"},
+			absent: []string{"data"},
+		},
+		{
+			name: "new directory needs its own sync",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Mkdir("wal", 0o755))
+				writeAt(t, m, "wal/0001", os.O_CREATE, "entry", 0)
+				requireNoError(t, syncFile("wal/0001")(m))
+				requireNoError(t, syncFile(".")(m))
+			},
+			want:   map[string]string{},
+			absent: []string{"wal/0001"},
+		},
+		{
+			name: "fixture helpers count as synced",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.AddFile("extra/added", "added"))
+				requireNoError(t, m.RemoveEntry("data"))
+			},
+			want:   map[string]string{"extra/added": "added"},
+			absent: []string{"data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(
+				mockfs.WithCreateIfMissing(true),
+				mockfs.File("db", "old-data"),
+				mockfs.Dir("data", mockfs.File("log", "log")),
+			)
+			tt.ops(t, mfs)
+			requireNoError(t, mfs.Crash())
+
+			for name, want := range tt.want {
+				if got := string(mustReadFile(t, mfs, name)); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, err := mfs.Stat(name); !errors.Is(err, mockfs.ErrNotExist) {
+					t.Errorf("Stat(%q) error = %v, want ErrNotExist", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMockFS_Crash_HardLinks(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"))
+	requireNoError(t, mfs.Link("a", "b"))
+	requireNoError(t, syncFile(".")(mfs))
+	requireNoError(t, mfs.Crash())
+
+	if ino := inodeOf(t, mfs, "a"); ino.Nlink != 2 || ino.Ino != inodeOf(t, mfs, "b").Ino {
+		t.Errorf("a and b are not the same inode with two links: %+v", ino)
+	}
+}
+
+func TestMockFS_Crash_RemovedRoot(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"), mockfs.Dir("d"))
+	requireNoError(t, mfs.RemoveAll("."))
+	requireNoError(t, mfs.Crash())
+
+	info, err := mfs.Stat(".")
+	requireNoError(t, err)
+	if !info.IsDir() {
+		t.Errorf("Stat(.) = mode %v, want a directory", info.Mode())
+	}
+	if entries, err := mfs.ReadDir("."); err != nil || len(entries) != 0 {
+		t.Errorf("ReadDir(.) = %v, %v, want no entries", entries, err)
+	}
+
+	// The recovered root is usable
+	writeAt(t, mfs, "b", os.O_CREATE, "new", 0)
+	if got := string(mustReadFile(t, mfs, "b")); got != "new" {
+		t.Errorf("b = %q, want %q", got, "new")
+	}
+}
+
+func TestMockFS_Crash_TornWrite(t *testing.T) {
+	t.Parallel()
+
+	want := []string{"0123456789", "0123X56789", "0123XY6789", "0123XYZ789"}
+	for keep, want := range want {
+		mfs := mockfs.MustNewMockFS(mockfs.File("db", "0123456789"))
+		writeAt(t, mfs, "db", 0, "first", 0)
+		writeAt(t, mfs, "db", 0, "XYZ", 4)
+
+		requireNoError(t, mfs.Crash(mockfs.WithTornWrite(keep)))
+		if got := string(mustReadFile(t, mfs, "db")); got != want {
+			t.Errorf("torn %d bytes: db = %q, want %q", keep, got, want)
+		}
+	}
+
+	// A torn write past the synced end is zero-filled
+	mfs := mockfs.MustNewMockFS(mockfs.File("db", "ab"))
+	writeAt(t, mfs, "db", 0, "cd", 4)
+	requireNoError(t, mfs.Crash(mockfs.WithTornWrite(1)))
+	if got := string(mustReadFile(t, mfs, "db")); got != "ab\x00\x00c" {
+		t.Errorf("db = %q, want %q", got, "ab\x00\x00c")
+	}
+
+	err := mfs.Crash(mockfs.WithTornWrite(-1))
+	assertError(t, err, mockfs.ErrUsage)
+}
+
+func TestMockFS_Crash_DetachesHandles(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("db", "old"))
+	f, err := mfs.OpenFile("db", os.O_RDWR, 0)
+	requireNoError(t, err)
+
+	requireNoError(t, mfs.Crash())
+
+	// The old handle still works, but the filesystem no longer sees it
+	_, err = f.Write([]byte("lost"))
+	requireNoError(t, err)
+	requireNoError(t, f.Sync())
+	requireNoError(t, f.Close())
+
+	if got := string(mustReadFile(t, mfs, "db")); got != "old" {
+		t.Errorf("db = %q, want %q", got, "old")
+	}
+
+	// New handles work on the recovered file
+	g, err := mfs.OpenFile("db", os.O_RDWR, 0)
+	requireNoError(t, err)
+	_, err = g.Write([]byte("new"))
+	requireNoError(t, err)
+	requireNoError(t, g.Sync())
+	requireNoError(t, g.Close())
+	requireNoError(t, mfs.Crash())
+
+	if got := string(mustReadFile(t, mfs, "db")); got != "new" {
+		t.Errorf("db = %q, want %q", got, "new")
+	}
+}
+
+func TestMockFile_Sync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stats and injection", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("db", "data"))
+		requireNoError(t, mfs.FailSyncOnce("db", mockfs.ErrDiskFull))
+
+		f, err := mfs.OpenMockFile("db")
+		requireNoError(t, err)
+		assertError(t, f.Sync(), mockfs.ErrDiskFull)
+		assertNoError(t, f.Sync())
+		requireNoError(t, f.Close())
+
+		f.Stats().Expect().
+			Count(mockfs.OpSync, 2).
+			Failure(mockfs.OpSync, 1).
+			Assert(t)
+	})
+
+	t.Run("closed file", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("db", "data")
+		requireNoError(t, f.Close())
+		assertError(t, f.Sync(), fs.ErrClosed)
+	})
+
+	t.Run("standalone file", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("db", "data")
+		requireNoError(t, f.Sync())
+		got, err := io.ReadAll(f)
+		requireNoError(t, err)
+		if string(got) != "data" {
+			t.Errorf("data = %q, want %q", got, "data")
+		}
+	})
+}
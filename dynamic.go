@@ -0,0 +1,87 @@
+package mockfs
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// DynamicContent is the content of a dynamic file, whose bytes are computed
+// anew each time it is opened. Create it with Dynamic and pass it to File or
+// AddFile.
+type DynamicContent struct {
+	fn func() (any, error)
+}
+
+// Dynamic returns content that fn computes each time the file is opened, like
+// the entries of /proc: a status file reporting a counter, or a file that
+// reads differently the second time. fn returns a []byte, a string, an
+// io.Reader, an encoding.BinaryMarshaler, a fmt.Stringer, GeneratedContent,
+// or an io.ReaderAt with a Size() int64 method, such as an io.SectionReader,
+// whose bytes become generated content of that size. Any other type fails
+// the Open; wrap a generator without a size, such as Zeros, in Generated.
+//
+// Open, OpenFile, and so ReadFile call fn, and nothing else does; OpenFile
+// with os.O_TRUNC on a writable handle empties the file instead. Every handle
+// on the file reads the content computed by the latest Open, and writes
+// change that content until the next Open. Stat does not compute the size
+// anew: Stat, Lstat, ReadDir, and Stat on a handle report the size of the
+// content of the latest Open, or 0 before the first Open, as the files of
+// /proc report no size. An error from fn fails the Open with a *PathError
+// wrapping it and leaves the content unchanged. Dynamic files are otherwise
+// ordinary files: they are listed by ReadDir and counted by Stats, and error
+// injection and latency apply to them.
+//
+// fn is called with the filesystem locked, so it must not use the
+// filesystem, and it may be called from several goroutines at once.
+//
+//	var opens atomic.Int64
+//	mockfs.File("status", mockfs.Dynamic(func() (any, error) {
+//	    return fmt.Sprintf("opens: %d\n", opens.Add(1)), nil
+//	}))
+func Dynamic(fn func() (any, error)) DynamicContent {
+	return DynamicContent{fn: fn}
+}
+
+// sizedReaderAt is an io.ReaderAt that knows the size of its content.
+type sizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// generate calls the content function of a dynamic file and converts its
+// result.
+func generate(fn func() (any, error)) (fileData, error) {
+	content, err := fn()
+	if err != nil {
+		return fileData{}, err
+	}
+
+	switch v := content.(type) {
+	case nil, []byte, string, io.Reader, encoding.BinaryMarshaler, fmt.Stringer, GeneratedContent:
+	case DynamicContent:
+		return fileData{}, errors.New("dynamic content computed as dynamic content")
+	case sizedReaderAt:
+		content = Generated(v.Size(), v)
+	default:
+		return fileData{}, fmt.Errorf("unsupported dynamic content type %T", content)
+	}
+	return fileContent(content)
+}
+
+// refresh computes the content of a dynamic file opened as name. It does
+// nothing for other files. Caller must hold the write lock.
+func (n *inode) refresh(name string) error {
+	if n.dyn == nil {
+		return nil
+	}
+
+	c, err := generate(n.dyn)
+	if err != nil {
+		return &fs.PathError{Op: OpOpen.String(), Path: name, Err: err}
+	}
+	n.Data, n.virt = c.data, c.virt
+	return nil
+}
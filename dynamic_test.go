@@ -0,0 +1,187 @@
+package mockfs_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// counterFile returns dynamic content reporting how many times it was computed.
+func counterFile() mockfs.DynamicContent {
+	n := 0
+	return mockfs.Dynamic(func() (any, error) {
+		n++
+		return fmt.Sprintf("count=%d\n", n), nil
+	})
+}
+
+func TestDynamic_ContentPerOpen(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.Dir("proc", mockfs.File("status", counterFile())))
+
+	if got := string(mustReadFile(t, mfs, "proc/status")); got != "count=1\n" {
+		t.Errorf("first read = %q, want %q", got, "count=1\n")
+	}
+	if got := string(mustReadFile(t, mfs, "proc/status")); got != "count=2\n" {
+		t.Errorf("second read = %q, want %q", got, "count=2\n")
+	}
+
+	// Stat reports the size of the latest content without computing it
+	info, err := mfs.Stat("proc/status")
+	requireNoError(t, err)
+	if info.Size() != int64(len("count=2\n")) || !info.Mode().IsRegular() {
+		t.Errorf("Stat = %d bytes, mode %v, want a regular file of %d bytes", info.Size(), info.Mode(), len("count=2\n"))
+	}
+
+	entries, err := mfs.ReadDir("proc")
+	requireNoError(t, err)
+	if len(entries) != 1 || entries[0].Name() != "status" {
+		t.Errorf("ReadDir = %v, want the status file", entries)
+	}
+	if got := string(mustReadFile(t, mfs, "proc/status")); got != "count=3\n" {
+		t.Errorf("read after Stat = %q, want %q", got, "count=3\n")
+	}
+
+	mfs.Stats().Expect().PathCount("proc/status", mockfs.OpOpen, 3).Assert(t)
+	mfs.HandleStats().Expect().PathCount("proc/status", mockfs.OpRead, 6).Assert(t)
+}
+
+func TestDynamic_Handles(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("status", counterFile()))
+
+	// A write changes the content until the next Open
+	f, err := mfs.OpenFile("status", os.O_RDWR, 0)
+	requireNoError(t, err)
+	_, err = f.Write([]byte("COUNT"))
+	requireNoError(t, err)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(f)
+	requireNoError(t, err)
+	if string(got) != "COUNT=1\n" {
+		t.Errorf("content after write = %q, want %q", got, "COUNT=1\n")
+	}
+	requireNoError(t, f.Close())
+	if got := string(mustReadFile(t, mfs, "status")); got != "count=2\n" {
+		t.Errorf("content after reopening = %q, want %q", got, "count=2\n")
+	}
+
+	// Stat on a handle reports the content the handle reads
+	r, err := mfs.Open("status")
+	requireNoError(t, err)
+	info, err := r.Stat()
+	requireNoError(t, err)
+	got, err = io.ReadAll(r)
+	requireNoError(t, err)
+	requireNoError(t, r.Close())
+	if info.Size() != int64(len(got)) || string(got) != "count=3\n" {
+		t.Errorf("Stat on the handle = %d bytes, content %q, want the size of %q", info.Size(), got, "count=3\n")
+	}
+
+	// Truncating instead of computing
+	f, err = mfs.OpenFile("status", os.O_WRONLY|os.O_TRUNC, 0)
+	requireNoError(t, err)
+	info, err = f.Stat()
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+	if info.Size() != 0 {
+		t.Errorf("Stat on the truncated handle = %d bytes, want 0", info.Size())
+	}
+	if got := string(mustReadFile(t, mfs, "status")); got != "count=4\n" {
+		t.Errorf("content after truncating = %q, want %q", got, "count=4\n")
+	}
+}
+
+func TestDynamic_Errors(t *testing.T) {
+	t.Parallel()
+
+	errStatus := errors.New("status unavailable")
+	fail := false
+	mfs := mockfs.MustNewMockFS(
+		mockfs.File("status", mockfs.Dynamic(func() (any, error) {
+			if fail {
+				return nil, errStatus
+			}
+			return mockfs.Generated(1<<30, mockfs.Zeros()), nil
+		})),
+	)
+
+	f, err := mfs.Open("status")
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+
+	// A failing function fails the Open; Stat keeps the current size
+	fail = true
+	_, err = mfs.Open("status")
+	assertError(t, err, errStatus)
+	info, err := mfs.Stat("status")
+	requireNoError(t, err)
+	if info.Size() != 1<<30 {
+		t.Errorf("Stat = %d bytes, want the size of the last content", info.Size())
+	}
+
+	// Error injection applies before the content is computed
+	fail = false
+	requireNoError(t, mfs.ErrorInjector().AddExact(mockfs.OpOpen, "status", mockfs.ErrTimeout, mockfs.ErrorModeOnce, 0))
+	_, err = mfs.Open("status")
+	assertError(t, err, mockfs.ErrTimeout)
+}
+
+func TestDynamic_AddFile(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS()
+	requireNoError(t, mfs.AddFile("run/status", counterFile()))
+	if got := string(mustReadFile(t, mfs, "run/status")); got != "count=1\n" {
+		t.Errorf("content = %q, want %q", got, "count=1\n")
+	}
+
+	assertAnyError(t, mfs.AddFile("run/nil", mockfs.Dynamic(nil)))
+	requireNoError(t, mfs.AddFile("run/nested", mockfs.Dynamic(func() (any, error) { return counterFile(), nil })))
+	_, err := mfs.Open("run/nested")
+	assertAnyError(t, err)
+}
+
+func TestDynamic_ContentTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content any
+		want    string
+		wantErr bool
+	}{
+		{name: "sized reader at", content: io.NewSectionReader(strings.NewReader("hello world"), 6, 5), want: "world"},
+		{name: "reader", content: strings.NewReader("hello"), want: "hello"},
+		{name: "generator without a size", content: mockfs.Zeros(), wantErr: true},
+		{name: "arbitrary value", content: 42, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.File("f", mockfs.Dynamic(func() (any, error) { return tt.content, nil })))
+			got, err := mfs.ReadFile("f")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ReadFile = %q, want an error", got)
+				}
+				return
+			}
+			requireNoError(t, err)
+			if string(got) != tt.want {
+				t.Errorf("ReadFile = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -22,11 +22,17 @@ const (
 	ErrorModeAfterSuccesses
 	// ErrorModeNext means the error is returned next N times, then cleared.
 	ErrorModeNext
+	// ErrorModeEvery means the error is returned on every Nth call: calls N, 2N, 3N, and so on.
+	ErrorModeEvery
+	// ErrorModeScript means the rule steps through a script of outcomes (see NewScriptRule).
+	ErrorModeScript
+	// ErrorModeRandom means the error is returned with a probability, drawn from a seeded Chaos (see NewChaosRule).
+	ErrorModeRandom
 )
 
 // IsValid returns true if mode is one of the defined ErrorMode constants.
 func (m ErrorMode) IsValid() bool {
-	return m >= ErrorModeAlways && m <= ErrorModeNext
+	return m >= ErrorModeAlways && m <= ErrorModeRandom
 }
 
 // ErrUsage indicates that mockfs itself was misconfigured or misused by the
@@ -82,6 +88,14 @@ var (
 
 	// ErrNegativeOffset indicates that the offset is negative.
 	ErrNegativeOffset = errors.New("negative offset")
+
+	// ErrEventOverflow indicates that a Watcher dropped events because its
+	// event buffer was full (see WithEventBuffer).
+	ErrEventOverflow = errors.New("event queue overflow")
+
+	// ErrSymlinkLoop indicates that too many symbolic links were encountered
+	// while resolving a path, usually because of a link cycle (ELOOP).
+	ErrSymlinkLoop = errors.New("too many levels of symbolic links")
 )
 
 // ErrorRule captures the settings for an error to be injected.
@@ -92,17 +106,24 @@ type ErrorRule struct {
 	matchers []PathMatcher // Matchers for paths.
 	usedOnce atomic.Bool   // Used only for ErrorModeOnce.
 	hits     atomic.Uint64 // Number of hits observed.
+	limit    TransferLimit // Bytes a partial transfer lets through; nil for a full failure.
+	span     *byteRange    // Byte range the rule guards; nil to fire wherever the file is accessed.
+	corrupt  Corruption    // Rewrites the data a read sees; nil for rules that do not corrupt.
+	script   []error       // Outcomes of ErrorModeScript, stepped through by hits.
+	chaos    *chaosDraw    // Probability and error pool of ErrorModeRandom.
 }
 
 // NewErrorRule creates a new error rule.
 //
 // Parameters:
 //   - err - the error to return.
-//   - mode - one of ErrorModeAlways, ErrorModeOnce, ErrorModeAfterSuccesses, or ErrorModeNext.
-//     Returns an error wrapping ErrUsage if mode is not one of these.
+//   - mode - one of ErrorModeAlways, ErrorModeOnce, ErrorModeAfterSuccesses, ErrorModeNext, or
+//     ErrorModeEvery. Returns an error wrapping ErrUsage if mode is not one of these; scripted
+//     and random rules are created with NewScriptRule and NewChaosRule.
 //   - after - used only for ErrorModeAfterSuccesses and ErrorModeNext; specifies the number of
 //     calls before the error behaviour activates. Returns an error wrapping ErrUsage if after is
-//     negative for these modes. Ignored for ErrorModeAlways and ErrorModeOnce.
+//     negative for these modes. For ErrorModeEvery it is the period, and must be at least 1.
+//     Ignored for ErrorModeAlways and ErrorModeOnce.
 //   - matchers - an optional list of path matchers. If not provided, the rule applies to no paths.
 func NewErrorRule(err error, mode ErrorMode, after int, matchers ...PathMatcher) (*ErrorRule, error) {
 	afterN, validationErr := validateModeAndAfter(mode, after)
@@ -134,6 +155,15 @@ func validateAfter(after int, mode ErrorMode) (uint64, error) {
 			return 0, fmt.Errorf("mockfs: %w: invalid after value %d for mode %v — must be >= 0", ErrUsage, after, mode)
 		}
 		return uint64(after), nil
+	case ErrorModeEvery:
+		if after < 1 {
+			return 0, fmt.Errorf("mockfs: %w: invalid period %d for mode %v — must be >= 1", ErrUsage, after, mode)
+		}
+		return uint64(after), nil
+	case ErrorModeScript:
+		return 0, fmt.Errorf("mockfs: %w: ErrorModeScript rules are created with NewScriptRule", ErrUsage)
+	case ErrorModeRandom:
+		return 0, fmt.Errorf("mockfs: %w: ErrorModeRandom rules are created with NewChaosRule", ErrUsage)
 	default:
 		return 0, nil
 	}
@@ -168,8 +198,21 @@ func (r *ErrorRule) matches(path string) bool {
 	return false
 }
 
+// outcome steps the rule for a matching call of op on path and reports
+// whether it fires, along with the error to inject.
+func (r *ErrorRule) outcome(op Operation, path string) (bool, error) {
+	switch r.mode {
+	case ErrorModeScript:
+		return r.step()
+	case ErrorModeRandom:
+		return r.chaos.draw(op, path)
+	default:
+		return r.shouldReturnError(), r.Err
+	}
+}
+
 // shouldReturnError returns true if the error should be returned.
-// For ErrorModeAfterSuccesses and ErrorModeNext, it increments the hit counter.
+// For ErrorModeAfterSuccesses, ErrorModeNext, and ErrorModeEvery, it increments the hit counter.
 func (r *ErrorRule) shouldReturnError() bool {
 	switch r.mode {
 	case ErrorModeAlways:
@@ -182,6 +225,9 @@ func (r *ErrorRule) shouldReturnError() bool {
 	case ErrorModeNext:
 		hits := r.hits.Add(1)
 		return hits <= r.AfterN
+	case ErrorModeEvery:
+		hits := r.hits.Add(1)
+		return hits%r.AfterN == 0
 	default:
 		//nolint:forbidigo // Panic is intentional here to mark incorrect use
 		panic(fmt.Sprintf("mockfs: invalid ErrorMode: %d", r.mode))
@@ -196,7 +242,9 @@ func (r *ErrorRule) CloneForSub(prefix string) *ErrorRule {
 	}
 
 	// AfterN was validated when the original rule was created; no re-validation needed.
-	return newValidatedErrorRule(r.Err, r.mode, r.AfterN, newMatchers...)
+	clone := newValidatedErrorRule(r.Err, r.mode, r.AfterN, newMatchers...)
+	clone.limit, clone.span, clone.corrupt, clone.script, clone.chaos = r.limit, r.span, r.corrupt, r.script, r.chaos
+	return clone
 }
 
 // Mode returns the rule's ErrorMode, as validated at construction by NewErrorRule.
@@ -236,6 +284,24 @@ const (
 	OpRemoveAll
 	// OpRename represents the Rename operation.
 	OpRename
+	// OpSymlink represents the Symlink operation.
+	OpSymlink
+	// OpReadlink represents the ReadLink operation.
+	OpReadlink
+	// OpLstat represents the Lstat operation.
+	OpLstat
+	// OpLink represents the Link operation.
+	OpLink
+	// OpChmod represents the Chmod operation.
+	OpChmod
+	// OpChtimes represents the Chtimes operation.
+	OpChtimes
+	// OpChown represents the Chown operation.
+	OpChown
+	// OpTruncate represents the Truncate operation.
+	OpTruncate
+	// OpSync represents the Sync operation.
+	OpSync
 
 	// NumOperations is the number of available operations.
 	NumOperations
@@ -257,6 +323,15 @@ var operationNames = map[Operation]string{
 	OpRemove:         "Remove",
 	OpRemoveAll:      "RemoveAll",
 	OpRename:         "Rename",
+	OpSymlink:        "Symlink",
+	OpReadlink:       "ReadLink",
+	OpLstat:          "Lstat",
+	OpLink:           "Link",
+	OpChmod:          "Chmod",
+	OpChtimes:        "Chtimes",
+	OpChown:          "Chown",
+	OpTruncate:       "Truncate",
+	OpSync:           "Sync",
 }
 
 // IsValid returns true if the operation is valid.
@@ -359,10 +434,14 @@ type ErrorInjector interface {
 type errorInjector struct {
 	mu      sync.RWMutex
 	configs map[Operation][]*ErrorRule
+	log     faultLog // Faults injected so far.
 }
 
-// Ensure errorInjector implements ErrorInjector.
-var _ ErrorInjector = (*errorInjector)(nil)
+// Ensure errorInjector implements ErrorInjector and FaultRecorder.
+var (
+	_ ErrorInjector = (*errorInjector)(nil)
+	_ FaultRecorder = (*errorInjector)(nil)
+)
 
 // NewErrorInjector returns a new ErrorInjector.
 func NewErrorInjector() ErrorInjector {
@@ -564,29 +643,45 @@ func (ei *errorInjector) CloneForSub(prefix string) ErrorInjector {
 
 // CheckAndApply tries rules in insertion order; notionally we could add priorities.
 // It owns locking and mutates rule state (shouldReturnError uses atomics for some modes).
+// Partial-transfer, range, and corruption rules are skipped.
 func (ei *errorInjector) CheckAndApply(op Operation, path string) error {
+	call := ei.log.call(op, path)
+	if r, err := ei.fire(op, path, (*ErrorRule).plain); r != nil {
+		// A short transfer moves no bytes here, so only its error applies
+		if short, ok := err.(*shortTransfer); ok { //nolint:errorlint // a script outcome is compared by type, never wrapped
+			err = short.err
+		}
+		ei.log.record(call, err)
+		return err
+	}
+	return nil
+}
+
+// InjectedFaults returns the faults the injector has injected, in order.
+func (ei *errorInjector) InjectedFaults() []InjectedFault {
+	return ei.log.faults()
+}
+
+// fire returns the first rule that matches path and fires, consuming the
+// firing: op-specific rules first, then the global/wildcard rules (OpUnknown).
+// Only rules for which applies reports true are considered. The error is the
+// one the firing injects, which varies for scripted rules.
+func (ei *errorInjector) fire(op Operation, path string, applies func(*ErrorRule) bool) (*ErrorRule, error) {
 	ei.mu.RLock()
 	defer ei.mu.RUnlock()
 
-	// First check op-specific rules
-	if arr, ok := ei.configs[op]; ok {
-		for _, r := range arr {
-			if r.matches(path) {
-				if r.shouldReturnError() {
-					return r.Err
-				}
+	for _, o := range [...]Operation{op, OpUnknown} {
+		for _, r := range ei.configs[o] {
+			if !applies(r) {
+				continue
 			}
-		}
-	}
-	// Then optionally check any global/wildcard rules (OpUnknown)
-	if arr, ok := ei.configs[OpUnknown]; ok {
-		for _, r := range arr {
-			if r.matches(path) {
-				if r.shouldReturnError() {
-					return r.Err
-				}
+			if !r.matches(path) {
+				continue
+			}
+			if fires, err := r.outcome(op, path); fires {
+				return r, err
 			}
 		}
 	}
-	return nil
+	return nil, nil //nolint:nilnil // a nil rule means no rule fired; callers test the rule, not the error
 }
@@ -0,0 +1,357 @@
+package mockfs
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+)
+
+// Expectation is a fluent assertion on the sequence of operations recorded
+// by the journal of a MockFS, in the style of gomock. Create one with
+// MockFS.Expect, describe the expected calls, and check them with Assert:
+//
+//	mfs.Expect().
+//		Open("cfg.tmp").
+//		Write("cfg.tmp").AtLeast(1).
+//		Close("cfg.tmp").
+//		Rename("cfg.tmp", "cfg").
+//		Assert(t)
+//
+// Calls are expected in the order they are declared, each exactly once
+// unless Times, AtLeast, or AnyTimes says otherwise. Unordered groups the
+// calls that may happen in any order, and InOrder groups the calls inside it
+// that must keep their order. A call may only match once the calls before it
+// have been matched often enough, and matching it retires those calls, as
+// gomock does: an operation is matched to the first declared call that can
+// take it.
+//
+// An operation that an expected call describes but cannot take, because the
+// call was already matched as often as allowed, was retired, or is not due
+// yet, is reported as unexpected, as gomock does. By default, operations
+// that match no expected call at all are ignored; Strict reports them too.
+// An Expectation needs the journal (WithJournal); use MockFS.ResetJournal to
+// leave fixture setup out of it.
+type Expectation struct {
+	m      *MockFS
+	root   *callGroup    // Top-level calls, in order.
+	group  *callGroup    // Group new calls are added to.
+	last   *expectedCall // Call the cardinality methods apply to.
+	strict bool          // Whether unexpected operations fail the assertion.
+	errs   []string      // Misuse of the builder, reported by Assert.
+}
+
+// Expect returns an Expectation on the operations in the journal.
+// See Expectation.
+func (m *MockFS) Expect() *Expectation {
+	root := &callGroup{ordered: true}
+	return &Expectation{m: m, root: root, group: root}
+}
+
+// Open expects an Open or OpenFile of name.
+func (e *Expectation) Open(name string) *Expectation { return e.call(OpOpen, name) }
+
+// Stat expects a Stat of name, through the filesystem or a handle.
+func (e *Expectation) Stat(name string) *Expectation { return e.call(OpStat, name) }
+
+// Read expects a Read or ReadAt on a handle of name.
+func (e *Expectation) Read(name string) *Expectation { return e.call(OpRead, name) }
+
+// Write expects a Write or WriteAt on a handle of name, or a WriteFile of name.
+func (e *Expectation) Write(name string) *Expectation { return e.call(OpWrite, name) }
+
+// Seek expects a Seek on a handle of name.
+func (e *Expectation) Seek(name string) *Expectation { return e.call(OpSeek, name) }
+
+// Close expects a Close of a handle of name.
+func (e *Expectation) Close(name string) *Expectation { return e.call(OpClose, name) }
+
+// ReadDir expects a ReadDir of name, through the filesystem or a handle.
+func (e *Expectation) ReadDir(name string) *Expectation { return e.call(OpReadDir, name) }
+
+// Mkdir expects a Mkdir of name.
+func (e *Expectation) Mkdir(name string) *Expectation { return e.call(OpMkdir, name) }
+
+// MkdirAll expects a MkdirAll of name.
+func (e *Expectation) MkdirAll(name string) *Expectation { return e.call(OpMkdirAll, name) }
+
+// Remove expects a Remove of name.
+func (e *Expectation) Remove(name string) *Expectation { return e.call(OpRemove, name) }
+
+// RemoveAll expects a RemoveAll of name.
+func (e *Expectation) RemoveAll(name string) *Expectation { return e.call(OpRemoveAll, name) }
+
+// Truncate expects a Truncate of name, through the filesystem or a handle.
+func (e *Expectation) Truncate(name string) *Expectation { return e.call(OpTruncate, name) }
+
+// Sync expects a Sync on a handle of name.
+func (e *Expectation) Sync(name string) *Expectation { return e.call(OpSync, name) }
+
+// Rename expects a Rename of oldpath to newpath.
+func (e *Expectation) Rename(oldpath, newpath string) *Expectation {
+	return e.Match(OpRename, NewExactMatcher(path.Clean(oldpath)), NewExactMatcher(path.Clean(newpath)))
+}
+
+// Match expects an operation op whose path matches the first matcher and
+// whose second path (see JournalEntry.NewPath) matches the second one.
+// Missing or nil matchers match any path.
+func (e *Expectation) Match(op Operation, matchers ...PathMatcher) *Expectation {
+	c := &expectedCall{op: op, min: 1, max: 1}
+	if len(matchers) > 0 {
+		c.path = matchers[0]
+	}
+	if len(matchers) > 1 {
+		c.newPath = matchers[1]
+	}
+	if len(matchers) > 2 {
+		e.errs = append(e.errs, fmt.Sprintf("Match(%s): %d matchers, want at most 2", op, len(matchers)))
+	}
+
+	e.group.units = append(e.group.units, c)
+	e.last = c
+	return e
+}
+
+// Times sets how many times the last call is expected.
+func (e *Expectation) Times(n int) *Expectation { return e.cardinality("Times", n, n) }
+
+// AtLeast expects the last call at least n times.
+func (e *Expectation) AtLeast(n int) *Expectation { return e.cardinality("AtLeast", n, math.MaxInt) }
+
+// AnyTimes allows the last call any number of times, including none.
+func (e *Expectation) AnyTimes() *Expectation { return e.cardinality("AnyTimes", 0, math.MaxInt) }
+
+// Unordered expects the calls that build declares to happen in any order.
+// Inside build, InOrder groups calls that must keep their order.
+func (e *Expectation) Unordered(build func(*Expectation)) *Expectation {
+	return e.nest(false, build)
+}
+
+// InOrder expects the calls that build declares to happen in order. It is
+// the default at the top level, and is meant for use inside Unordered.
+func (e *Expectation) InOrder(build func(*Expectation)) *Expectation {
+	return e.nest(true, build)
+}
+
+// Strict makes Assert report every operation that matches no expected call,
+// not only those an expected call describes but cannot take.
+func (e *Expectation) Strict() *Expectation {
+	e.strict = true
+	return e
+}
+
+// Assert checks the journal against the expected calls and reports a
+// mismatch as a single error through t, listing the recorded operations
+// with the expected calls that are missing.
+func (e *Expectation) Assert(t TestReporter) {
+	t.Helper()
+
+	if len(e.errs) > 0 {
+		t.Errorf("mockfs: invalid expectation: %s", strings.Join(e.errs, "; "))
+		return
+	}
+	if e.m.journal == nil {
+		t.Errorf("mockfs: Expect needs the journal; create the MockFS with WithJournal")
+		return
+	}
+
+	var calls []*expectedCall
+	e.root.link(nil, &calls)
+
+	var diff []string
+	failed := false
+	for entry := range e.m.Journal() {
+		c := matchCall(calls, entry)
+		d := declaredCall(calls, entry)
+		switch {
+		case c != nil:
+			c.count++
+			c.retirePrereqs()
+			diff = append(diff, "    "+entry.String())
+		case d != nil && (d.retired || d.count >= d.max):
+			failed = true
+			diff = append(diff, fmt.Sprintf("  + %s (over %s, matched %d)", entry, d, d.count))
+		case d != nil:
+			failed = true
+			diff = append(diff, fmt.Sprintf("  + %s (before %s is due)", entry, d))
+		case e.strict:
+			failed = true
+			diff = append(diff, "  + "+entry.String())
+		default:
+			diff = append(diff, "  ~ "+entry.String())
+		}
+	}
+	for _, c := range calls {
+		if c.count < c.min {
+			failed = true
+			diff = append(diff, fmt.Sprintf("  - %s (matched %d)", c, c.count))
+		}
+	}
+
+	if failed {
+		t.Errorf("mockfs: operations do not match expectations (- missing, + unexpected, ~ ignored):\n%s",
+			strings.Join(diff, "\n"))
+	}
+}
+
+// call expects op on the exact path name.
+func (e *Expectation) call(op Operation, name string) *Expectation {
+	return e.Match(op, NewExactMatcher(path.Clean(name)))
+}
+
+// cardinality sets the bounds of the last call.
+func (e *Expectation) cardinality(method string, lo, hi int) *Expectation {
+	switch {
+	case e.last == nil:
+		e.errs = append(e.errs, method+" without a preceding call")
+	case lo < 0:
+		e.errs = append(e.errs, fmt.Sprintf("%s(%d) on %s: negative count", method, lo, e.last))
+	default:
+		e.last.min, e.last.max = lo, hi
+	}
+	return e
+}
+
+// nest adds a group whose calls build declares.
+func (e *Expectation) nest(ordered bool, build func(*Expectation)) *Expectation {
+	g := &callGroup{ordered: ordered}
+	e.group.units = append(e.group.units, g)
+
+	outer := e.group
+	e.group, e.last = g, nil
+	if build != nil {
+		build(e)
+	}
+	e.group, e.last = outer, nil
+
+	return e
+}
+
+// declaredCall returns the first call that expects the entry's operation,
+// whether or not it can take it, or nil.
+func declaredCall(calls []*expectedCall, entry JournalEntry) *expectedCall {
+	for _, c := range calls {
+		if c.matches(entry) {
+			return c
+		}
+	}
+	return nil
+}
+
+// matchCall returns the first call that can take the entry, or nil.
+func matchCall(calls []*expectedCall, entry JournalEntry) *expectedCall {
+	for _, c := range calls {
+		if !c.retired && c.count < c.max && c.matches(entry) && c.ready() {
+			return c
+		}
+	}
+	return nil
+}
+
+// expectUnit is a call or a group of calls in an Expectation.
+type expectUnit interface {
+	// link sets prereqs as the prerequisites of the unit's first calls,
+	// appends its calls to all in declaration order, and returns the calls
+	// that units after it must wait for.
+	link(prereqs []*expectedCall, all *[]*expectedCall) []*expectedCall
+}
+
+// callGroup is an ordered or unordered group of units.
+type callGroup struct {
+	ordered bool
+	units   []expectUnit
+}
+
+// link implements expectUnit.
+func (g *callGroup) link(prereqs []*expectedCall, all *[]*expectedCall) []*expectedCall {
+	if g.ordered {
+		for _, u := range g.units {
+			prereqs = u.link(prereqs, all)
+		}
+		return prereqs
+	}
+
+	var last []*expectedCall
+	for _, u := range g.units {
+		last = append(last, u.link(prereqs, all)...)
+	}
+	if len(g.units) == 0 {
+		return prereqs
+	}
+	return last
+}
+
+// expectedCall is one expected operation with its cardinality.
+type expectedCall struct {
+	op       Operation
+	path     PathMatcher     // Matches JournalEntry.Path; nil matches any.
+	newPath  PathMatcher     // Matches JournalEntry.NewPath; nil matches any.
+	min, max int             // Bounds on the number of matches.
+	prereqs  []*expectedCall // Calls that must be satisfied first.
+	count    int             // Matches so far.
+	retired  bool            // Whether a later call has been matched.
+}
+
+// link implements expectUnit.
+func (c *expectedCall) link(prereqs []*expectedCall, all *[]*expectedCall) []*expectedCall {
+	c.prereqs = prereqs
+	*all = append(*all, c)
+	return []*expectedCall{c}
+}
+
+// matches reports whether the entry is the operation the call expects.
+func (c *expectedCall) matches(entry JournalEntry) bool {
+	return entry.Op == c.op &&
+		(c.path == nil || c.path.Matches(entry.Path)) &&
+		(c.newPath == nil || c.newPath.Matches(entry.NewPath))
+}
+
+// ready reports whether every call before c has been matched often enough.
+func (c *expectedCall) ready() bool {
+	for _, p := range c.prereqs {
+		if p.count < p.min || !p.ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// retirePrereqs stops the calls before c from matching any more operations.
+func (c *expectedCall) retirePrereqs() {
+	for _, p := range c.prereqs {
+		if !p.retired {
+			p.retired = true
+			p.retirePrereqs()
+		}
+	}
+}
+
+// String describes the call, such as "Rename a -> b (at least 1)".
+func (c *expectedCall) String() string {
+	s := c.op.String() + " " + matcherString(c.path)
+	if c.newPath != nil {
+		s += " -> " + matcherString(c.newPath)
+	}
+
+	switch {
+	case c.min == c.max && c.min != 1:
+		s += fmt.Sprintf(" (%d times)", c.min)
+	case c.min == 0 && c.max == math.MaxInt:
+		s += " (any times)"
+	case c.max == math.MaxInt:
+		s += fmt.Sprintf(" (at least %d)", c.min)
+	}
+	return s
+}
+
+// matcherString describes a path matcher for messages.
+func matcherString(m PathMatcher) string {
+	switch m := m.(type) {
+	case nil:
+		return "*"
+	case fmt.Stringer:
+		return m.String()
+	default:
+		return fmt.Sprintf("%T", m)
+	}
+}
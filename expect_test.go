@@ -0,0 +1,272 @@
+package mockfs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// replaceConfig writes cfg.tmp and renames it over cfg, the way an atomic
+// config save does.
+func replaceConfig(t *testing.T, m *mockfs.MockFS) {
+	t.Helper()
+
+	f, err := m.Create("cfg.tmp")
+	requireNoError(t, err)
+	_, err = f.Write([]byte("new"))
+	requireNoError(t, err)
+	requireNoError(t, f.Sync())
+	requireNoError(t, f.Close())
+	requireNoError(t, m.Rename("cfg.tmp", "cfg"))
+}
+
+func TestMockFS_Expect(t *testing.T) {
+	t.Parallel()
+
+	glob, err := mockfs.NewGlobMatcher("*.tmp")
+	requireNoError(t, err)
+
+	tests := []struct {
+		name   string
+		ops    func(t *testing.T, m *mockfs.MockFS)
+		expect func(e *mockfs.Expectation) *mockfs.Expectation
+		want   []string // Substrings of the reported error; none if the assertion passes.
+	}{
+		{
+			name: "sequence with ignored operations",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Open("cfg.tmp").Write("cfg.tmp").Close("cfg.tmp").Rename("cfg.tmp", "cfg")
+			},
+		},
+		{
+			name: "strict sequence",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Open("cfg.tmp").Write("cfg.tmp").Sync("cfg.tmp").Close("cfg.tmp").Rename("cfg.tmp", "cfg")
+			},
+		},
+		{
+			name: "strict mode reports unexpected operations",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Open("cfg.tmp").Write("cfg.tmp").Close("cfg.tmp").Rename("cfg.tmp", "cfg")
+			},
+			want: []string{"  + #3 Sync cfg.tmp"},
+		},
+		{
+			name: "missing call",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Open("cfg.tmp").Close("cfg.tmp").Remove("cfg.tmp")
+			},
+			want: []string{"  - Remove cfg.tmp (matched 0)", "    #4 Close cfg.tmp"},
+		},
+		{
+			name: "out of order",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Rename("cfg.tmp", "cfg").Close("cfg.tmp")
+			},
+			want: []string{"  + #4 Close cfg.tmp [h1] (before Close cfg.tmp is due)", "  - Close cfg.tmp (matched 0)"},
+		},
+		{
+			name: "wrong rename target",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Rename("cfg.tmp", "other")
+			},
+			want: []string{"  - Rename cfg.tmp -> other (matched 0)"},
+		},
+		{
+			name: "any times allows none",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Open("cfg.tmp").Read("cfg.tmp").AnyTimes().Close("cfg.tmp")
+			},
+		},
+		{
+			name: "at least",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Write("cfg.tmp").AtLeast(2)
+			},
+			want: []string{"  - Write cfg.tmp (at least 2) (matched 1)"},
+		},
+		{
+			name: "exact count",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				for range 3 {
+					_, err := m.Stat("cfg")
+					requireNoError(t, err)
+				}
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Stat("cfg").Times(2)
+			},
+			want: []string{"  + #3 Stat cfg"},
+		},
+		{
+			name: "calls beyond the count fail without strict mode",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				for range 2 {
+					f, err := m.Open("cfg")
+					requireNoError(t, err)
+					requireNoError(t, f.Close())
+				}
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Open("cfg").Times(1)
+			},
+			want: []string{"  ~ #2 Close cfg [h1]", "  + #3 Open cfg [h2] (over Open cfg, matched 1)"},
+		},
+		{
+			name: "calls after retirement fail without strict mode",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Sync("cfg.tmp").AnyTimes().Write("cfg.tmp")
+			},
+			want: []string{"  + #3 Sync cfg.tmp [h1] (over Sync cfg.tmp (any times), matched 0)"},
+		},
+		{
+			name: "a later call retires earlier ones",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				f, err := m.OpenMockFile("cfg")
+				requireNoError(t, err)
+				requireNoError(t, f.Close())
+				_, err = m.Stat("cfg")
+				requireNoError(t, err)
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Open("cfg").Stat("cfg").AnyTimes().Close("cfg")
+			},
+			want: []string{"  + #3 Stat cfg"},
+		},
+		{
+			name: "unordered group",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Mkdir("b", 0o755))
+				requireNoError(t, m.Mkdir("a", 0o755))
+				requireNoError(t, m.Remove("cfg"))
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().
+					Unordered(func(e *mockfs.Expectation) { e.Mkdir("a").Mkdir("b") }).
+					Remove("cfg")
+			},
+		},
+		{
+			name: "unordered group must finish first",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Mkdir("b", 0o755))
+				requireNoError(t, m.Remove("cfg"))
+				requireNoError(t, m.Mkdir("a", 0o755))
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().
+					Unordered(func(e *mockfs.Expectation) { e.Mkdir("a").Mkdir("b") }).
+					Remove("cfg")
+			},
+			want: []string{"  + #2 Remove cfg", "  - Remove cfg (matched 0)"},
+		},
+		{
+			name: "in-order group inside unordered group",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Mkdir("a", 0o755))
+				requireNoError(t, m.Mkdir("b", 0o755))
+				requireNoError(t, m.Remove("a"))
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Unordered(func(e *mockfs.Expectation) {
+					e.Mkdir("b")
+					e.InOrder(func(e *mockfs.Expectation) { e.Mkdir("a").Remove("a") })
+				})
+			},
+		},
+		{
+			name: "in-order group keeps its order",
+			ops: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				requireNoError(t, m.Mkdir("a", 0o755))
+				requireNoError(t, m.Remove("a"))
+				requireNoError(t, m.Mkdir("a", 0o755))
+			},
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Strict().Unordered(func(e *mockfs.Expectation) {
+					e.InOrder(func(e *mockfs.Expectation) { e.Remove("a").Mkdir("a") })
+				})
+			},
+			want: []string{"  + #1 Mkdir a"},
+		},
+		{
+			name: "path matchers",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Match(mockfs.OpWrite, glob).Match(mockfs.OpRename, glob, nil)
+			},
+		},
+		{
+			name: "path matcher in message",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.Match(mockfs.OpRemove, glob).Match(mockfs.OpMkdir)
+			},
+			want: []string{"  - Remove *.tmp (matched 0)", "  - Mkdir * (matched 0)"},
+		},
+		{
+			name: "cardinality without a call",
+			ops:  replaceConfig,
+			expect: func(e *mockfs.Expectation) *mockfs.Expectation {
+				return e.AnyTimes().Open("cfg.tmp").Times(-1)
+			},
+			want: []string{"invalid expectation: AnyTimes without a preceding call; Times(-1) on Open cfg.tmp: negative count"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.WithJournal(), mockfs.File("cfg", "old"))
+			tt.ops(t, mfs)
+
+			r := &fakeReporter{}
+			tt.expect(mfs.Expect()).Assert(r)
+
+			if len(tt.want) == 0 {
+				if len(r.errors) != 0 {
+					t.Errorf("Assert reported:\n%s", strings.Join(r.errors, "\n"))
+				}
+				return
+			}
+			if len(r.errors) != 1 {
+				t.Fatalf("Assert reported %d errors, want 1: %q", len(r.errors), r.errors)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(r.errors[0], want) {
+					t.Errorf("error does not contain %q:\n%s", want, r.errors[0])
+				}
+			}
+		})
+	}
+}
+
+func TestMockFS_Expect_NeedsJournal(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("cfg", "old"))
+	_, _ = mfs.Stat("cfg")
+
+	r := &fakeReporter{}
+	mfs.Expect().Stat("cfg").Assert(r)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "WithJournal") {
+		t.Errorf("errors = %q, want a request for WithJournal", r.errors)
+	}
+}
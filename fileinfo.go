@@ -14,6 +14,7 @@ type FileInfo struct {
 	size    int64
 	mode    FileMode
 	modTime time.Time
+	sys     any
 }
 
 // Ensure interface implementations.
@@ -100,9 +101,11 @@ func (fi *FileInfo) IsDir() bool {
 	return fi.mode.IsDir()
 }
 
-// Sys always returns nil for a FileInfo.
+// Sys returns the underlying data source. For entries produced by a MockFS
+// or returned by MockFile.Stat it is an *InodeInfo; for a FileInfo built
+// with NewFileInfo it is nil.
 func (fi *FileInfo) Sys() any {
-	return nil
+	return fi.sys
 }
 
 // Type returns the type bits for the entry.
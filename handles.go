@@ -0,0 +1,203 @@
+package mockfs
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpenHandle describes a handle opened from a MockFS that has not been closed.
+type OpenHandle struct {
+	ID     uint64    // Handle ID, as in JournalEntry.Handle.
+	Path   string    // Cleaned path the handle was opened with.
+	Flags  int       // Open flags; os.O_RDONLY for Open.
+	Opened time.Time // When the handle was opened.
+	Stack  string    // Call stack of the Open, starting at the caller of the package.
+}
+
+// String returns a one-line description of the handle, without its stack.
+func (h OpenHandle) String() string {
+	return fmt.Sprintf("h%d %s (%s) opened at %s", h.ID, h.Path, flagString(h.Flags), h.Opened.Format("15:04:05.000"))
+}
+
+// HandleStats returns the combined statistics of every handle opened from
+// the filesystem, including handles already closed, such as the one
+// ReadFile uses internally. WriteFile opens no handle and is counted by
+// MockFS.Stats, which only counts the operations on the filesystem itself;
+// MockFile.Stats counts those on a single handle. ResetStats resets these
+// counters too.
+func (m *MockFS) HandleStats() Stats {
+	return m.handleStats.Snapshot()
+}
+
+// AllStats returns the sum of Stats and HandleStats: every operation on the
+// filesystem and on its handles.
+func (m *MockFS) AllStats() Stats {
+	return sumStats(m.stats.Snapshot(), m.handleStats.Snapshot())
+}
+
+// OpenHandles returns the handles opened from the filesystem that are still
+// open, ordered by ID. Handles detached by Crash stay open until closed.
+func (m *MockFS) OpenHandles() []OpenHandle {
+	return m.live.list()
+}
+
+// CheckLeaks registers a cleanup on t that fails the test if any handle
+// opened from m is still open when the test ends, listing each one with the
+// stack that opened it. Cleanups run last-in first-out, so call it before
+// registering cleanups that close handles. t must be a CleanupReporter, as
+// *testing.T is; any other TestReporter is reported as a test failure.
+//
+//	mfs := mockfs.MustNewMockFS(...)
+//	mockfs.CheckLeaks(t, mfs)
+func CheckLeaks(t TestReporter, m *MockFS) {
+	t.Helper()
+
+	ct, ok := t.(CleanupReporter)
+	if !ok {
+		t.Errorf("mockfs: CheckLeaks: %T is not a CleanupReporter", t)
+		return
+	}
+	ct.Cleanup(func() {
+		t.Helper()
+
+		open := m.OpenHandles()
+		if len(open) == 0 {
+			return
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "mockfs: %d handles left open:", len(open))
+		for _, h := range open {
+			fmt.Fprintf(&b, "\n%s", h)
+			for line := range strings.Lines(h.Stack) {
+				b.WriteString("\n\t" + strings.TrimSuffix(line, "\n"))
+			}
+		}
+		t.Errorf("%s", b.String())
+	})
+}
+
+// handleRegistry tracks the handles of a MockFS that are not closed yet.
+type handleRegistry struct {
+	mu      sync.Mutex
+	handles map[uint64]OpenHandle // Open handles by ID.
+}
+
+// add registers f, opened with flag, capturing the caller's stack.
+func (r *handleRegistry) add(f *MockFile, flag int) {
+	h := OpenHandle{
+		ID:     f.id,
+		Path:   f.name,
+		Flags:  flag,
+		Opened: time.Now(),
+		Stack:  callerStack(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.handles == nil {
+		r.handles = make(map[uint64]OpenHandle)
+	}
+	r.handles[h.ID] = h
+}
+
+// remove unregisters the handle with the given ID.
+func (r *handleRegistry) remove(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.handles, id)
+}
+
+// list returns the registered handles, ordered by ID.
+func (r *handleRegistry) list() []OpenHandle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	handles := slices.Collect(maps.Values(r.handles))
+	slices.SortFunc(handles, func(a, b OpenHandle) int { return cmp.Compare(a.ID, b.ID) })
+
+	return handles
+}
+
+// handleRecorder is the StatsRecorder of a handle opened from a MockFS.
+// Besides the handle's own counters, it records every operation in the
+// filesystem's HandleStats.
+type handleRecorder struct {
+	StatsRecorder               // Counters of the handle.
+	fsys          StatsRecorder // Counters of all handles of the filesystem.
+}
+
+// Record implements StatsRecorder.
+func (r *handleRecorder) Record(op Operation, bytes int, err error) {
+	r.StatsRecorder.Record(op, bytes, err)
+	r.fsys.Record(op, bytes, err)
+}
+
+// RecordPath implements StatsRecorder.
+func (r *handleRecorder) RecordPath(op Operation, name string, bytes int, err error) {
+	r.StatsRecorder.RecordPath(op, name, bytes, err)
+	r.fsys.RecordPath(op, name, bytes, err)
+}
+
+// pkgPrefix is the prefix of the function names of this package.
+var pkgPrefix = reflect.TypeFor[MockFS]().PkgPath() + "."
+
+// callerStack formats the call stack of the current goroutine, leaving out
+// the frames of this package on top and those of the runtime.
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	var b strings.Builder
+	inside := true
+	for {
+		fr, more := frames.Next()
+		inside = inside && strings.HasPrefix(fr.Function, pkgPrefix)
+		if !inside && !strings.HasPrefix(fr.Function, "runtime.") {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", fr.Function, fr.File, fr.Line)
+		}
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// flagString formats open flags, such as "O_WRONLY|O_CREATE|O_TRUNC".
+func flagString(flag int) string {
+	var names []string
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		names = append(names, "O_WRONLY")
+	case os.O_RDWR:
+		names = append(names, "O_RDWR")
+	default:
+		names = append(names, "O_RDONLY")
+	}
+
+	for _, f := range []struct {
+		bit  int
+		name string
+	}{
+		{os.O_APPEND, "O_APPEND"},
+		{os.O_CREATE, "O_CREATE"},
+		{os.O_EXCL, "O_EXCL"},
+		{os.O_SYNC, "O_SYNC"},
+		{os.O_TRUNC, "O_TRUNC"},
+	} {
+		if flag&f.bit != 0 {
+			names = append(names, f.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
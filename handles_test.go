@@ -0,0 +1,155 @@
+package mockfs_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestMockFS_HandleStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "hello"), mockfs.File("b", "world!"))
+
+	_ = mustReadFile(t, mfs, "a")
+	requireNoError(t, mfs.WriteFile("b", []byte("new"), 0o644))
+	f, err := mfs.OpenMockFile("b")
+	requireNoError(t, err)
+	_, err = f.Read(make([]byte, 2))
+	requireNoError(t, err)
+
+	// ReadFile's handle is closed, f is still open; both count.
+	// ReadFile reads until io.EOF.
+	mfs.HandleStats().Expect().
+		Count(mockfs.OpRead, 3).
+		Count(mockfs.OpClose, 1).
+		BytesRead(7).
+		PathCount("a", mockfs.OpRead, 2).
+		PathCount("b", mockfs.OpRead, 1).
+		Assert(t)
+	f.Stats().Expect().Count(mockfs.OpRead, 1).BytesRead(2).Assert(t)
+
+	// Filesystem-level operations stay out of HandleStats
+	if n := mfs.HandleStats().Count(mockfs.OpOpen); n != 0 {
+		t.Errorf("HandleStats opens = %d, want 0", n)
+	}
+
+	all := mfs.AllStats()
+	fsys := mfs.Stats()
+	handles := mfs.HandleStats()
+	if all.Operations() != fsys.Operations()+handles.Operations() {
+		t.Errorf("AllStats = %v, want the sum of %v and %v", all, fsys, handles)
+	}
+	all.Expect().
+		Count(mockfs.OpOpen, 2).
+		Count(mockfs.OpRead, 3).
+		BytesRead(7).
+		BytesWritten(3).
+		PathCount("a", mockfs.OpOpen, 1).
+		PathCount("a", mockfs.OpRead, 2).
+		Assert(t)
+
+	mfs.ResetStats()
+	if !mfs.HandleStats().Empty() {
+		t.Errorf("HandleStats after ResetStats = %v, want empty", mfs.HandleStats())
+	}
+	if f.Stats().Empty() {
+		t.Error("ResetStats reset the stats of an open handle")
+	}
+	requireNoError(t, f.Close())
+}
+
+func TestMockFS_OpenHandles(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"))
+
+	f1, err := mfs.Open("a")
+	requireNoError(t, err)
+	f2, err := mfs.OpenFile("b", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	requireNoError(t, err)
+	_ = mustReadFile(t, mfs, "a")
+
+	open := mfs.OpenHandles()
+	if len(open) != 2 {
+		t.Fatalf("OpenHandles = %v, want 2 handles", open)
+	}
+
+	tests := []struct {
+		name  string
+		h     mockfs.OpenHandle
+		path  string
+		flags int
+		desc  string
+	}{
+		{name: "open", h: open[0], path: "a", flags: os.O_RDONLY, desc: "a (O_RDONLY)"},
+		{name: "open file", h: open[1], path: "b", flags: os.O_WRONLY | os.O_CREATE | os.O_TRUNC, desc: "b (O_WRONLY|O_CREATE|O_TRUNC)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.h.Path != tt.path || tt.h.Flags != tt.flags || tt.h.Opened.IsZero() {
+				t.Errorf("handle = %+v, want %s with flags %#x", tt.h, tt.path, tt.flags)
+			}
+			if !strings.Contains(tt.h.String(), tt.desc) {
+				t.Errorf("String() = %q, want it to contain %q", tt.h.String(), tt.desc)
+			}
+			// The stack starts at the caller, outside the package
+			first, _, _ := strings.Cut(tt.h.Stack, "\n")
+			if !strings.HasSuffix(first, ".TestMockFS_OpenHandles") {
+				t.Errorf("stack starts at %q, want the test function:\n%s", first, tt.h.Stack)
+			}
+		})
+	}
+
+	requireNoError(t, f1.Close())
+	if open := mfs.OpenHandles(); len(open) != 1 || open[0].Path != "b" {
+		t.Errorf("OpenHandles after Close = %v, want only b", open)
+	}
+	requireNoError(t, f2.Close())
+	if open := mfs.OpenHandles(); len(open) != 0 {
+		t.Errorf("OpenHandles after closing all = %v, want none", open)
+	}
+}
+
+func TestCheckLeaks(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"), mockfs.File("b", "data"))
+	r := &fakeReporter{}
+	mockfs.CheckLeaks(r, mfs)
+
+	closed, err := mfs.Open("a")
+	requireNoError(t, err)
+	requireNoError(t, closed.Close())
+	leaked, err := mfs.Open("b")
+	requireNoError(t, err)
+
+	r.finish()
+	if len(r.errors) != 1 {
+		t.Fatalf("errors = %q, want one leak report", r.errors)
+	}
+	for _, want := range []string{"1 handles left open", "b (O_RDONLY)", "TestCheckLeaks", "handles_test.go:"} {
+		if !strings.Contains(r.errors[0], want) {
+			t.Errorf("report does not contain %q:\n%s", want, r.errors[0])
+		}
+	}
+
+	requireNoError(t, leaked.Close())
+	r = &fakeReporter{}
+	mockfs.CheckLeaks(r, mfs)
+	r.finish()
+	if len(r.errors) != 0 {
+		t.Errorf("errors = %q, want none once every handle is closed", r.errors)
+	}
+
+	// A reporter that cannot register cleanups is reported at once
+	plain := &mockReporter{}
+	mockfs.CheckLeaks(plain, mfs)
+	if len(plain.errors) != 1 || !strings.Contains(plain.errors[0], "not a CleanupReporter") {
+		t.Errorf("errors = %q, want one usage report", plain.errors)
+	}
+}
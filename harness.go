@@ -0,0 +1,229 @@
+package mockfs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error ForEachFault injects when it is given no errors.
+var ErrInjected = errors.New("injected fault")
+
+// DefaultFaultTimeout is how long a run of a FaultSweep may take before it
+// is reported as hung, unless FaultSweep.Timeout says otherwise.
+const DefaultFaultTimeout = 5 * time.Second
+
+// FaultPoint identifies one call the code under test makes: the call number,
+// counted from 1, among the calls of Op on Path.
+type FaultPoint struct {
+	Op   Operation // Operation called.
+	Path string    // Path the operation was called on.
+	Call int       // Number of the call among the calls of Op on Path.
+}
+
+// String returns a one-line description of the point.
+func (p FaultPoint) String() string {
+	return fmt.Sprintf("%s %s (call %d)", p.Op, p.Path, p.Call)
+}
+
+// FaultSweep fails each call the code under test makes, one run at a time.
+// The zero value is ready to use; see Run.
+type FaultSweep struct {
+	// Errors are injected at each point in turn. Defaults to ErrInjected.
+	Errors []error
+
+	// Timeout is how long a run may take before it is reported as hung.
+	// Defaults to DefaultFaultTimeout.
+	Timeout time.Duration
+
+	// Check, if set, is called after every run with a fault injected, and a
+	// non-nil result is reported as an inconsistent filesystem. Use it to
+	// assert the invariants the code under test promises, for example that a
+	// file holds either its old or its new contents.
+	Check func(*MockFS) error
+}
+
+// ForEachFault runs body once against a fresh fixture from setup to record
+// every call it makes, then once more per recorded call and error with just
+// that call failing. It reports through t the points that led to a panic, a
+// hang, or handles left open. It is shorthand for FaultSweep{Errors: errs}.Run.
+func ForEachFault(t TestReporter, setup func() *MockFS, body func(*MockFS) error, errs ...error) {
+	t.Helper()
+	FaultSweep{Errors: errs}.Run(t, setup, body)
+}
+
+// Run sweeps body for faults. It first runs body against a fresh fixture
+// from setup, recording every (Operation, path, call number) that reaches the
+// filesystem's error injector; that run must succeed and close its handles.
+// Then, for each recorded point and each error, it runs body against another
+// fresh fixture with exactly that call failing, and reports through t the
+// points where body panicked, hung, left handles open, or failed Check.
+// Errors returned by body under a fault are expected and not reported.
+//
+// Only the points of the first run are swept, so calls that only happen on
+// error paths are not failed themselves. Calls made through a Sub filesystem
+// are not recorded. A hung run is abandoned, not stopped: its goroutine keeps
+// running until body returns.
+func (s FaultSweep) Run(t TestReporter, setup func() *MockFS, body func(*MockFS) error) {
+	t.Helper()
+
+	errs := s.Errors
+	if len(errs) == 0 {
+		errs = []error{ErrInjected}
+	}
+
+	rec := s.run(t, setup, body, nil, nil)
+	if rec == nil {
+		return
+	}
+	points := rec.recorded()
+
+	for _, p := range points {
+		for _, err := range errs {
+			s.run(t, setup, body, &p, err)
+		}
+	}
+}
+
+// run runs body once against a fresh fixture, failing the call at point with
+// err unless point is nil, and reports what went wrong. It returns the
+// injector used, or nil if the run did not finish.
+func (s FaultSweep) run(t TestReporter, setup func() *MockFS, body func(*MockFS) error, point *FaultPoint, err error) *pointInjector {
+	t.Helper()
+
+	label := "without faults"
+	if point != nil {
+		label = fmt.Sprintf("with %s failing with %v", point, err)
+	}
+
+	m := setup()
+	inj := &pointInjector{ErrorInjector: m.injector, calls: make(map[FaultPoint]int), point: point, err: err}
+	m.injector = inj
+	openBefore := m.openHandles()
+
+	type result struct {
+		err   error
+		panic any
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{panic: r}
+			}
+		}()
+		done <- result{err: body(m)}
+	}()
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFaultTimeout
+	}
+
+	var res result
+	select {
+	case res = <-done:
+	case <-time.After(timeout):
+		t.Errorf("mockfs: body hung %s: no result after %v", label, timeout)
+		return nil
+	}
+
+	switch {
+	case res.panic != nil:
+		t.Errorf("mockfs: body panicked %s: %v", label, res.panic)
+		return nil
+	case point == nil && res.err != nil:
+		t.Errorf("mockfs: body failed %s: %v", label, res.err)
+		return nil
+	}
+
+	if leaked := m.openHandles() - openBefore; leaked > 0 {
+		t.Errorf("mockfs: body left %d handles open %s", leaked, label)
+	}
+	if point != nil && s.Check != nil {
+		if checkErr := s.Check(m); checkErr != nil {
+			t.Errorf("mockfs: inconsistent filesystem %s: %v", label, checkErr)
+		}
+	}
+
+	return inj
+}
+
+// openHandles returns the number of handles open on the filesystem,
+// including those on inodes a Crash detached.
+func (m *MockFS) openHandles() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.nopen
+}
+
+// pointInjector wraps an ErrorInjector to count the calls that reach it,
+// recording them as fault points, and to fail the call at one point.
+type pointInjector struct {
+	ErrorInjector
+
+	mu     sync.Mutex
+	calls  map[FaultPoint]int // Calls so far, by point with a zero Call.
+	points []FaultPoint       // Every call, in order.
+	point  *FaultPoint        // Call to fail; nil to only record.
+	err    error              // Error to fail it with.
+}
+
+// Ensure pointInjector supports partial transfers and corruption.
+var (
+	_ transferChecker = (*pointInjector)(nil)
+	_ corrupter       = (*pointInjector)(nil)
+)
+
+// hit records a call of op on path and reports whether it is the one to fail.
+func (pi *pointInjector) hit(op Operation, path string) bool {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	key := FaultPoint{Op: op, Path: path}
+	pi.calls[key]++
+	p := FaultPoint{Op: op, Path: path, Call: pi.calls[key]}
+	pi.points = append(pi.points, p)
+
+	return pi.point != nil && *pi.point == p
+}
+
+// recorded returns the points recorded so far.
+func (pi *pointInjector) recorded() []FaultPoint {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	return slices.Clone(pi.points)
+}
+
+// CheckAndApply fails the call at the injector's point, and otherwise
+// delegates to the wrapped injector.
+func (pi *pointInjector) CheckAndApply(op Operation, path string) error {
+	if pi.hit(op, path) {
+		return pi.err
+	}
+	//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is
+	return pi.ErrorInjector.CheckAndApply(op, path)
+}
+
+// checkTransfer fails the transfer at the injector's point, and otherwise
+// delegates to the wrapped injector.
+func (pi *pointInjector) checkTransfer(op Operation, path string, off int64, requested int) (int, error) {
+	if pi.hit(op, path) {
+		return 0, pi.err
+	}
+	return checkTransfer(pi.ErrorInjector, op, path, off, requested)
+}
+
+// corruption delegates to the wrapped injector without counting a call.
+func (pi *pointInjector) corruption(op Operation, path string) Corruption {
+	return corruptionFor(pi.ErrorInjector, op, path)
+}
+
+// hasCorruption delegates to the wrapped injector.
+func (pi *pointInjector) hasCorruption() bool {
+	return keepsVersions(pi.ErrorInjector)
+}
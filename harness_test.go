@@ -0,0 +1,208 @@
+package mockfs_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// saveConfig replaces config.json atomically: write a temporary file, close
+// it, and rename it over the original.
+func saveConfig(m *mockfs.MockFS) error {
+	f, err := m.OpenFile("config.json.tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte("new")); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return m.Rename("config.json.tmp", "config.json")
+}
+
+func configFixture() *mockfs.MockFS {
+	return mockfs.MustNewMockFS(mockfs.File("config.json", "old"))
+}
+
+func TestForEachFault(t *testing.T) {
+	t.Parallel()
+
+	var runs int
+	var injected []error
+	body := func(m *mockfs.MockFS) error {
+		runs++
+		err := saveConfig(m)
+		if err != nil {
+			injected = append(injected, err)
+		}
+		return err
+	}
+
+	r := &fakeReporter{}
+	mockfs.ForEachFault(r, configFixture, body, mockfs.ErrDiskFull, mockfs.ErrTimeout)
+
+	if len(r.errors) != 0 {
+		t.Errorf("reported %q for a correct body", r.errors)
+	}
+	// Open, Write, Close, and Rename, each with two errors, after the recording run
+	if runs != 1+4*2 {
+		t.Errorf("runs = %d, want 9", runs)
+	}
+	if len(injected) != 8 {
+		t.Errorf("failed runs = %d, want 8", len(injected))
+	}
+	for _, err := range injected {
+		if !errors.Is(err, mockfs.ErrDiskFull) && !errors.Is(err, mockfs.ErrTimeout) {
+			t.Errorf("body failed with %v, want an injected error", err)
+		}
+	}
+}
+
+func TestForEachFault_DefaultError(t *testing.T) {
+	t.Parallel()
+
+	var got error
+	mockfs.ForEachFault(&fakeReporter{}, configFixture, func(m *mockfs.MockFS) error {
+		_, err := m.Stat("config.json")
+		if err != nil {
+			got = err
+		}
+		return err
+	})
+	assertError(t, got, mockfs.ErrInjected)
+}
+
+func TestForEachFault_Reports(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	tests := []struct {
+		name string
+		body func(*mockfs.MockFS) error
+		want []string
+	}{
+		{
+			name: "panic",
+			body: func(m *mockfs.MockFS) error {
+				f, _ := m.Open("config.json")
+				defer f.Close()
+				_, err := f.Stat()
+				return err
+			},
+			want: []string{"body panicked with Open config.json (call 1) failing"},
+		},
+		{
+			name: "leaked handle",
+			body: func(m *mockfs.MockFS) error {
+				f, err := m.Open("config.json")
+				if err != nil {
+					return err
+				}
+				if _, err := io.ReadAll(f); err != nil {
+					return err // f is never closed
+				}
+				return f.Close()
+			},
+			want: []string{
+				"left 1 handles open with Read config.json (call 1) failing",
+				"left 1 handles open with Read config.json (call 2) failing", // the read that hits EOF
+			},
+		},
+		{
+			name: "handle leaked across a crash",
+			body: func(m *mockfs.MockFS) error {
+				f, err := m.Open("config.json")
+				if err != nil {
+					return err
+				}
+				m.Crash()
+				if _, err := f.Read(make([]byte, 1)); err != nil {
+					return err // f is never closed
+				}
+				return f.Close()
+			},
+			want: []string{"left 1 handles open with Read config.json (call 1) failing"},
+		},
+		{
+			name: "hang",
+			body: func(m *mockfs.MockFS) error {
+				if _, err := m.Stat("config.json"); err != nil {
+					<-release
+				}
+				return nil
+			},
+			want: []string{"body hung with Stat config.json (call 1) failing"},
+		},
+		{
+			name: "failure without faults",
+			body: func(*mockfs.MockFS) error { return mockfs.ErrTimeout },
+			want: []string{"body failed without faults: operation timeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &fakeReporter{}
+			mockfs.FaultSweep{Timeout: 50 * time.Millisecond}.Run(r, configFixture, tt.body)
+
+			if len(r.errors) != len(tt.want) {
+				t.Fatalf("reported %q, want %d reports", r.errors, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(r.errors[i], want) {
+					t.Errorf("report %q does not contain %q", r.errors[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultSweep_Check(t *testing.T) {
+	t.Parallel()
+
+	// Writing in place is not atomic: a failed write leaves a truncated file
+	writeInPlace := func(m *mockfs.MockFS) error {
+		f, err := m.OpenFile("config.json", os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write([]byte("new"))
+		return err
+	}
+	oldOrNew := func(m *mockfs.MockFS) error {
+		data, err := m.ReadFile("config.json")
+		if err != nil {
+			return err
+		}
+		if s := string(data); s != "old" && s != "new" {
+			return fmt.Errorf("config.json = %q", s)
+		}
+		return nil
+	}
+
+	r := &fakeReporter{}
+	mockfs.FaultSweep{Check: oldOrNew}.Run(r, configFixture, writeInPlace)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], `inconsistent filesystem with Write config.json (call 1) failing with injected fault: config.json = ""`) {
+		t.Errorf("reported %q, want the failed write", r.errors)
+	}
+
+	r = &fakeReporter{}
+	mockfs.FaultSweep{Check: oldOrNew}.Run(r, configFixture, saveConfig)
+	if len(r.errors) != 0 {
+		t.Errorf("reported %q for an atomic save", r.errors)
+	}
+}
@@ -0,0 +1,329 @@
+package mockfs
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"testing/fstest"
+	"time"
+)
+
+// InodeInfo describes the inode behind a MockFS entry.
+// It is the value returned by Sys() on the fs.FileInfo and fs.DirEntry values
+// produced by a MockFS and its file handles.
+type InodeInfo struct {
+	Ino   uint64    // Inode number, unique within the owning MockFS.
+	Nlink int       // Number of directory entries (hard links) referring to the inode.
+	Uid   int       // Owner user ID.
+	Gid   int       // Owner group ID.
+	Atime time.Time // Last access time, as set by Chtimes.
+}
+
+// inode is a file, directory, or symbolic link independent of the names it is
+// reachable by. Every path in MockFS.files points at an inode, several paths
+// may share one (hard links), and open MockFile handles keep theirs alive
+// after the last name is removed, matching POSIX unlink-while-open semantics.
+type inode struct {
+	*fstest.MapFile           // File data, mode, and modification time.
+	ino             uint64    // Inode number; 0 for standalone MockFile inodes.
+	nlink           int       // Number of directory entries referring to the inode.
+	nopen           int       // Number of open MockFile handles referring to the inode.
+	atime           time.Time // Last access time.
+	uid             int       // Owner user ID.
+	gid             int       // Owner group ID.
+
+	virt *virtualData        // Generated content, in place of Data; nil for other inodes (see content.go).
+	dyn  func() (any, error) // Content function of a dynamic file; nil for other inodes (see dynamic.go).
+
+	// Durability state, consulted by MockFS.Crash (see durability.go)
+	dirty      bool              // Whether Data may differ from its last synced state.
+	synced     []byte            // Data as of the last sync, while dirty.
+	syncedVirt *virtualData      // Generated content as of the last sync, while dirty.
+	lastWrite  *pendingWrite     // Last write since the last sync.
+	entries    map[string]*inode // Directory entries as of the last sync, by base name.
+
+	previous     []byte       // Data before the latest change, read by StaleContent; nil if never changed.
+	previousVirt *virtualData // Generated content before the latest change, if it was generated.
+}
+
+// newStandaloneInode wraps a MapFile that does not belong to any MockFS.
+func newStandaloneInode(mapFile *fstest.MapFile) *inode {
+	return &inode{MapFile: mapFile, nlink: 1, atime: mapFile.ModTime}
+}
+
+// info returns the InodeInfo snapshot exposed through FileInfo.Sys.
+func (n *inode) info() *InodeInfo {
+	return &InodeInfo{Ino: n.ino, Nlink: n.nlink, Uid: n.uid, Gid: n.gid, Atime: n.atime}
+}
+
+// fileInfo builds a FileInfo for the inode under the given base name.
+func (n *inode) fileInfo(name string) *FileInfo {
+	return &FileInfo{
+		name:    name,
+		size:    n.size(),
+		mode:    n.Mode,
+		modTime: n.ModTime,
+		sys:     n.info(),
+	}
+}
+
+// --- Inode table ---
+
+// newInode allocates an inode for mapFile and registers it in the inode table.
+// The inode starts with no links; callers attach it with link. A regular
+// file starts out unsynced, so a crash before its first sync empties it.
+// Caller must hold the write lock.
+func (m *MockFS) newInode(mapFile *fstest.MapFile) *inode {
+	m.nextIno++
+	n := &inode{MapFile: mapFile, ino: m.nextIno, atime: mapFile.ModTime, uid: m.uid, gid: m.primaryGid()}
+	if mapFile.Mode.IsRegular() {
+		n.dirty = true
+		if len(mapFile.Data) > 0 {
+			n.lastWrite = &pendingWrite{data: bytes.Clone(mapFile.Data)}
+		}
+	}
+	m.inodes[n.ino] = n
+	return n
+}
+
+// link makes cleanPath refer to n, replacing (and unlinking) any existing
+// entry, and reports the new entry to watchers as created.
+// Caller must hold the write lock.
+func (m *MockFS) link(cleanPath string, n *inode) {
+	if old, exists := m.files[cleanPath]; exists {
+		if old == n {
+			return
+		}
+		m.drop(cleanPath)
+	}
+
+	m.files[cleanPath] = n
+	n.nlink++
+	m.notify(cleanPath, EventCreate)
+}
+
+// unlink removes the cleanPath entry and drops its inode from the table once
+// nothing refers to it, and reports the removal to watchers.
+// Caller must hold the write lock.
+func (m *MockFS) unlink(cleanPath string) {
+	if _, exists := m.files[cleanPath]; exists {
+		m.drop(cleanPath)
+		m.notify(cleanPath, EventRemove)
+	}
+}
+
+// drop removes the cleanPath entry like unlink, without an event, for an
+// entry about to be replaced. Caller must hold the write lock.
+func (m *MockFS) drop(cleanPath string) {
+	n, exists := m.files[cleanPath]
+	if !exists {
+		return
+	}
+
+	delete(m.files, cleanPath)
+	n.nlink--
+	m.releaseInode(n)
+}
+
+// unlinkTree removes cleanPath and every entry below it, deepest first.
+// Caller must hold the write lock.
+func (m *MockFS) unlinkTree(cleanPath string) {
+	prefix := cleanPath + "/"
+	var paths []string
+	for p := range m.files {
+		if p == cleanPath || strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+
+	// Entries sort after their directory
+	slices.Sort(paths)
+	for _, p := range slices.Backward(paths) {
+		m.unlink(p)
+	}
+}
+
+// releaseInode drops n from the inode table when it has neither links nor open handles.
+// Inodes detached by Crash are no longer in the table and are left alone.
+// Caller must hold the write lock.
+func (m *MockFS) releaseInode(n *inode) {
+	if n.nlink <= 0 && n.nopen <= 0 && m.inodes[n.ino] == n {
+		delete(m.inodes, n.ino)
+	}
+}
+
+// checkOpenLimit returns the error for opening name when the limit set by
+// WithMaxOpenFiles is reached, and nil otherwise.
+// Caller must hold the write lock.
+func (m *MockFS) checkOpenLimit(name string) error {
+	if m.maxOpen > 0 && m.nopen >= m.maxOpen {
+		return &fs.PathError{Op: OpOpen.String(), Path: name, Err: ErrTooManyHandles}
+	}
+	return nil
+}
+
+// acquireInode records a new open handle on n. Caller must hold the write lock.
+func (m *MockFS) acquireInode(n *inode) {
+	n.nopen++
+	m.nopen++
+}
+
+// closeInode records that a handle on n was closed, freeing an unlinked
+// inode with its last handle. It takes the write lock itself.
+func (m *MockFS) closeInode(n *inode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n.nopen--
+	m.nopen--
+	m.releaseInode(n)
+}
+
+// --- Hard links ---
+
+// Link creates newname as a hard link to the oldname file, mirroring [os.Link].
+// Both names then refer to the same inode: writes through either name, or
+// through handles opened by either name, are visible through the other, and
+// the data survives until the last name is removed and the last handle is closed.
+//
+// A final symbolic link in oldname is not followed, so linking a symlink
+// creates a second link to the symlink itself. Returns ErrExist if newname
+// exists and ErrPermission if oldname is a directory.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Link(oldname, newname string) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpLink, oldname, 0, err) }()
+	j := m.journal.begin(OpLink, oldname)
+	j.to(newname)
+	defer func() { j.end(0, err) }()
+
+	cleanOld, err := m.validateAndCleanPath(oldname, OpLink)
+	if err != nil {
+		return err
+	}
+	cleanNew, err := m.validateAndCleanPath(newname, OpLink)
+	if err != nil {
+		return err
+	}
+
+	if err := m.injector.CheckAndApply(OpLink, cleanOld); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	j.simulate(m.latency, OpLink)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	opName := OpLink.String()
+
+	if m.writeMode == writeModeReadOnly {
+		return &fs.PathError{Op: opName, Path: newname, Err: ErrPermission}
+	}
+
+	_, n, err := m.lookup(opName, oldname, cleanOld, false)
+	if err != nil {
+		return err
+	}
+	if n.Mode.IsDir() {
+		return &fs.PathError{Op: opName, Path: oldname, Err: ErrPermission}
+	}
+
+	resolvedNew, err := m.resolvePath(opName, newname, cleanNew, false)
+	if err != nil {
+		return err
+	}
+	if _, exists := m.files[resolvedNew]; exists {
+		return &fs.PathError{Op: opName, Path: newname, Err: ErrExist}
+	}
+	if err := m.checkParentDir(opName, newname, resolvedNew); err != nil {
+		return err
+	}
+	if err := m.checkCreate(opName, newname, resolvedNew); err != nil {
+		return err
+	}
+
+	m.link(resolvedNew, n)
+	return nil
+}
+
+// --- Rename ---
+
+// renameEntry moves the entry at cleanOld (and, for a directory, everything
+// below it) to cleanNew without copying, so open handles keep referring to
+// the same inodes. An existing destination is replaced following rename(2):
+// a file may replace a file, a directory may replace an empty directory.
+// Caller must hold the write lock and have resolved both paths.
+func (m *MockFS) renameEntry(newpath, cleanOld, cleanNew string) error {
+	// Renaming onto the same inode, even through another hard link, is a no-op
+	n := m.files[cleanOld]
+	if cleanOld == cleanNew || m.files[cleanNew] == n {
+		return nil
+	}
+
+	if n.Mode.IsDir() && strings.HasPrefix(cleanNew, cleanOld+"/") {
+		return &fs.PathError{Op: "Rename", Path: newpath, Err: ErrInvalid}
+	}
+	if err := m.checkParentDir("Rename", newpath, cleanNew); err != nil {
+		return err
+	}
+	if err := m.checkRename(newpath, cleanOld, cleanNew, n); err != nil {
+		return err
+	}
+
+	if dst, exists := m.files[cleanNew]; exists {
+		switch {
+		case n.Mode.IsDir() && !dst.Mode.IsDir():
+			return &fs.PathError{Op: "Rename", Path: newpath, Err: ErrNotDir}
+		case !n.Mode.IsDir() && dst.Mode.IsDir():
+			return &fs.PathError{Op: "Rename", Path: newpath, Err: ErrIsDir}
+		case dst.Mode.IsDir() && m.hasChildren(cleanNew):
+			return &fs.PathError{Op: "Rename", Path: newpath, Err: ErrNotEmpty}
+		}
+	}
+	if err := m.checkMove(newpath, cleanOld, cleanNew); err != nil {
+		return err
+	}
+	if _, exists := m.files[cleanNew]; exists {
+		m.drop(cleanNew)
+	}
+
+	// Move the entry itself, then its descendants, keeping each inode
+	m.files[cleanNew] = n
+	delete(m.files, cleanOld)
+
+	if n.Mode.IsDir() {
+		oldPrefix := cleanOld + "/"
+		for p, child := range m.files {
+			if rel, ok := strings.CutPrefix(p, oldPrefix); ok {
+				m.files[path.Join(cleanNew, rel)] = child
+				delete(m.files, p)
+			}
+		}
+	}
+
+	m.notify(cleanOld, EventRename)
+	m.notify(cleanNew, EventCreate)
+	return nil
+}
+
+// hasChildren reports whether any entry exists below the cleanPath directory.
+// Caller must hold the mutex.
+func (m *MockFS) hasChildren(cleanPath string) bool {
+	prefix := cleanPath + "/"
+	if cleanPath == "." {
+		prefix = ""
+	}
+
+	for p := range m.files {
+		if p != "." && strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package mockfs_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// inodeOf returns the InodeInfo reported by Stat for name.
+func inodeOf(tb testing.TB, mfs *mockfs.MockFS, name string) *mockfs.InodeInfo {
+	tb.Helper()
+
+	info, err := mfs.Stat(name)
+	requireNoError(tb, err, name)
+
+	ino, ok := info.Sys().(*mockfs.InodeInfo)
+	if !ok {
+		tb.Fatalf("Stat(%q).Sys() = %T, want *mockfs.InodeInfo", name, info.Sys())
+	}
+	return ino
+}
+
+func TestMockFS_Link(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []mockfs.FsOption
+		oldname string
+		newname string
+		wantErr error
+	}{
+		{name: "link file", oldname: "file.txt", newname: "hard.txt"},
+		{name: "link into subdirectory", oldname: "file.txt", newname: "dir/hard.txt"},
+		{name: "existing destination", oldname: "file.txt", newname: "dir/child.txt", wantErr: mockfs.ErrExist},
+		{name: "missing source", oldname: "missing.txt", newname: "hard.txt", wantErr: mockfs.ErrNotExist},
+		{name: "missing parent", oldname: "file.txt", newname: "missing/hard.txt", wantErr: mockfs.ErrNotExist},
+		{name: "directory source", oldname: "dir", newname: "hard", wantErr: mockfs.ErrPermission},
+		{name: "invalid path", oldname: "file.txt", newname: "../hard.txt", wantErr: mockfs.ErrInvalid},
+		{
+			name:    "read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			oldname: "file.txt",
+			newname: "hard.txt",
+			wantErr: mockfs.ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]mockfs.FsOption{
+				mockfs.File("file.txt", "content"),
+				mockfs.Dir("dir", mockfs.File("child.txt", "child")),
+			}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			err := mfs.Link(tt.oldname, tt.newname)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+				return
+			}
+			requireNoError(t, err)
+
+			if got := string(mustReadFile(t, mfs, tt.newname)); got != "content" {
+				t.Errorf("ReadFile(%q) = %q, want %q", tt.newname, got, "content")
+			}
+		})
+	}
+}
+
+func TestMockFS_LinkSharesInode(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a.txt", "one"), mockfs.File("b.txt", "other"))
+	requireNoError(t, mfs.Link("a.txt", "c.txt"))
+
+	a, c, b := inodeOf(t, mfs, "a.txt"), inodeOf(t, mfs, "c.txt"), inodeOf(t, mfs, "b.txt")
+	if a.Ino != c.Ino {
+		t.Errorf("linked names have inodes %d and %d, want equal", a.Ino, c.Ino)
+	}
+	if a.Ino == b.Ino {
+		t.Errorf("distinct files share inode %d", a.Ino)
+	}
+	if a.Nlink != 2 || b.Nlink != 1 {
+		t.Errorf("Nlink = %d and %d, want 2 and 1", a.Nlink, b.Nlink)
+	}
+
+	// A write through one name is visible through the other
+	requireNoError(t, mfs.WriteFile("c.txt", []byte("two"), 0o644))
+	if got := string(mustReadFile(t, mfs, "a.txt")); got != "two" {
+		t.Errorf("ReadFile(a.txt) = %q, want %q", got, "two")
+	}
+
+	// Removing one name keeps the data behind the other
+	requireNoError(t, mfs.Remove("a.txt"))
+	if got := inodeOf(t, mfs, "c.txt").Nlink; got != 1 {
+		t.Errorf("Nlink after Remove = %d, want 1", got)
+	}
+	if got := string(mustReadFile(t, mfs, "c.txt")); got != "two" {
+		t.Errorf("ReadFile(c.txt) = %q, want %q", got, "two")
+	}
+
+	// Directory entries and open handles report the same inode
+	entries, err := mfs.ReadDir(".")
+	requireNoError(t, err)
+	for _, e := range entries {
+		info, err := e.Info()
+		requireNoError(t, err)
+		if _, ok := info.Sys().(*mockfs.InodeInfo); !ok {
+			t.Errorf("ReadDir entry %q Sys() = %T, want *mockfs.InodeInfo", e.Name(), info.Sys())
+		}
+	}
+
+	f, err := mfs.OpenMockFile("c.txt")
+	requireNoError(t, err)
+	info, err := f.Stat()
+	requireNoError(t, err)
+	if got, ok := info.Sys().(*mockfs.InodeInfo); !ok || got.Ino != c.Ino {
+		t.Errorf("handle Stat().Sys() = %v, want inode %d", info.Sys(), c.Ino)
+	}
+	requireNoError(t, f.Close())
+}
+
+func TestMockFS_UnlinkWhileOpen(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("data.txt", "hello"))
+
+	f, err := mfs.OpenFile("data.txt", os.O_RDWR|os.O_APPEND, 0)
+	requireNoError(t, err)
+
+	requireNoError(t, mfs.Remove("data.txt"))
+
+	if _, err := mfs.Stat("data.txt"); err == nil {
+		t.Fatal("Stat after Remove: expected error")
+	}
+
+	// The handle still reads and writes the unlinked file
+	_, err = f.Write([]byte(" world"))
+	requireNoError(t, err)
+
+	got, err := io.ReadAll(f)
+	requireNoError(t, err)
+	if string(got) != "hello world" {
+		t.Errorf("read after unlink = %q, want %q", got, "hello world")
+	}
+
+	info, err := f.Stat()
+	requireNoError(t, err)
+	if ino := info.Sys().(*mockfs.InodeInfo); ino.Nlink != 0 {
+		t.Errorf("Nlink of unlinked open file = %d, want 0", ino.Nlink)
+	}
+
+	requireNoError(t, f.Close())
+
+	// Re-creating the name yields a fresh file
+	requireNoError(t, mfs.AddFile("data.txt", "new"))
+	if got := string(mustReadFile(t, mfs, "data.txt")); got != "new" {
+		t.Errorf("ReadFile after re-create = %q, want %q", got, "new")
+	}
+}
+
+func TestMockFS_RenameKeepsHandles(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.Dir("logs", mockfs.File("app.log", "line1\n")),
+		mockfs.Dir("archive"),
+	)
+	before := inodeOf(t, mfs, "logs/app.log")
+
+	f, err := mfs.OpenFile("logs/app.log", os.O_WRONLY|os.O_APPEND, 0)
+	requireNoError(t, err)
+
+	// Rotate the log, then write through the handle opened before the rotation
+	requireNoError(t, mfs.Rename("logs/app.log", "logs/app.log.1"))
+	_, err = f.Write([]byte("line2\n"))
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+
+	if got := string(mustReadFile(t, mfs, "logs/app.log.1")); got != "line1\nline2\n" {
+		t.Errorf("rotated log = %q, want %q", got, "line1\nline2\n")
+	}
+	if after := inodeOf(t, mfs, "logs/app.log.1"); after.Ino != before.Ino {
+		t.Errorf("inode after Rename = %d, want %d", after.Ino, before.Ino)
+	}
+
+	// Directory renames carry their children and open handles along
+	d, err := mfs.OpenFile("logs/app.log.1", os.O_RDWR|os.O_APPEND, 0)
+	requireNoError(t, err)
+	requireNoError(t, mfs.Rename("logs", "archive/logs"))
+	_, err = d.Write([]byte("line3\n"))
+	requireNoError(t, err)
+	requireNoError(t, d.Close())
+
+	if got := string(mustReadFile(t, mfs, "archive/logs/app.log.1")); got != "line1\nline2\nline3\n" {
+		t.Errorf("moved log = %q, want %q", got, "line1\nline2\nline3\n")
+	}
+}
+
+func TestMockFS_RenameReplace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		oldpath string
+		newpath string
+		wantErr error
+	}{
+		{name: "file onto file", oldpath: "config.tmp", newpath: "config.json"},
+		{name: "directory onto empty directory", oldpath: "full", newpath: "empty"},
+		{name: "same path", oldpath: "config.json", newpath: "config.json"},
+		{name: "file onto directory", oldpath: "config.tmp", newpath: "empty", wantErr: mockfs.ErrIsDir},
+		{name: "directory onto file", oldpath: "empty", newpath: "config.json", wantErr: mockfs.ErrNotDir},
+		{name: "directory onto non-empty directory", oldpath: "empty", newpath: "full", wantErr: mockfs.ErrNotEmpty},
+		{name: "directory into itself", oldpath: "full", newpath: "full/sub", wantErr: mockfs.ErrInvalid},
+		{name: "missing destination parent", oldpath: "config.tmp", newpath: "missing/config", wantErr: mockfs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(
+				mockfs.File("config.json", "old"),
+				mockfs.File("config.tmp", "new"),
+				mockfs.Dir("empty"),
+				mockfs.Dir("full", mockfs.File("item", "x")),
+			)
+
+			err := mfs.Rename(tt.oldpath, tt.newpath)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+				if _, statErr := mfs.Stat(tt.oldpath); statErr != nil {
+					t.Errorf("source removed by failed Rename: %v", statErr)
+				}
+				return
+			}
+			requireNoError(t, err)
+
+			if _, err := mfs.Stat(tt.newpath); err != nil {
+				t.Errorf("Stat(%q) after Rename: %v", tt.newpath, err)
+			}
+		})
+	}
+}
+
+func TestMockFS_RenameOntoHardLink(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"))
+	requireNoError(t, mfs.Link("a", "b"))
+
+	// Both names refer to the same inode, so nothing changes
+	requireNoError(t, mfs.Rename("a", "b"))
+	for _, name := range []string{"a", "b"} {
+		if ino := inodeOf(t, mfs, name); ino.Nlink != 2 {
+			t.Errorf("Stat(%q).Nlink = %d after Rename, want 2", name, ino.Nlink)
+		}
+	}
+}
+
+func TestMockFS_RenameReplaceKeepsOldHandle(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("config.json", "old"), mockfs.File("config.tmp", "new"))
+
+	f, err := mfs.Open("config.json")
+	requireNoError(t, err)
+
+	// Atomic replace: readers that opened the old file keep its content
+	requireNoError(t, mfs.Rename("config.tmp", "config.json"))
+
+	got, err := io.ReadAll(f)
+	requireNoError(t, err)
+	if string(got) != "old" {
+		t.Errorf("read from replaced file = %q, want %q", got, "old")
+	}
+	requireNoError(t, f.Close())
+
+	if got := string(mustReadFile(t, mfs, "config.json")); got != "new" {
+		t.Errorf("ReadFile after replace = %q, want %q", got, "new")
+	}
+	_, err = mfs.Stat("config.tmp")
+	assertError(t, err, mockfs.ErrNotExist)
+}
+
+func TestMockFS_SubPreservesHardLinks(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.Dir("dir", mockfs.File("a.txt", "shared")))
+	requireNoError(t, mfs.Link("dir/a.txt", "dir/b.txt"))
+
+	sub, err := mfs.Sub("dir")
+	requireNoError(t, err)
+	subFS := sub.(*mockfs.MockFS)
+
+	if a, b := inodeOf(t, subFS, "a.txt"), inodeOf(t, subFS, "b.txt"); a.Ino != b.Ino || a.Nlink != 2 {
+		t.Errorf("sub inodes = %+v and %+v, want one inode with Nlink 2", a, b)
+	}
+}
+
+func TestMockFS_LinkInjectionAndStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "content"))
+	requireNoError(t, mfs.ErrorInjector().AddExact(mockfs.OpLink, "file.txt", mockfs.ErrDiskFull, mockfs.ErrorModeOnce, 0))
+
+	assertError(t, mfs.Link("file.txt", "hard.txt"), mockfs.ErrDiskFull)
+	requireNoError(t, mfs.Link("file.txt", "hard.txt"))
+
+	mfs.Stats().Expect().
+		Count(mockfs.OpLink, 2).
+		Failure(mockfs.OpLink, 1).
+		Assert(t)
+}
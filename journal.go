@@ -0,0 +1,252 @@
+package mockfs
+
+import (
+	"fmt"
+	"iter"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JournalEntry is one operation recorded by the journal of a MockFS.
+type JournalEntry struct {
+	Seq      uint64        // Position in the journal, starting at 1.
+	Op       Operation     // Operation performed.
+	Path     string        // Cleaned path; the old path for Rename and Link, the link name for Symlink.
+	NewPath  string        // New path for Rename and Link, target for Symlink; empty otherwise.
+	Handle   uint64        // Handle the operation ran on, or the handle Open returned; 0 otherwise.
+	Offset   int64         // Start of a read or write, offset after a Seek, size for Truncate; -1 otherwise.
+	Bytes    int           // Bytes read or written.
+	Err      error         // Error returned by the operation.
+	Injected bool          // Whether an error-injection rule decided the outcome.
+	Latency  time.Duration // Time spent in simulated latency.
+	Time     time.Time     // When the operation started.
+}
+
+// String returns a one-line description of the entry.
+func (e JournalEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#%d %s %s", e.Seq, e.Op, e.Path)
+	if e.NewPath != "" {
+		fmt.Fprintf(&b, " -> %s", e.NewPath)
+	}
+	if e.Handle != 0 {
+		fmt.Fprintf(&b, " [h%d]", e.Handle)
+	}
+	if e.Offset >= 0 {
+		fmt.Fprintf(&b, " @%d", e.Offset)
+	}
+	if e.Bytes != 0 {
+		fmt.Fprintf(&b, " %dB", e.Bytes)
+	}
+	switch {
+	case e.Injected && e.Err != nil:
+		fmt.Fprintf(&b, ": %v (injected)", e.Err)
+	case e.Injected:
+		b.WriteString(": short (injected)")
+	case e.Err != nil:
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+// JournalFilter selects journal entries. See MockFS.Journal.
+type JournalFilter func(JournalEntry) bool
+
+// JournalPath selects the entries that involve any of the given paths, as
+// either Path or NewPath. Paths are cleaned before they are compared.
+func JournalPath(paths ...string) JournalFilter {
+	clean := make([]string, len(paths))
+	for i, p := range paths {
+		clean[i] = path.Clean(p)
+	}
+
+	return func(e JournalEntry) bool {
+		return slices.Contains(clean, e.Path) || (e.NewPath != "" && slices.Contains(clean, e.NewPath))
+	}
+}
+
+// JournalOps selects the entries for any of the given operations.
+func JournalOps(ops ...Operation) JournalFilter {
+	return func(e JournalEntry) bool {
+		return slices.Contains(ops, e.Op)
+	}
+}
+
+// WithJournal turns on the operation journal: every filesystem operation, and
+// every operation on a handle the filesystem opens, is recorded as a
+// JournalEntry in the order the operations complete. See MockFS.Journal.
+func WithJournal() FsOption {
+	return func(m *MockFS) error {
+		m.journal = &journal{}
+		return nil
+	}
+}
+
+// Journal returns an iterator over the journal entries that pass every
+// filter, oldest first. The iterator works on a snapshot taken when Journal
+// is called, so operations may run while it is consumed.
+// Without WithJournal, the journal is always empty.
+//
+// The journal orders operations by completion, which is what assertions
+// about ordering need, such as "the temp file was closed before the rename":
+//
+//	for e := range mfs.Journal(mockfs.JournalPath("db.tmp")) {
+//		...
+//	}
+func (m *MockFS) Journal(filters ...JournalFilter) iter.Seq[JournalEntry] {
+	entries := m.journal.snapshot()
+
+	return func(yield func(JournalEntry) bool) {
+	next:
+		for _, e := range entries {
+			for _, keep := range filters {
+				if keep != nil && !keep(e) {
+					continue next
+				}
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// ResetJournal discards all journal entries. Sequence numbers start again at 1.
+func (m *MockFS) ResetJournal() {
+	m.journal.reset()
+}
+
+// journal is the ordered log of operations behind MockFS.Journal.
+// A nil *journal records nothing.
+type journal struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+// begin starts the entry for an operation on name. It returns nil, which
+// ignores all calls, if j is nil.
+func (j *journal) begin(op Operation, name string) *journalOp {
+	if j == nil {
+		return nil
+	}
+
+	return &journalOp{j: j, e: JournalEntry{
+		Op:     op,
+		Path:   path.Clean(name),
+		Offset: -1,
+		Time:   time.Now(),
+	}}
+}
+
+// snapshot returns a copy of the entries.
+func (j *journal) snapshot() []JournalEntry {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return slices.Clone(j.entries)
+}
+
+// reset discards all entries.
+func (j *journal) reset() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = nil
+}
+
+// journalOp collects the entry of one operation until it completes.
+// A nil *journalOp ignores all calls.
+type journalOp struct {
+	j *journal
+	e JournalEntry
+}
+
+// simulate applies the simulated latency for op and records how long it took.
+func (o *journalOp) simulate(l LatencySimulator, op Operation) {
+	if o == nil {
+		l.Simulate(op)
+		return
+	}
+
+	start := time.Now()
+	l.Simulate(op)
+	o.e.Latency = time.Since(start)
+}
+
+// fault marks the outcome of the operation as injected.
+func (o *journalOp) fault() {
+	if o != nil {
+		o.e.Injected = true
+	}
+}
+
+// transfer records a read or write of requested bytes at off, of which an
+// injection rule let limit through before returning err.
+func (o *journalOp) transfer(off int64, requested, limit int, err error) {
+	if o == nil {
+		return
+	}
+
+	o.e.Offset = off
+	o.e.Injected = err != nil || limit < requested
+}
+
+// at records the offset of the operation.
+func (o *journalOp) at(off int64) {
+	if o != nil {
+		o.e.Offset = off
+	}
+}
+
+// to records the second path of the operation.
+func (o *journalOp) to(name string) {
+	if o != nil {
+		o.e.NewPath = path.Clean(name)
+	}
+}
+
+// on records the handle of the operation.
+func (o *journalOp) on(f *MockFile) {
+	if o != nil && f != nil {
+		o.e.Handle = f.id
+	}
+}
+
+// end completes the entry with the result of the operation and appends it
+// to the journal.
+func (o *journalOp) end(n int, err error) {
+	if o == nil {
+		return
+	}
+
+	o.e.Bytes, o.e.Err = n, err
+
+	o.j.mu.Lock()
+	defer o.j.mu.Unlock()
+
+	o.e.Seq = uint64(len(o.j.entries)) + 1
+	o.j.entries = append(o.j.entries, o.e)
+}
+
+// track starts the journal entry for an operation on the handle. Standalone
+// files have no journal.
+func (f *MockFile) track(op Operation) *journalOp {
+	if f.fsys == nil {
+		return nil
+	}
+
+	o := f.fsys.journal.begin(op, f.name)
+	o.on(f)
+	return o
+}
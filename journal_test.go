@@ -0,0 +1,243 @@
+package mockfs_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// journalOps returns the operations of the journal entries that pass filters.
+func journalOps(mfs *mockfs.MockFS, filters ...mockfs.JournalFilter) []mockfs.Operation {
+	var ops []mockfs.Operation
+	for e := range mfs.Journal(filters...) {
+		ops = append(ops, e.Op)
+	}
+	return ops
+}
+
+func TestMockFS_Journal_Disabled(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"))
+	_ = mustReadFile(t, mfs, "a")
+
+	if ops := journalOps(mfs); len(ops) != 0 {
+		t.Errorf("journal = %v, want empty without WithJournal", ops)
+	}
+}
+
+func TestMockFS_Journal_AtomicReplace(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithJournal(), mockfs.File("db", "old"))
+
+	f, err := mfs.Create("db.tmp")
+	requireNoError(t, err)
+	_, err = f.Write([]byte("new"))
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+	requireNoError(t, mfs.Rename("db.tmp", "db"))
+
+	entries := slices.Collect(mfs.Journal(mockfs.JournalPath("db.tmp")))
+	want := []mockfs.Operation{mockfs.OpOpen, mockfs.OpWrite, mockfs.OpClose, mockfs.OpRename}
+	if got := journalOps(mfs, mockfs.JournalPath("db.tmp")); !slices.Equal(got, want) {
+		t.Fatalf("ops = %v, want %v", got, want)
+	}
+
+	open, write, closeEntry, rename := entries[0], entries[1], entries[2], entries[3]
+	if open.Handle == 0 || write.Handle != open.Handle || closeEntry.Handle != open.Handle {
+		t.Errorf("handles = %d, %d, %d, want the same non-zero ID", open.Handle, write.Handle, closeEntry.Handle)
+	}
+	if write.Offset != 0 || write.Bytes != 3 {
+		t.Errorf("write = %v, want 3 bytes at 0", write)
+	}
+	if rename.Path != "db.tmp" || rename.NewPath != "db" || rename.Handle != 0 || rename.Offset != -1 {
+		t.Errorf("rename = %v, want db.tmp -> db without handle or offset", rename)
+	}
+	if closeEntry.Seq >= rename.Seq {
+		t.Errorf("close #%d not before rename #%d", closeEntry.Seq, rename.Seq)
+	}
+
+	// The new name matches the rename too
+	if got := journalOps(mfs, mockfs.JournalPath("db")); !slices.Equal(got, []mockfs.Operation{mockfs.OpRename}) {
+		t.Errorf("ops on db = %v, want [Rename]", got)
+	}
+}
+
+func TestMockFS_Journal_Injected(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithJournal(), mockfs.File("a", "abcdef"), mockfs.File("b", "abcdef"))
+	requireNoError(t, mfs.FailStatOnce("a", mockfs.ErrTimeout))
+	requireNoError(t, mfs.ShortRead("b", 2))
+
+	_, err := mfs.Stat("a")
+	assertError(t, err, mockfs.ErrTimeout)
+	_, err = mfs.Stat("a")
+	requireNoError(t, err)
+
+	f, err := mfs.Open("b")
+	requireNoError(t, err)
+	_, err = f.Read(make([]byte, 4))
+	requireNoError(t, err)
+	_, err = f.Read(make([]byte, 4))
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+
+	tests := []struct {
+		name     string
+		filter   mockfs.JournalFilter
+		injected []bool
+		errs     []error
+	}{
+		{
+			name:     "failed stat",
+			filter:   mockfs.JournalOps(mockfs.OpStat),
+			injected: []bool{true, false},
+			errs:     []error{mockfs.ErrTimeout, nil},
+		},
+		{
+			name:     "short reads",
+			filter:   mockfs.JournalOps(mockfs.OpRead),
+			injected: []bool{true, true},
+			errs:     []error{nil, nil},
+		},
+		{
+			name:     "close",
+			filter:   mockfs.JournalOps(mockfs.OpClose),
+			injected: []bool{false},
+			errs:     []error{nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries := slices.Collect(mfs.Journal(tt.filter))
+			if len(entries) != len(tt.injected) {
+				t.Fatalf("entries = %v, want %d", entries, len(tt.injected))
+			}
+			for i, e := range entries {
+				if e.Injected != tt.injected[i] || !errors.Is(e.Err, tt.errs[i]) {
+					t.Errorf("entry %d = %v, want injected %v with error %v", i, e, tt.injected[i], tt.errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMockFS_Journal_Offsets(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithJournal(), mockfs.File("log", "0123456789"))
+
+	f, err := mfs.OpenFile("log", os.O_RDWR, 0)
+	requireNoError(t, err)
+	_, err = f.Seek(4, io.SeekStart)
+	requireNoError(t, err)
+	_, err = f.Read(make([]byte, 3))
+	requireNoError(t, err)
+	_, err = f.ReadAt(make([]byte, 2), 8)
+	requireNoError(t, err)
+	_, err = f.WriteAt([]byte("x"), 1)
+	requireNoError(t, err)
+	requireNoError(t, f.(*mockfs.MockFile).Truncate(5))
+	requireNoError(t, f.Close())
+	requireNoError(t, mfs.Symlink("log", "current"))
+
+	var got []string
+	for e := range mfs.Journal() {
+		e.Seq, e.Time, e.Handle = 0, time.Time{}, 0
+		got = append(got, e.String())
+	}
+	want := []string{
+		"#0 Open log",
+		"#0 Seek log @4",
+		"#0 Read log @4 3B",
+		"#0 Read log @8 2B",
+		"#0 Write log @1 1B",
+		"#0 Truncate log @5",
+		"#0 Close log",
+		"#0 Symlink current -> log",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("journal =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMockFS_Journal_Latency(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		mfs := mockfs.MustNewMockFS(
+			mockfs.WithJournal(),
+			mockfs.WithPerOperationLatency(map[mockfs.Operation]time.Duration{mockfs.OpStat: 10 * time.Millisecond}),
+			mockfs.File("a", "data"),
+		)
+
+		start := time.Now()
+		_, err := mfs.Stat("a")
+		requireNoError(t, err)
+		requireNoError(t, mfs.Chmod("a", 0o600))
+
+		entries := slices.Collect(mfs.Journal())
+		if len(entries) != 2 {
+			t.Fatalf("entries = %v, want 2", entries)
+		}
+		if e := entries[0]; e.Latency != 10*time.Millisecond || !e.Time.Equal(start) {
+			t.Errorf("stat latency %v at %v, want 10ms at %v", e.Latency, e.Time, start)
+		}
+		if e := entries[1]; e.Latency != 0 || !e.Time.Equal(start.Add(10*time.Millisecond)) {
+			t.Errorf("chmod latency %v at %v, want 0 after the stat", e.Latency, e.Time)
+		}
+	})
+}
+
+func TestMockFS_Journal_Iteration(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithJournal(), mockfs.File("a", "data"), mockfs.Dir("d"))
+	for range 3 {
+		_, err := mfs.Stat("a")
+		requireNoError(t, err)
+	}
+
+	// Stopping early is honored, and operations may run meanwhile
+	var seqs []uint64
+	for e := range mfs.Journal() {
+		seqs = append(seqs, e.Seq)
+		_, _ = mfs.Stat("a")
+		if len(seqs) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(seqs, []uint64{1, 2}) {
+		t.Errorf("seqs = %v, want [1 2]", seqs)
+	}
+
+	mfs.ResetJournal()
+	requireNoError(t, mfs.Mkdir("e", 0o755))
+	entries := slices.Collect(mfs.Journal(mockfs.JournalOps(mockfs.OpMkdir), mockfs.JournalPath("e")))
+	if len(entries) != 1 || entries[0].Seq != 1 {
+		t.Errorf("entries after reset = %v, want a single #1 Mkdir", entries)
+	}
+
+	// A sub-filesystem keeps a journal of its own
+	sub, err := mfs.Sub("d")
+	requireNoError(t, err)
+	_, err = sub.(*mockfs.MockFS).Stat(".")
+	requireNoError(t, err)
+	if ops := journalOps(sub.(*mockfs.MockFS)); !slices.Equal(ops, []mockfs.Operation{mockfs.OpStat}) {
+		t.Errorf("sub journal = %v, want [Stat]", ops)
+	}
+	if ops := journalOps(mfs); len(ops) != 1 {
+		t.Errorf("journal = %v, want only the Mkdir", ops)
+	}
+}
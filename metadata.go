@@ -0,0 +1,200 @@
+package mockfs
+
+import (
+	"io/fs"
+	"time"
+)
+
+// chmodMask holds the mode bits Chmod may change, matching [os.Chmod]:
+// the permission bits plus setuid, setgid, and sticky.
+const chmodMask = ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+
+// chmod replaces the inode's chmodMask bits with those of mode.
+func (n *inode) chmod(mode FileMode) {
+	n.Mode = n.Mode&^chmodMask | mode&chmodMask
+}
+
+// chtimes sets the access and modification times.
+// A zero time leaves the corresponding time unchanged.
+func (n *inode) chtimes(atime, mtime time.Time) {
+	if !atime.IsZero() {
+		n.atime = atime
+	}
+	if !mtime.IsZero() {
+		n.ModTime = mtime
+	}
+}
+
+// chown sets the owner user and group IDs. An ID of -1 is left unchanged.
+func (n *inode) chown(uid, gid int) {
+	if uid != -1 {
+		n.uid = uid
+	}
+	if gid != -1 {
+		n.gid = gid
+	}
+}
+
+// --- MockFS ---
+
+// Chmod changes the mode of the named file to mode, mirroring [os.Chmod].
+// The permission bits and the setuid, setgid, and sticky bits are replaced;
+// the file type is kept. A symbolic link is followed.
+// Returns ErrPermission on a read-only filesystem (WithReadOnly).
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Chmod(name string, mode FileMode) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpChmod, name, 0, err) }()
+	j := m.journal.begin(OpChmod, name)
+	defer func() { j.end(0, err) }()
+
+	return m.changeInode(j, OpChmod, name, func(n *inode) { n.chmod(mode) })
+}
+
+// Chtimes changes the access and modification times of the named file,
+// mirroring [os.Chtimes]. A zero time.Time value leaves the corresponding
+// time unchanged. A symbolic link is followed.
+// The access time is reported by FileInfo.Sys as InodeInfo.Atime.
+// Returns ErrPermission on a read-only filesystem (WithReadOnly).
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Chtimes(name string, atime, mtime time.Time) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpChtimes, name, 0, err) }()
+	j := m.journal.begin(OpChtimes, name)
+	defer func() { j.end(0, err) }()
+
+	return m.changeInode(j, OpChtimes, name, func(n *inode) { n.chtimes(atime, mtime) })
+}
+
+// Chown changes the numeric uid and gid of the named file, mirroring [os.Chown].
+// An ID of -1 leaves the corresponding value unchanged. A symbolic link is followed.
+// The owner is reported by FileInfo.Sys as InodeInfo.Uid and InodeInfo.Gid.
+// Returns ErrPermission on a read-only filesystem (WithReadOnly).
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Chown(name string, uid, gid int) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpChown, name, 0, err) }()
+	j := m.journal.begin(OpChown, name)
+	defer func() { j.end(0, err) }()
+
+	return m.changeInode(j, OpChown, name, func(n *inode) { n.chown(uid, gid) })
+}
+
+// changeInode runs the simulation layer for a metadata change of the named
+// file and then applies change to its inode under the write lock.
+func (m *MockFS) changeInode(j *journalOp, op Operation, name string, change func(*inode)) error {
+	cleanName, err := m.validateAndCleanPath(name, op)
+	if err != nil {
+		return err
+	}
+
+	if err := m.injector.CheckAndApply(op, cleanName); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	j.simulate(m.latency, op)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.writeMode == writeModeReadOnly {
+		return &fs.PathError{Op: op.String(), Path: name, Err: ErrPermission}
+	}
+
+	resolved, node, err := m.lookup(op.String(), name, cleanName, true)
+	if err != nil {
+		return err
+	}
+
+	// Only the owner may change mode and times; Chown stays unrestricted
+	// so that tests can arrange ownership
+	if op != OpChown && !m.owns(node) {
+		return &fs.PathError{Op: op.String(), Path: name, Err: ErrPermission}
+	}
+
+	change(node)
+	m.notify(resolved, EventChmod)
+	return nil
+}
+
+// --- MockFile ---
+
+// Chmod changes the mode of the file to mode, mirroring [os.File.Chmod].
+// See MockFS.Chmod for the bits that are changed.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (f *MockFile) Chmod(mode FileMode) (err error) {
+	// Record the result of this operation on exit
+	defer func() { f.stats.RecordPath(OpChmod, f.name, 0, err) }()
+	j := f.track(OpChmod)
+	defer func() { j.end(0, err) }()
+
+	return f.changeInode(j, OpChmod, func(n *inode) { n.chmod(mode) })
+}
+
+// Chtimes changes the access and modification times of the file.
+// A zero time.Time value leaves the corresponding time unchanged.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (f *MockFile) Chtimes(atime, mtime time.Time) (err error) {
+	// Record the result of this operation on exit
+	defer func() { f.stats.RecordPath(OpChtimes, f.name, 0, err) }()
+	j := f.track(OpChtimes)
+	defer func() { j.end(0, err) }()
+
+	return f.changeInode(j, OpChtimes, func(n *inode) { n.chtimes(atime, mtime) })
+}
+
+// Chown changes the numeric uid and gid of the file, mirroring [os.File.Chown].
+// An ID of -1 leaves the corresponding value unchanged.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (f *MockFile) Chown(uid, gid int) (err error) {
+	// Record the result of this operation on exit
+	defer func() { f.stats.RecordPath(OpChown, f.name, 0, err) }()
+	j := f.track(OpChown)
+	defer func() { j.end(0, err) }()
+
+	return f.changeInode(j, OpChown, func(n *inode) { n.chown(uid, gid) })
+}
+
+// changeInode runs the simulation layer for a metadata change through the
+// handle and then applies change to the file's inode. Metadata changes are
+// allowed on handles of any access mode, as with fchmod(2), but not on a
+// file opened from a read-only filesystem.
+func (f *MockFile) changeInode(j *journalOp, op Operation, change func(*inode)) error {
+	<-f.mu
+	defer func() { f.mu <- struct{}{} }()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+
+	// Simulate latency before checking for errors (models real I/O timing)
+	j.simulate(f.latency, op)
+
+	if err := f.injector.CheckAndApply(op, f.name); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	unlock := f.lockInode(true)
+	defer unlock()
+
+	if f.fsys != nil && f.fsys.writeMode == writeModeReadOnly {
+		return &fs.PathError{Op: op.String(), Path: f.name, Err: ErrPermission}
+	}
+	if f.fsys != nil && op != OpChown && !f.fsys.owns(f.node) {
+		return &fs.PathError{Op: op.String(), Path: f.name, Err: ErrPermission}
+	}
+
+	change(f.node)
+	f.notify(EventChmod)
+	return nil
+}
@@ -0,0 +1,227 @@
+package mockfs_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestMockFS_Chmod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []mockfs.FsOption
+		path     string
+		mode     mockfs.FileMode
+		wantMode mockfs.FileMode
+		wantErr  error
+	}{
+		{name: "tighten file", path: "secret.key", mode: 0o600, wantMode: 0o600},
+		{name: "keeps directory type", path: "dir", mode: 0o700, wantMode: mockfs.ModeDir | 0o700},
+		{name: "ignores type bits", path: "secret.key", mode: mockfs.ModeDir | 0o400, wantMode: 0o400},
+		{name: "sets sticky bit", path: "dir", mode: fs.ModeSticky | 0o777, wantMode: mockfs.ModeDir | fs.ModeSticky | 0o777},
+		{name: "follows symlink", path: "link", mode: 0o640, wantMode: 0o640},
+		{name: "missing file", path: "missing", mode: 0o600, wantErr: mockfs.ErrNotExist},
+		{name: "invalid path", path: "../secret.key", mode: 0o600, wantErr: mockfs.ErrInvalid},
+		{
+			name:    "read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			path:    "secret.key",
+			mode:    0o600,
+			wantErr: mockfs.ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]mockfs.FsOption{
+				mockfs.File("secret.key", "key", 0o644),
+				mockfs.Dir("dir"),
+				mockfs.Symlink("link", "secret.key"),
+			}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			err := mfs.Chmod(tt.path, tt.mode)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+				return
+			}
+			requireNoError(t, err)
+
+			info, err := mfs.Stat(tt.path)
+			requireNoError(t, err)
+			if info.Mode() != tt.wantMode {
+				t.Errorf("mode = %v, want %v", info.Mode(), tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestMockFS_Chtimes(t *testing.T) {
+	t.Parallel()
+
+	atime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mtime := time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC)
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "data"))
+	orig, err := mfs.Stat("file.txt")
+	requireNoError(t, err)
+
+	// Zero values leave both times unchanged
+	requireNoError(t, mfs.Chtimes("file.txt", time.Time{}, time.Time{}))
+	info, err := mfs.Stat("file.txt")
+	requireNoError(t, err)
+	if !info.ModTime().Equal(orig.ModTime()) {
+		t.Errorf("ModTime changed by zero Chtimes: %v, want %v", info.ModTime(), orig.ModTime())
+	}
+
+	requireNoError(t, mfs.Chtimes("file.txt", atime, mtime))
+	info, err = mfs.Stat("file.txt")
+	requireNoError(t, err)
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("ModTime = %v, want %v", info.ModTime(), mtime)
+	}
+	if got := info.Sys().(*mockfs.InodeInfo).Atime; !got.Equal(atime) {
+		t.Errorf("Atime = %v, want %v", got, atime)
+	}
+
+	// Only the access time
+	newAtime := atime.Add(time.Hour)
+	requireNoError(t, mfs.Chtimes("file.txt", newAtime, time.Time{}))
+	info, err = mfs.Stat("file.txt")
+	requireNoError(t, err)
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("ModTime = %v, want unchanged %v", info.ModTime(), mtime)
+	}
+	if got := info.Sys().(*mockfs.InodeInfo).Atime; !got.Equal(newAtime) {
+		t.Errorf("Atime = %v, want %v", got, newAtime)
+	}
+
+	assertError(t, mfs.Chtimes("missing", atime, mtime), mockfs.ErrNotExist)
+}
+
+func TestMockFS_Chown(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "data"))
+	owner := func() (int, int) {
+		t.Helper()
+		info, err := mfs.Stat("file.txt")
+		requireNoError(t, err)
+		ino := info.Sys().(*mockfs.InodeInfo)
+		return ino.Uid, ino.Gid
+	}
+
+	if uid, gid := owner(); uid != 0 || gid != 0 {
+		t.Errorf("default owner = %d:%d, want 0:0", uid, gid)
+	}
+
+	requireNoError(t, mfs.Chown("file.txt", 1000, 100))
+	if uid, gid := owner(); uid != 1000 || gid != 100 {
+		t.Errorf("owner = %d:%d, want 1000:100", uid, gid)
+	}
+
+	// -1 leaves the corresponding ID unchanged
+	requireNoError(t, mfs.Chown("file.txt", -1, 200))
+	if uid, gid := owner(); uid != 1000 || gid != 200 {
+		t.Errorf("owner = %d:%d, want 1000:200", uid, gid)
+	}
+
+	assertError(t, mfs.Chown("missing", 0, 0), mockfs.ErrNotExist)
+
+	ro := mockfs.MustNewMockFS(mockfs.File("file.txt", "data"), mockfs.WithReadOnly())
+	assertError(t, ro.Chown("file.txt", 1, 1), mockfs.ErrPermission)
+}
+
+func TestMockFS_MetadataStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "data"))
+
+	requireNoError(t, mfs.Chmod("file.txt", 0o600))
+	requireNoError(t, mfs.Chtimes("file.txt", time.Now(), time.Now()))
+	requireNoError(t, mfs.Chown("file.txt", 1, 1))
+	assertAnyError(t, mfs.Chown("missing", 1, 1))
+
+	mfs.Stats().Expect().
+		Count(mockfs.OpChmod, 1).
+		Count(mockfs.OpChtimes, 1).
+		Count(mockfs.OpChown, 2).
+		Failure(mockfs.OpChown, 1).
+		Assert(t)
+}
+
+func TestMockFile_Metadata(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("secret.key", "key", 0o644))
+	mtime := time.Date(2023, 6, 7, 8, 9, 10, 0, time.UTC)
+
+	f, err := mfs.OpenFile("secret.key", os.O_RDONLY, 0)
+	requireNoError(t, err)
+	mf := f.(*mockfs.MockFile)
+
+	// Metadata changes work on read-only handles and are visible through the filesystem
+	requireNoError(t, mf.Chmod(0o400))
+	requireNoError(t, mf.Chtimes(time.Time{}, mtime))
+	requireNoError(t, mf.Chown(1000, -1))
+
+	info, err := mfs.Stat("secret.key")
+	requireNoError(t, err)
+	if info.Mode() != 0o400 {
+		t.Errorf("mode = %v, want %v", info.Mode(), mockfs.FileMode(0o400))
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("ModTime = %v, want %v", info.ModTime(), mtime)
+	}
+	if uid := info.Sys().(*mockfs.InodeInfo).Uid; uid != 1000 {
+		t.Errorf("Uid = %d, want 1000", uid)
+	}
+
+	mf.Stats().Expect().
+		Count(mockfs.OpChmod, 1).
+		Count(mockfs.OpChtimes, 1).
+		Count(mockfs.OpChown, 1).
+		NoFailures().
+		Assert(t)
+
+	requireNoError(t, mf.Close())
+	assertError(t, mf.Chmod(0o600), fs.ErrClosed)
+}
+
+func TestMockFile_MetadataInjection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("injected error", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("file.txt", "data")
+		requireNoError(t, f.ErrorInjector().AddExact(mockfs.OpChmod, "file.txt", mockfs.ErrPermission, mockfs.ErrorModeOnce, 0))
+
+		assertError(t, f.Chmod(0o600), mockfs.ErrPermission)
+		requireNoError(t, f.Chmod(0o600))
+
+		info, err := f.Stat()
+		requireNoError(t, err)
+		if info.Mode() != 0o600 {
+			t.Errorf("mode = %v, want %v", info.Mode(), mockfs.FileMode(0o600))
+		}
+	})
+
+	t.Run("read-only filesystem", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "data"), mockfs.WithReadOnly())
+		f, err := mfs.OpenMockFile("file.txt")
+		requireNoError(t, err)
+		defer f.Close()
+
+		assertError(t, f.Chown(1, 1), mockfs.ErrPermission)
+	})
+}
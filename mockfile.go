@@ -14,9 +14,10 @@ import (
 type writeMode uint8
 
 const (
-	writeModeAppend    writeMode = iota // Append data to existing contents.
-	writeModeOverwrite                  // Overwrite existing contents.
-	writeModeReadOnly                   // Write is not allowed.
+	writeModeAppend     writeMode = iota // Append data to existing contents.
+	writeModeOverwrite                   // Overwrite existing contents.
+	writeModeReadOnly                    // Write is not allowed.
+	writeModePositional                  // Write at the current offset, like os.File.
 )
 
 // MockFile represents an open file. It implements the following interfaces:
@@ -28,16 +29,21 @@ const (
 //   - io.WriterAt
 //   - io.Closer
 type MockFile struct {
-	mapFile        *fstest.MapFile                  // The underlying file data.
+	node           *inode                           // The underlying file data and metadata.
 	name           string                           // Cleaned name used to open this file (relative to its MockFS).
 	position       int64                            // Current read position in the file.
 	mu             chan struct{}                    // 1-buffered ticket guarding all mutable state.
 	closed         bool                             // Tracks if the file has been closed.
 	writeMode      writeMode                        // How writes modify the file data.
+	writeOnly      bool                             // Whether reads are rejected (handle opened with O_WRONLY).
 	readDirHandler func(int) ([]fs.DirEntry, error) // Handler for ReadDir operations (directories only).
 	latency        LatencySimulator                 // Latency simulator for this file.
 	stats          StatsRecorder                    // Operation statistics.
 	injector       ErrorInjector                    // Error injector for operations on this file.
+	fsys           *MockFS                          // Filesystem the handle was opened from (nil for standalone files).
+	path           string                           // Path the handle was opened at, with symlinks resolved; names its change events.
+	id             uint64                           // Handle ID in the filesystem's journal (0 for standalone files).
+	versioned      bool                             // Whether the handle has saved the file's previous version.
 }
 
 // Ensure interface implementations.
@@ -50,11 +56,13 @@ var (
 	_ io.WriterAt    = (*MockFile)(nil)
 	_ io.Closer      = (*MockFile)(nil)
 	_ io.Seeker      = (*MockFile)(nil)
+	_ WritableFile   = (*MockFile)(nil)
 )
 
 // fileOptions holds the configurable state for a new MockFile.
 type fileOptions struct {
 	writeMode      writeMode
+	writeOnly      bool
 	injector       ErrorInjector
 	latency        LatencySimulator
 	readDirHandler func(int) ([]fs.DirEntry, error)
@@ -80,6 +88,16 @@ func WithFileOverwrite() FileOption {
 	}
 }
 
+// WithFilePositional sets the file to write at the current offset on write,
+// like os.File: Write overwrites or extends the data from the offset and
+// advances it, so consecutive writes and Seek followed by Write compose.
+func WithFilePositional() FileOption {
+	return func(o *fileOptions) error {
+		o.writeMode = writeModePositional
+		return nil
+	}
+}
+
 // WithFileReadOnly sets the file to reject all writes.
 func WithFileReadOnly() FileOption {
 	return func(o *fileOptions) error {
@@ -88,6 +106,15 @@ func WithFileReadOnly() FileOption {
 	}
 }
 
+// WithFileWriteOnly sets the file to reject all reads.
+// Writes still follow the configured write mode.
+func WithFileWriteOnly() FileOption {
+	return func(o *fileOptions) error {
+		o.writeOnly = true
+		return nil
+	}
+}
+
 // WithFileErrorInjector sets the error injector for the file.
 func WithFileErrorInjector(injector ErrorInjector) FileOption {
 	return func(o *fileOptions) error {
@@ -157,17 +184,17 @@ func WithFileStats(stats StatsRecorder) FileOption {
 // It is called by MockFS.Open() and the public constructors.
 //
 // Parameters:
-//   - mapFile: the fstest.MapFile containing file data and metadata.
+//   - node: the inode containing file data and metadata.
 //   - name: cleaned path used when checking injection rules.
-//   - writeMode: how Write operations modify the file (append/overwrite/readonly).
+//   - writeMode: how Write operations modify the file (append/overwrite/positional/readonly).
 //   - injector: the error injector to use (may be nil to use a new, empty injector).
 //   - latencySimulator: simulator for operation latency (may be nil for no latency).
 //   - readDirHandler: handler for ReadDir operations on directories (may be nil).
 //   - stats: operation stats recorder. If nil, a new one is created for this file handle.
 //
-// Panics if mapFile is nil: this is a programmer error, not a runtime condition.
+// Panics if node is nil: this is a programmer error, not a runtime condition.
 func newMockFile(
-	mapFile *fstest.MapFile,
+	node *inode,
 	name string,
 	writeMode writeMode,
 	injector ErrorInjector,
@@ -175,9 +202,9 @@ func newMockFile(
 	readDirHandler func(int) ([]fs.DirEntry, error),
 	stats StatsRecorder,
 ) *MockFile {
-	if mapFile == nil {
+	if node == nil {
 		//nolint:forbidigo // Panic is intentional here to mark incorrect use
-		panic("mockfs: mapFile cannot be nil")
+		panic("mockfs: node cannot be nil")
 	}
 
 	// Default no-op callbacks
@@ -192,7 +219,7 @@ func newMockFile(
 	}
 
 	return &MockFile{
-		mapFile:        mapFile,
+		node:           node,
 		name:           name,
 		mu:             newFileLock(),
 		writeMode:      writeMode,
@@ -241,15 +268,18 @@ func NewMockFile(mapFile *fstest.MapFile, name string, opts ...FileOption) (*Moc
 		}
 	}
 
-	return newMockFile(
-		mapFile,
+	f := newMockFile(
+		newStandaloneInode(mapFile),
 		name,
 		options.writeMode,
 		options.injector,
 		options.latency,
 		options.readDirHandler,
 		options.stats,
-	), nil
+	)
+	f.writeOnly = options.writeOnly
+
+	return f, nil
 }
 
 // MustNewMockFile is like NewMockFile but panics if construction fails.
@@ -319,28 +349,51 @@ func (f *MockFile) Read(b []byte) (n int, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpRead, n, err) }()
+	defer func() { f.stats.RecordPath(OpRead, f.name, n, err) }()
+	j := f.track(OpRead)
+	defer func() { j.end(n, err) }()
 
 	if f.closed {
 		return 0, fs.ErrClosed
 	}
 
 	// Simulate latency before checking for errors (models real I/O timing)
-	f.latency.Simulate(OpRead)
+	j.simulate(f.latency, OpRead)
 
-	if err := f.injector.CheckAndApply(OpRead, f.name); err != nil {
+	// A write-only handle fails every read, so no rule is consumed by it
+	if f.writeOnly {
+		return 0, &fs.PathError{Op: OpRead.String(), Path: f.name, Err: ErrPermission}
+	}
+
+	unlock := f.lockInode(false)
+	defer unlock()
+
+	src, err := f.readSource(f.position, len(b))
+	if err != nil {
+		return 0, f.readError(err)
+	}
+	requested := readable(src.size(), f.position, len(b))
+	limit, injErr := checkTransfer(f.injector, OpRead, f.name, f.position, requested)
+	j.transfer(f.position, requested, limit, injErr)
+	if injErr != nil && limit == 0 {
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests.
-		return 0, err
+		return 0, injErr
 	}
 
 	// Read from current position
-	if f.position >= int64(len(f.mapFile.Data)) {
+	if f.position >= src.size() {
 		return 0, io.EOF
 	}
 
-	n = copy(b, f.mapFile.Data[f.position:])
+	if n, err = src.readAt(b[:limit], f.position); err != nil {
+		return 0, f.readError(err)
+	}
 	f.position += int64(n)
 
+	if injErr != nil {
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests.
+		return n, injErr
+	}
 	return n, nil
 }
 
@@ -353,7 +406,9 @@ func (f *MockFile) ReadAt(b []byte, off int64) (n int, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpRead, n, err) }()
+	defer func() { f.stats.RecordPath(OpRead, f.name, n, err) }()
+	j := f.track(OpRead)
+	defer func() { j.end(n, err) }()
 
 	if f.closed {
 		err = fs.ErrClosed
@@ -361,11 +416,26 @@ func (f *MockFile) ReadAt(b []byte, off int64) (n int, err error) {
 	}
 
 	// Simulate latency before checking for errors (models real I/O timing)
-	f.latency.Simulate(OpRead)
+	j.simulate(f.latency, OpRead)
 
-	if err := f.injector.CheckAndApply(OpRead, f.name); err != nil {
+	// A write-only handle fails every read, so no rule is consumed by it
+	if f.writeOnly {
+		return 0, &fs.PathError{Op: OpRead.String(), Path: f.name, Err: ErrPermission}
+	}
+
+	unlock := f.lockInode(false)
+	defer unlock()
+
+	src, err := f.readSource(off, len(b))
+	if err != nil {
+		return 0, f.readError(err)
+	}
+	requested := readable(src.size(), off, len(b))
+	limit, injErr := checkTransfer(f.injector, OpRead, f.name, off, requested)
+	j.transfer(off, requested, limit, injErr)
+	if injErr != nil && limit == 0 {
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests.
-		return 0, err
+		return 0, injErr
 	}
 
 	if off < 0 {
@@ -373,19 +443,46 @@ func (f *MockFile) ReadAt(b []byte, off int64) (n int, err error) {
 	}
 
 	// Read from current position
-	if off >= int64(len(f.mapFile.Data)) {
+	if off >= src.size() {
 		return 0, io.EOF
 	}
 
-	n = copy(b, f.mapFile.Data[off:])
-	if n < len(b) {
+	if n, err = src.readAt(b[:limit], off); err != nil {
+		return 0, f.readError(err)
+	}
+	switch {
+	case injErr != nil:
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests.
+		return n, injErr
+	case limit < requested:
+		// io.ReaderAt requires an error with a short count
+		return n, io.ErrUnexpectedEOF
+	case n < len(b):
 		return n, io.EOF
 	}
 
 	return n, nil
 }
 
+// readable returns how many of n bytes a read at off finds before the end of
+// a file of size bytes. A negative off is rejected by the caller and yields n.
+func readable(size, off int64, n int) int {
+	if off < 0 {
+		return n
+	}
+	return int(min(int64(n), max(size-off, 0)))
+}
+
+// readError returns the error for a read on the handle whose content
+// generator failed.
+func (f *MockFile) readError(err error) error {
+	return &fs.PathError{Op: OpRead.String(), Path: f.name, Err: err}
+}
+
 // Write implements io.Writer for MockFile.
+// Where the data goes depends on the file's write mode: the overwrite mode
+// replaces the whole content, the append mode adds to the end, and the
+// positional mode writes at the current offset and advances it, as os.File does.
 //
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (f *MockFile) Write(b []byte) (n int, err error) {
@@ -393,7 +490,9 @@ func (f *MockFile) Write(b []byte) (n int, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpWrite, n, err) }()
+	defer func() { f.stats.RecordPath(OpWrite, f.name, n, err) }()
+	j := f.track(OpWrite)
+	defer func() { j.end(n, err) }()
 
 	if f.closed {
 		err = fs.ErrClosed
@@ -401,11 +500,32 @@ func (f *MockFile) Write(b []byte) (n int, err error) {
 	}
 
 	// Simulate latency before checking for errors (models real I/O timing)
-	f.latency.Simulate(OpWrite)
+	j.simulate(f.latency, OpWrite)
 
-	if err := f.injector.CheckAndApply(OpWrite, f.name); err != nil {
+	off := f.writeOffset()
+	limit, injErr := checkTransfer(f.injector, OpWrite, f.name, off, len(b))
+	j.transfer(off, len(b), limit, injErr)
+	if injErr != nil && limit == 0 {
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests.
-		return 0, err
+		return 0, injErr
+	}
+
+	// A partial-transfer rule lets only a prefix through, or fails the write
+	// after all of it, and a full disk lets a prefix through
+	short := limit < len(b) || injErr != nil
+	b = b[:limit]
+
+	unlock := f.lockInode(true)
+	defer unlock()
+
+	full := false
+	if f.writeMode != writeModeReadOnly {
+		if fit := f.fitWrite(f.writeOffset(), len(b), f.writeMode == writeModeOverwrite); fit < len(b) {
+			full, b = true, b[:fit]
+		}
+	}
+	if full && len(b) == 0 {
+		return 0, f.diskFull()
 	}
 
 	// Check write mode
@@ -414,23 +534,40 @@ func (f *MockFile) Write(b []byte) (n int, err error) {
 		return 0, &fs.PathError{Op: OpWrite.String(), Path: f.name, Err: fs.ErrPermission}
 
 	case writeModeAppend:
-		f.mapFile.Data = append(f.mapFile.Data, b...)
-		f.mapFile.ModTime = time.Now()
+		f.beginVersion()
+		f.node.recordWrite(f.node.size(), b)
+		f.node.writeAt(b, f.node.size())
+		f.node.ModTime = time.Now()
 		n = len(b)
-		return n, nil
 
 	case writeModeOverwrite:
 		// Replace entire content
-		f.mapFile.Data = bytes.Clone(b)
-		f.mapFile.ModTime = time.Now()
+		f.beginVersion()
+		f.node.recordWrite(0, b)
+		f.node.replace(b)
+		f.node.ModTime = time.Now()
 		n = len(b)
 		f.position = int64(n)
-		return n, nil
+
+	case writeModePositional:
+		n = f.writeAt(b, f.position)
+		f.position += int64(n)
 
 	default:
 		//nolint:forbidigo // Panic is intentional here to mark incorrect use
 		panic("mockfs: invalid writeMode")
 	}
+	if n > 0 {
+		f.notify(EventWrite)
+	}
+
+	switch {
+	case full:
+		return n, f.diskFull()
+	case short:
+		return n, shortWriteError(injErr)
+	}
+	return n, nil
 }
 
 // WriteAt implements io.WriterAt for MockFile.
@@ -442,7 +579,9 @@ func (f *MockFile) WriteAt(b []byte, off int64) (n int, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpWrite, n, err) }()
+	defer func() { f.stats.RecordPath(OpWrite, f.name, n, err) }()
+	j := f.track(OpWrite)
+	defer func() { j.end(n, err) }()
 
 	if f.closed {
 		return 0, fs.ErrClosed
@@ -451,36 +590,69 @@ func (f *MockFile) WriteAt(b []byte, off int64) (n int, err error) {
 	// Simulate latency before checking for errors (models real I/O timing).
 	// This must run before the write-mode check so that read-only files
 	// experience the same I/O timing as writable ones, matching Write behaviour.
-	f.latency.Simulate(OpWrite)
+	j.simulate(f.latency, OpWrite)
 
 	// Check write mode
 	if f.writeMode == writeModeReadOnly {
 		return 0, &fs.PathError{Op: OpWrite.String(), Path: f.name, Err: ErrPermission}
 	}
 
-	if err := f.injector.CheckAndApply(OpWrite, f.name); err != nil {
+	limit, injErr := checkTransfer(f.injector, OpWrite, f.name, off, len(b))
+	j.transfer(off, len(b), limit, injErr)
+	if injErr != nil && limit == 0 {
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
-		return 0, err
+		return 0, injErr
 	}
 
 	if off < 0 {
 		return 0, &fs.PathError{Op: OpWrite.String(), Path: f.name, Err: ErrNegativeOffset}
 	}
 
-	// Extend file if necessary
-	needed := int(off) + len(b)
-	if needed > len(f.mapFile.Data) {
-		newData := make([]byte, needed)
-		copy(newData, f.mapFile.Data)
-		f.mapFile.Data = newData
+	unlock := f.lockInode(true)
+	defer unlock()
+	fit := f.fitWrite(off, limit, false)
+	if fit == 0 && limit > 0 {
+		return 0, f.diskFull()
 	}
 
-	n = copy(f.mapFile.Data[off:], b)
-	f.mapFile.ModTime = time.Now()
+	n = f.writeAt(b[:fit], off)
+	if n > 0 {
+		f.notify(EventWrite)
+	}
 
+	switch {
+	case fit < limit:
+		return n, f.diskFull()
+	case limit < len(b) || injErr != nil:
+		return n, shortWriteError(injErr)
+	}
 	return n, nil
 }
 
+// writeOffset returns the offset the next Write lands at in the file's write mode.
+func (f *MockFile) writeOffset() int64 {
+	switch f.writeMode {
+	case writeModeAppend:
+		return f.node.size()
+	case writeModePositional:
+		return f.position
+	default:
+		return 0
+	}
+}
+
+// writeAt copies b into the file content at off, zero-filling any gap and
+// extending the content as needed, and updates the modification time.
+// Caller must hold f.mu.
+func (f *MockFile) writeAt(b []byte, off int64) int {
+	f.beginVersion()
+	f.node.recordWrite(off, b)
+	f.node.writeAt(b, off)
+	f.node.ModTime = time.Now()
+
+	return len(b)
+}
+
 // Seek implements io.Seeker for MockFile.
 // It sets the offset for the next Read or Write operation.
 //
@@ -490,16 +662,19 @@ func (f *MockFile) Seek(offset int64, whence int) (n int64, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpSeek, 0, err) }()
+	defer func() { f.stats.RecordPath(OpSeek, f.name, 0, err) }()
+	j := f.track(OpSeek)
+	defer func() { j.end(0, err) }()
 
 	if f.closed {
 		return 0, fs.ErrClosed
 	}
 
 	// Simulate latency before checking for errors (models real I/O timing)
-	f.latency.Simulate(OpSeek)
+	j.simulate(f.latency, OpSeek)
 
 	if err := f.injector.CheckAndApply(OpSeek, f.name); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return 0, err
 	}
@@ -510,7 +685,7 @@ func (f *MockFile) Seek(offset int64, whence int) (n int64, err error) {
 	case io.SeekCurrent:
 		n = f.position + offset
 	case io.SeekEnd:
-		n = int64(len(f.mapFile.Data)) + offset
+		n = f.node.size() + offset
 	default:
 		return 0, &fs.PathError{Op: OpSeek.String(), Path: f.name, Err: fs.ErrInvalid}
 	}
@@ -520,6 +695,7 @@ func (f *MockFile) Seek(offset int64, whence int) (n int64, err error) {
 	}
 
 	f.position = n
+	j.at(n)
 
 	return n, nil
 }
@@ -535,22 +711,25 @@ func (f *MockFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpReadDir, 0, err) }()
+	defer func() { f.stats.RecordPath(OpReadDir, f.name, 0, err) }()
+	j := f.track(OpReadDir)
+	defer func() { j.end(0, err) }()
 
 	if f.closed {
 		return nil, fs.ErrClosed
 	}
 
-	if !f.mapFile.Mode.IsDir() {
+	if !f.node.Mode.IsDir() {
 		return nil, &fs.PathError{Op: OpReadDir.String(), Path: f.name, Err: fs.ErrInvalid}
 	}
 
 	// Simulate latency and check for injected errors before consulting the
 	// handler. This ensures nil-handler directories (standalone empty dirs)
 	// respect the same latency and error-injection rules as handler-backed ones.
-	f.latency.Simulate(OpReadDir)
+	j.simulate(f.latency, OpReadDir)
 
 	if err := f.injector.CheckAndApply(OpReadDir, f.name); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return nil, err
 	}
@@ -574,36 +753,28 @@ func (f *MockFile) Stat() (fi fs.FileInfo, err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpStat, 0, err) }()
+	defer func() { f.stats.RecordPath(OpStat, f.name, 0, err) }()
+	j := f.track(OpStat)
+	defer func() { j.end(0, err) }()
 
 	if f.closed {
 		return nil, fs.ErrClosed
 	}
 
 	// Simulate latency before checking for errors
-	f.latency.Simulate(OpStat)
+	j.simulate(f.latency, OpStat)
 
 	if err := f.injector.CheckAndApply(OpStat, f.name); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return nil, err
 	}
 
-	// Build FileInfo from MapFile content
-	name := path.Base(f.name)
-	mode := f.mapFile.Mode
-	modTime := f.mapFile.ModTime
-	size := int64(len(f.mapFile.Data))
-	if f.mapFile.Mode.IsDir() {
-		// Directories always report size 0
-		size = 0
-	}
+	// Build FileInfo from the inode; directories always report size 0
+	unlock := f.lockInode(false)
+	defer unlock()
 
-	return &FileInfo{
-		name:    name,
-		size:    size,
-		mode:    mode,
-		modTime: modTime,
-	}, nil
+	return f.node.fileInfo(path.Base(f.name)), nil
 }
 
 // Close implements io.Closer for MockFile.
@@ -617,31 +788,60 @@ func (f *MockFile) Close() (err error) {
 	defer func() { f.mu <- struct{}{} }()
 
 	// Record the result of this operation on exit
-	defer func() { f.stats.Record(OpClose, 0, err) }()
+	defer func() { f.stats.RecordPath(OpClose, f.name, 0, err) }()
+	j := f.track(OpClose)
+	defer func() { j.end(0, err) }()
 
 	if f.closed {
 		return fs.ErrClosed
 	}
 
 	// Simulate latency before checking for errors (models real I/O timing)
-	f.latency.Simulate(OpClose)
+	j.simulate(f.latency, OpClose)
 
 	// Check for injected error
 	if err := f.injector.CheckAndApply(OpClose, f.name); err != nil {
+		j.fault()
 		// Still mark as closed to prevent resource leaks
-		f.closed = true
-		f.latency.Reset()
+		f.markClosed()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
 
-	// Mark as closed
+	f.markClosed()
+
+	return nil
+}
+
+// markClosed marks the handle closed, resets its latency state, and releases
+// the handle's hold on its inode and its place in OpenHandles.
+// Caller must hold f.mu.
+func (f *MockFile) markClosed() {
 	f.closed = true
 
 	// Reset latency state after closing
 	f.latency.Reset()
 
-	return nil
+	if f.fsys != nil {
+		f.fsys.closeInode(f.node)
+		f.fsys.live.remove(f.id)
+	}
+}
+
+// lockInode acquires the owning filesystem's lock for access to inode
+// metadata shared with the filesystem, returning the matching unlock.
+// Standalone files have no filesystem and need no lock.
+func (f *MockFile) lockInode(write bool) func() {
+	switch {
+	case f.fsys == nil:
+		return func() {}
+	case write:
+		f.fsys.mu.Lock()
+		return f.fsys.mu.Unlock
+	default:
+		f.fsys.mu.RLock()
+		return f.fsys.mu.RUnlock
+	}
 }
 
 // ErrorInjector returns the error injector for advanced configuration.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -673,6 +674,45 @@ func TestMockFile_Write(t *testing.T) {
 		}
 	})
 
+	t.Run("positional mode", func(t *testing.T) {
+		t.Parallel()
+		mapFile := &fstest.MapFile{Data: []byte("0123456789"), Mode: 0o644, ModTime: time.Now()}
+		file := mockfs.MustNewMockFile(mapFile, "test.txt", mockfs.WithFilePositional())
+
+		// Consecutive writes land one after the other
+		for _, chunk := range []string{"ab", "cd"} {
+			n, err := file.Write([]byte(chunk))
+			requireNoError(t, err)
+			if n != len(chunk) {
+				t.Errorf("n = %d, want %d", n, len(chunk))
+			}
+		}
+		if string(mapFile.Data) != "abcd456789" {
+			t.Errorf("data = %q, want %q", mapFile.Data, "abcd456789")
+		}
+
+		// Seek then Write overwrites in place and extends past the end
+		_, err := file.Seek(8, io.SeekStart)
+		requireNoError(t, err)
+		_, err = file.Write([]byte("XYZ"))
+		requireNoError(t, err)
+		if string(mapFile.Data) != "abcd4567XYZ" {
+			t.Errorf("data = %q, want %q", mapFile.Data, "abcd4567XYZ")
+		}
+
+		// A gap left by seeking past the end is zero-filled
+		pos, err := file.Seek(2, io.SeekCurrent)
+		requireNoError(t, err)
+		if pos != 13 {
+			t.Errorf("position = %d, want 13", pos)
+		}
+		_, err = file.Write([]byte("!"))
+		requireNoError(t, err)
+		if string(mapFile.Data) != "abcd4567XYZ\x00\x00!" {
+			t.Errorf("data = %q, want %q", mapFile.Data, "abcd4567XYZ\x00\x00!")
+		}
+	})
+
 	t.Run("readonly mode", func(t *testing.T) {
 		t.Parallel()
 		initialData := []byte("initial")
@@ -690,6 +730,19 @@ func TestMockFile_Write(t *testing.T) {
 		}
 	})
 
+	t.Run("write-only mode", func(t *testing.T) {
+		t.Parallel()
+		file := mockfs.NewMockFileFromString("test.txt", "initial", mockfs.WithFileWriteOnly())
+
+		_, err := file.Read(make([]byte, 4))
+		assertError(t, err, mockfs.ErrPermission, "read")
+		n, err := file.Write([]byte("new"))
+		requireNoError(t, err, "write")
+		if n != 3 {
+			t.Errorf("n = %d, want 3", n)
+		}
+	})
+
 	t.Run("closed file", func(t *testing.T) {
 		t.Parallel()
 		file := mockfs.NewMockFileFromBytes("test.txt", []byte("data"))
@@ -844,6 +897,33 @@ func TestMockFile_WriteAt_Closed(t *testing.T) {
 	assertError(t, err, fs.ErrClosed)
 }
 
+func TestMockFile_ConcurrentHandles(t *testing.T) {
+	t.Parallel()
+
+	// Handles on one file share its inode with the filesystem; run with -race
+	mfs := mockfs.MustNewMockFS(mockfs.File("f", "data"))
+	var wg sync.WaitGroup
+	for range 2 {
+		f, err := mfs.OpenFile("f", os.O_RDWR, 0)
+		requireNoError(t, err)
+		wg.Go(func() {
+			defer f.Close()
+			for range 100 {
+				_, _ = f.Write([]byte("xy"))
+				_ = f.(*mockfs.MockFile).Truncate(1)
+				_, _ = f.ReadAt(make([]byte, 2), 0)
+			}
+		})
+	}
+	wg.Go(func() {
+		for range 100 {
+			_, _ = mfs.Stat("f")
+			_, _ = mfs.ReadFile("f")
+		}
+	})
+	wg.Wait()
+}
+
 func TestMockFile_WriteAt_ReadOnly(t *testing.T) {
 	t.Parallel()
 
@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"path"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing/fstest"
 	"time"
 )
@@ -32,11 +34,31 @@ const (
 // See [testing/fstest.MapFile].
 type MapFile = fstest.MapFile
 
+// WritableFile is an open file that supports both reading and writing.
+// It mirrors the I/O methods of [*os.File].
+type WritableFile interface {
+	fs.File
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+
+	// Sync commits the file's contents, or a directory's entries, to stable storage.
+	Sync() error
+}
+
 // WritableFS is an extension of fs.FS that supports write operations.
 // It mirrors the mutating side of the [os] standard package.
 type WritableFS interface {
 	fs.FS
 
+	// OpenFile opens a file with the given [os] flags (O_RDONLY, O_WRONLY,
+	// O_RDWR, O_APPEND, O_CREATE, O_EXCL, O_TRUNC) and permission bits.
+	OpenFile(name string, flag int, perm FileMode) (WritableFile, error)
+
+	// Create creates or truncates the named file for reading and writing.
+	Create(name string) (WritableFile, error)
+
 	// Mkdir creates a directory in the filesystem.
 	Mkdir(path string, perm FileMode) error
 
@@ -50,7 +72,7 @@ type WritableFS interface {
 	RemoveAll(path string) error
 
 	// Rename renames a file or directory in the filesystem.
-	// If the destination already exists, it will be overwritten.
+	// If the destination already exists, it is atomically replaced.
 	Rename(oldpath, newpath string) error
 
 	// WriteFile writes data to a file in the filesystem.
@@ -63,7 +85,9 @@ type WritableFS interface {
 type FsOption func(fs *MockFS) error
 
 // File adds a file at the current context path.
-// The content will be converted to a byte slice.
+// The content will be converted to a byte slice, unless it is a
+// GeneratedContent, which makes a generated file (see Generated), or a
+// DynamicContent, which makes a dynamic file (see Dynamic).
 // The mode is optional, defaulting to 0644.
 //
 // Note: File does not create parent directories.
@@ -86,7 +110,7 @@ func File(name string, content any, mode ...FileMode) FsOption {
 		}
 		cleanPath := path.Clean(fullPath)
 
-		data, err := toBytes(content)
+		c, err := fileContent(content)
 		if err != nil {
 			return fmt.Errorf("%s: invalid content in %s (%T): %w", opName, cleanPath, content, err)
 		}
@@ -96,11 +120,7 @@ func File(name string, content any, mode ...FileMode) FsOption {
 			perm = mode[0]
 		}
 
-		m.files[cleanPath] = &fstest.MapFile{
-			Data:    data,
-			Mode:    (perm & ModePerm) &^ ModeDir,
-			ModTime: time.Now(),
-		}
+		m.link(cleanPath, m.newFile(c, perm))
 		return nil
 	}
 }
@@ -145,10 +165,10 @@ func Dir(name string, args ...any) FsOption {
 		}
 
 		// Create the directory entry itself
-		m.files[cleanPath] = &fstest.MapFile{
+		m.link(cleanPath, m.newInode(&fstest.MapFile{
 			Mode:    (perm & ModePerm) | ModeDir,
 			ModTime: time.Now(),
-		}
+		}))
 
 		// Apply children with cleanPath as their context; restore the
 		// caller's context on return so sibling options in the parent
@@ -210,6 +230,33 @@ func WithAppend() FsOption {
 	}
 }
 
+// WithPositional sets the write policy to write at the current offset, as
+// os.File does. Handles returned by Open write from their offset, overwriting
+// or extending the data and advancing the offset; WriteFile replaces the
+// contents, like os.WriteFile.
+func WithPositional() FsOption {
+	return func(m *MockFS) error {
+		m.writeMode = writeModePositional
+		return nil
+	}
+}
+
+// WithMaxOpenFiles limits the number of handles open at once to n, like the
+// per-process file descriptor limit behind EMFILE. Once n handles are open,
+// Open, OpenFile, Create, and ReadFile fail with a *PathError wrapping
+// ErrTooManyHandles until a handle is closed. The failure is recorded as a
+// failed OpOpen. Handles detached by Crash count until they are closed.
+// Returns an error if n is not positive.
+func WithMaxOpenFiles(n int) FsOption {
+	return func(m *MockFS) error {
+		if n <= 0 {
+			return fmt.Errorf("WithMaxOpenFiles: non-positive limit %d", n)
+		}
+		m.maxOpen = n
+		return nil
+	}
+}
+
 // WithLatency sets the simulated latency for all operations.
 func WithLatency(duration time.Duration) FsOption {
 	return func(m *MockFS) error {
@@ -248,14 +295,27 @@ func WithPerOperationLatency(durations map[Operation]time.Duration) FsOption {
 
 // MockFS wraps a file map to inject errors for specific paths and operations.
 type MockFS struct {
-	files           map[string]*fstest.MapFile // Internal file storage.
-	mu              sync.RWMutex               // Mutex for concurrent file structure operations.
-	injector        ErrorInjector              // Shared error injector.
-	stats           StatsRecorder              // Filesystem-level operation statistics.
-	latency         LatencySimulator           // Shared latency simulator.
-	createIfMissing bool                       // Whether to create files on write if missing.
-	writeMode       writeMode                  // How to apply data to files.
-	buildCtx        string                     // Current path context for File()/Dir() during NewMockFS; the value held after NewMockFS returns has no further meaning.
+	files           map[string]*inode // Directory entries: cleaned path to inode.
+	inodes          map[uint64]*inode // Inode table: every linked or open inode by number.
+	nextIno         uint64            // Last allocated inode number.
+	mu              sync.RWMutex      // Mutex for concurrent file structure operations.
+	injector        ErrorInjector     // Shared error injector.
+	stats           StatsRecorder     // Filesystem-level operation statistics.
+	handleStats     StatsRecorder     // Combined statistics of all handles.
+	latency         LatencySimulator  // Shared latency simulator.
+	journal         *journal          // Operation journal (nil unless WithJournal).
+	handles         atomic.Uint64     // Last assigned handle ID.
+	live            handleRegistry    // Handles not closed yet.
+	watchers        watchRegistry     // Watchers started by Watch.
+	nopen           int               // Number of open handles, including those detached by Crash.
+	maxOpen         int               // Limit on open handles (WithMaxOpenFiles); 0 for none.
+	limits          spaceLimits       // Space limits (WithCapacity, WithInodeLimit, WithQuota).
+	createIfMissing bool              // Whether to create files on write if missing.
+	writeMode       writeMode         // How to apply data to files.
+	permChecks      bool              // Whether permission bits are enforced (WithPermissionChecks).
+	uid             int               // Simulated user ID for permission checks and new entries.
+	gids            []int             // Simulated group IDs; the first one owns new entries.
+	buildCtx        string            // Current path context for File()/Dir() during NewMockFS; the value held after NewMockFS returns has no further meaning.
 }
 
 // Ensure interface implementations.
@@ -263,6 +323,7 @@ var (
 	_ fs.FS         = (*MockFS)(nil)
 	_ fs.ReadDirFS  = (*MockFS)(nil)
 	_ fs.ReadFileFS = (*MockFS)(nil)
+	_ fs.ReadLinkFS = (*MockFS)(nil)
 	_ fs.StatFS     = (*MockFS)(nil)
 	_ fs.SubFS      = (*MockFS)(nil)
 	_ WritableFS    = (*MockFS)(nil)
@@ -275,23 +336,23 @@ var (
 // Returns an error wrapping ErrUsage if any option fails (e.g. an invalid
 // path passed to File or Dir). Use MustNewMockFS to panic instead.
 func NewMockFS(opts ...FsOption) (*MockFS, error) {
-	files := map[string]*fstest.MapFile{
-		".": {
-			Mode:    ModeDir | defaultDirPerm,
-			ModTime: time.Now(),
-		},
-	}
-
 	m := &MockFS{
-		files:           files,
+		files:           make(map[string]*inode),
+		inodes:          make(map[uint64]*inode),
 		injector:        NewErrorInjector(),
 		stats:           NewStatsRecorder(nil),
+		handleStats:     NewStatsRecorder(nil),
 		latency:         NewNoopLatencySimulator(),
 		createIfMissing: false,
 		writeMode:       writeModeOverwrite,
 		buildCtx:        ".",
 	}
 
+	m.link(".", m.newInode(&fstest.MapFile{
+		Mode:    ModeDir | defaultDirPerm,
+		ModTime: time.Now(),
+	}))
+
 	// Apply options
 	for _, opt := range opts {
 		if opt == nil {
@@ -302,6 +363,21 @@ func NewMockFS(opts ...FsOption) (*MockFS, error) {
 		}
 	}
 
+	// The fixture belongs to the simulated user, whatever the option order
+	if m.permChecks {
+		for _, n := range m.inodes {
+			n.uid, n.gid = m.uid, m.primaryGid()
+		}
+	}
+
+	// The fixture is the synced state a Crash rolls back to
+	m.markDurable()
+
+	// Schedule delays are latency of the filesystem, whatever the option order
+	if w, ok := m.injector.(latencyWrapper); ok {
+		m.latency = w.wrapLatency(m.latency)
+	}
+
 	return m, nil
 }
 
@@ -322,7 +398,9 @@ func MustNewMockFS(opts ...FsOption) *MockFS {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) Stat(name string) (fi fs.FileInfo, err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpStat, 0, err) }()
+	defer func() { m.stats.RecordPath(OpStat, name, 0, err) }()
+	j := m.journal.begin(OpStat, name)
+	defer func() { j.end(0, err) }()
 
 	cleanName, err := m.validateAndCleanPath(name, OpStat)
 	if err != nil {
@@ -335,27 +413,22 @@ func (m *MockFS) Stat(name string) (fi fs.FileInfo, err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpStat, cleanName); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return nil, err
 	}
 
-	m.latency.Simulate(OpStat)
+	j.simulate(m.latency, OpStat)
 
 	m.mu.RLock()
-	mapFile, exists := m.files[cleanName]
-	m.mu.RUnlock()
+	defer m.mu.RUnlock()
 
-	if !exists {
-		return nil, &fs.PathError{Op: OpStat.String(), Path: name, Err: fs.ErrNotExist}
+	_, node, err := m.lookup(OpStat.String(), name, cleanName, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build FileInfo from MapFile
-	return &FileInfo{
-		name:    basename,
-		size:    int64(len(mapFile.Data)),
-		mode:    mapFile.Mode,
-		modTime: mapFile.ModTime,
-	}, nil
+	return node.fileInfo(basename), nil
 }
 
 // Open opens the named file and returns a MockFile.
@@ -366,7 +439,9 @@ func (m *MockFS) Stat(name string) (fi fs.FileInfo, err error) {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) Open(name string) (f fs.File, err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpOpen, 0, err) }()
+	defer func() { m.stats.RecordPath(OpOpen, name, 0, err) }()
+	j := m.journal.begin(OpOpen, name)
+	defer func() { j.end(0, err) }()
 
 	cleanName, err := m.validateAndCleanPath(name, OpOpen)
 	if err != nil {
@@ -374,40 +449,139 @@ func (m *MockFS) Open(name string) (f fs.File, err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpOpen, cleanName); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return nil, err
 	}
 
-	m.latency.Simulate(OpOpen)
+	j.simulate(m.latency, OpOpen)
 
-	m.mu.RLock()
-	mapFile, exists := m.files[cleanName]
-	m.mu.RUnlock()
+	// Lookup and handle registration are atomic so that a concurrent
+	// Remove cannot free the inode in between
+	m.mu.Lock()
+	var (
+		resolved string
+		node     *inode
+	)
+	err = m.checkOpenLimit(name)
+	if err == nil {
+		resolved, node, err = m.lookup(OpOpen.String(), name, cleanName, true)
+	}
+	if err == nil {
+		err = m.checkAccess(OpOpen.String(), name, node, permRead)
+	}
+	if err == nil {
+		err = node.refresh(name)
+	}
+	if err == nil {
+		m.acquireInode(node)
+	}
+	mode := m.writeMode
+	if err == nil && !m.canAccess(node, permWrite) {
+		mode = writeModeReadOnly
+	}
+	m.mu.Unlock()
 
-	if !exists {
-		return nil, &fs.PathError{Op: OpOpen.String(), Path: name, Err: ErrNotExist}
+	if err != nil {
+		return nil, err
 	}
 
-	// Create ReadDir handler for directories
-	var readDirHandler func(int) ([]fs.DirEntry, error)
-	if mapFile.Mode.IsDir() {
-		readDirHandler = m.createReadDirHandler(cleanName)
+	handle := m.newHandle(node, cleanName, resolved, mode, os.O_RDONLY)
+	j.on(handle)
+
+	return handle, nil
+}
+
+// OpenFile opens the named file with the given flags and returns a WritableFile.
+// It is the generalized open call, mirroring [os.OpenFile]; flag is a bitmask
+// of the [os] O_* constants.
+//
+// Exactly one of os.O_RDONLY, os.O_WRONLY, or os.O_RDWR selects the access mode
+// of the returned handle: read-only handles reject Write and WriteAt, and
+// write-only handles reject Read and ReadAt, both with ErrPermission.
+// The remaining flags behave as follows:
+//   - os.O_CREATE creates the file with perm if it does not exist. The parent
+//     directory must already exist.
+//   - os.O_EXCL, used with os.O_CREATE, returns ErrExist if the file exists.
+//   - os.O_TRUNC empties an existing file opened for writing.
+//   - os.O_APPEND makes every write append to the file.
+//
+// Without os.O_APPEND, a writable handle writes at its current offset and
+// advances it, like os.File, whatever the filesystem's write mode (see
+// WithPositional); Seek moves the offset for the next Write.
+// Opening a writable handle or creating a file on a read-only filesystem
+// (WithReadOnly) returns ErrPermission, and opening a directory for writing
+// returns ErrIsDir.
+//
+// OpenFile is recorded as OpOpen in Stats and is subject to OpOpen error
+// injection and latency, exactly like Open.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) OpenFile(name string, flag int, perm FileMode) (f WritableFile, err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpOpen, name, 0, err) }()
+	j := m.journal.begin(OpOpen, name)
+	defer func() { j.end(0, err) }()
+
+	cleanName, err := m.validateAndCleanPath(name, OpOpen)
+	if err != nil {
+		return nil, err
 	}
 
-	// Clone latency simulator to give each file handle independent Once() state
-	// while preserving duration configuration
-	clonedLatency := m.latency.Clone()
+	if err := m.injector.CheckAndApply(OpOpen, cleanName); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return nil, err
+	}
 
-	// Create MockFile with its own Stats for file-handle operations
-	return newMockFile(
-		mapFile,
-		cleanName,
-		m.writeMode,
-		m.injector,    // Share error injector
-		clonedLatency, // Independent per file
-		readDirHandler,
-		nil, // Each file gets its own Stats
-	), nil
+	j.simulate(m.latency, OpOpen)
+
+	access := flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	writable := access != os.O_RDONLY
+
+	if writable && m.writeMode == writeModeReadOnly {
+		return nil, &fs.PathError{Op: OpOpen.String(), Path: name, Err: ErrPermission}
+	}
+
+	m.mu.Lock()
+	var (
+		resolved string
+		node     *inode
+	)
+	err = m.checkOpenLimit(name)
+	if err == nil {
+		resolved, node, err = m.openFileEntry(name, cleanName, flag, perm, writable)
+	}
+	if err == nil {
+		m.acquireInode(node)
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mode := writeModePositional
+	switch {
+	case !writable:
+		mode = writeModeReadOnly
+	case flag&os.O_APPEND != 0:
+		mode = writeModeAppend
+	}
+
+	handle := m.newHandle(node, cleanName, resolved, mode, flag)
+	handle.writeOnly = access == os.O_WRONLY
+	handle.versioned = writable && flag&os.O_TRUNC != 0
+	j.on(handle)
+
+	return handle, nil
+}
+
+// Create creates or truncates the named file, mirroring [os.Create].
+// If the file does not exist, it is created with mode 0o666.
+// It is shorthand for OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666).
+func (m *MockFS) Create(name string) (WritableFile, error) {
+	return m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
 }
 
 // OpenMockFile opens the named file and returns the concrete *MockFile directly.
@@ -465,7 +639,9 @@ func (m *MockFS) ReadFile(name string) (data []byte, err error) {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) ReadDir(name string) (de []fs.DirEntry, err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpReadDir, 0, err) }()
+	defer func() { m.stats.RecordPath(OpReadDir, name, 0, err) }()
+	j := m.journal.begin(OpReadDir, name)
+	defer func() { j.end(0, err) }()
 
 	cleanName, err := m.validateAndCleanPath(name, OpReadDir)
 	if err != nil {
@@ -473,26 +649,30 @@ func (m *MockFS) ReadDir(name string) (de []fs.DirEntry, err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpReadDir, cleanName); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return nil, err
 	}
 
-	m.latency.Simulate(OpReadDir)
+	j.simulate(m.latency, OpReadDir)
 
 	m.mu.RLock()
-	mapFile, exists := m.files[cleanName]
+	resolved, node, err := m.lookup(OpReadDir.String(), name, cleanName, true)
+	if err == nil && node.Mode.IsDir() {
+		err = m.checkAccess(OpReadDir.String(), name, node, permRead)
+	}
 	m.mu.RUnlock()
 
-	if !exists {
-		return nil, &fs.PathError{Op: OpReadDir.String(), Path: name, Err: ErrNotExist}
+	if err != nil {
+		return nil, err
 	}
 
-	if !mapFile.Mode.IsDir() {
+	if !node.Mode.IsDir() {
 		return nil, &fs.PathError{Op: OpReadDir.String(), Path: name, Err: ErrNotDir}
 	}
 
 	// Use the same handler logic
-	handler := m.createReadDirHandler(cleanName)
+	handler := m.createReadDirHandler(resolved)
 	return handler(-1)
 }
 
@@ -512,12 +692,19 @@ func (m *MockFS) Sub(dir string) (fs.FS, error) {
 	subFS.latency = m.latency.Clone()
 	subFS.writeMode = m.writeMode
 	subFS.createIfMissing = m.createIfMissing
-	// Sub filesystem gets its own Stats (not shared with parent)
+	subFS.permChecks, subFS.uid, subFS.gids = m.permChecks, m.uid, m.gids
+	subFS.maxOpen = m.maxOpen
+	subFS.limits = m.limits.forSub(cleanDir)
+	// Sub filesystem gets its own Stats (not shared with parent) and journal
+	if m.journal != nil {
+		subFS.journal = &journal{}
+	}
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	m.copyFilesToSubFS(subFS, cleanDir, info)
+	subFS.markDurable()
 
 	// Clone the injector with adjusted paths
 	subFS.injector = m.injector.CloneForSub(cleanDir)
@@ -530,6 +717,7 @@ func (m *MockFS) Sub(dir string) (fs.FS, error) {
 // AddFile adds a new file to the mock filesystem.
 // If the file already exists, it is overwritten.
 // The parent directories will be created implicitly if they don't exist.
+// The content is converted as by File.
 // Returns an error if the path or content is invalid, or if a file blocks a parent directory.
 func (m *MockFS) AddFile(filePath string, content any, mode ...FileMode) error {
 	opName := "AddFile"
@@ -541,7 +729,7 @@ func (m *MockFS) AddFile(filePath string, content any, mode ...FileMode) error {
 
 	cleanPath := path.Clean(filePath)
 
-	data, err := toBytes(content)
+	c, err := fileContent(content)
 	if err != nil {
 		return fmt.Errorf("%s: invalid content in %s (%T): %w", opName, filePath, content, err)
 	}
@@ -560,16 +748,18 @@ func (m *MockFS) AddFile(filePath string, content any, mode ...FileMode) error {
 	}
 
 	// Check if a directory exists at this path
-	if existing, exists := m.files[cleanPath]; exists && existing.Mode.IsDir() {
+	existing, exists := m.files[cleanPath]
+	if exists && existing.Mode.IsDir() {
 		return &fs.PathError{Op: opName, Path: filePath, Err: ErrIsDir}
 	}
-
-	m.files[cleanPath] = &fstest.MapFile{
-		Data:    data, // already deep copied by toBytes
-		Mode:    (perm & ModePerm) &^ ModeDir,
-		ModTime: time.Now(),
+	if err := m.checkAddFile(filePath, cleanPath, existing, c.size()); err != nil {
+		return err
 	}
 
+	// A new inode replaces the old entry; handles opened before keep the old content
+	m.link(cleanPath, m.newFile(c, perm))
+	m.persistPath(cleanPath)
+
 	return nil
 }
 
@@ -592,7 +782,12 @@ func (m *MockFS) AddDir(dirPath string, mode ...FileMode) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	return m.mkdirAll(opName, cleanPath, perm)
+	if err := m.mkdirAll(opName, cleanPath, perm); err != nil {
+		return err
+	}
+	m.persistPath(cleanPath)
+
+	return nil
 }
 
 // RemoveEntry removes a file or directory from the mock filesystem.
@@ -610,12 +805,8 @@ func (m *MockFS) RemoveEntry(fileOrDirPath string) error {
 	defer m.mu.Unlock()
 
 	// Recursive removal to keep map state consistent
-	prefix := cleanPath + "/"
-	for p := range m.files {
-		if p == cleanPath || strings.HasPrefix(p, prefix) {
-			delete(m.files, p)
-		}
-	}
+	m.unpersistPath(cleanPath)
+	m.unlinkTree(cleanPath)
 
 	return nil
 }
@@ -824,6 +1015,229 @@ func (m *MockFS) FailRenameOnce(filepath string, err error) error {
 	return m.injector.AddExact(OpRename, filepath, err, ErrorModeOnce, 0)
 }
 
+// FailChmod configures a path to return the specified error on Chmod operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChmod(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChmod, filepath, err, ErrorModeAlways, 0)
+}
+
+// FailChmodOnce configures a path to return the specified error once on Chmod operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChmodOnce(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChmod, filepath, err, ErrorModeOnce, 0)
+}
+
+// FailChtimes configures a path to return the specified error on Chtimes operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChtimes(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChtimes, filepath, err, ErrorModeAlways, 0)
+}
+
+// FailChtimesOnce configures a path to return the specified error once on Chtimes operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChtimesOnce(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChtimes, filepath, err, ErrorModeOnce, 0)
+}
+
+// FailChown configures a path to return the specified error on Chown operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChown(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChown, filepath, err, ErrorModeAlways, 0)
+}
+
+// FailChownOnce configures a path to return the specified error once on Chown operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailChownOnce(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpChown, filepath, err, ErrorModeOnce, 0)
+}
+
+// FailTruncate configures a path to return the specified error on Truncate operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailTruncate(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpTruncate, filepath, err, ErrorModeAlways, 0)
+}
+
+// FailTruncateOnce configures a path to return the specified error once on Truncate operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailTruncateOnce(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpTruncate, filepath, err, ErrorModeOnce, 0)
+}
+
+// FailSync configures a path to return the specified error on Sync operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailSync(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpSync, filepath, err, ErrorModeAlways, 0)
+}
+
+// FailSyncOnce configures a path to return the specified error once on Sync operations.
+// It returns an error only if the underlying rule configuration is invalid;
+// for this fixed-mode call, that is unreachable.
+func (m *MockFS) FailSyncOnce(filepath string, err error) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(OpSync, filepath, err, ErrorModeOnce, 0)
+}
+
+// FailScript configures a path to step through script on op, one outcome per
+// call: nil succeeds, an error is returned, and a trailing ErrRepeat starts the
+// script over (see NewScriptRule).
+// Returns an error wrapping ErrUsage if the script is invalid.
+func (m *MockFS) FailScript(op Operation, filepath string, script ...error) error {
+	rule, err := NewScriptRule(script, NewExactMatcher(filepath))
+	if err != nil {
+		return err
+	}
+	m.injector.Add(op, rule)
+	return nil
+}
+
+// FailEvery configures a path to return the specified error on every kth op call.
+// Returns an error wrapping ErrUsage if k is less than 1.
+func (m *MockFS) FailEvery(op Operation, filepath string, err error, k int) error {
+	//nolint:wrapcheck // returned verbatim: AddExact's own error already carries the "mockfs:" prefix
+	return m.injector.AddExact(op, filepath, err, ErrorModeEvery, k)
+}
+
+// FailCalls configures a path to return the specified error on the given op
+// call numbers, counted from 1 (see NewCallsRule).
+// Returns an error wrapping ErrUsage if calls is empty or holds a number less than 1.
+func (m *MockFS) FailCalls(op Operation, filepath string, err error, calls ...int) error {
+	rule, ruleErr := NewCallsRule(err, calls, NewExactMatcher(filepath))
+	if ruleErr != nil {
+		return ruleErr
+	}
+	m.injector.Add(op, rule)
+	return nil
+}
+
+// InjectChaos makes operations on every path fail at random, with errors
+// drawn from errs by a generator seeded with seed (see NewChaosRule). probs
+// maps each operation to its failure probability; the entry for OpUnknown
+// applies to every operation without an entry of its own, and operations with
+// a zero probability do not draw at all. The returned Chaos records the
+// faults it injects and can report them when a test fails.
+//
+// Returns an error wrapping ErrUsage if a probability is outside [0, 1] or
+// errs is empty, in which case no rule is added.
+func (m *MockFS) InjectChaos(seed uint64, probs map[Operation]float64, errs ...error) (*Chaos, error) {
+	c := NewChaos(seed)
+	matcher := NewWildcardMatcher()
+
+	rules := make(map[Operation]*ErrorRule, NumOperations)
+	for op := OpStat; op < NumOperations; op++ {
+		p, ok := probs[op]
+		if !ok {
+			p = probs[OpUnknown]
+		}
+
+		rule, err := NewChaosRule(c, p, errs, matcher)
+		if err != nil {
+			return nil, err
+		}
+		if p > 0 {
+			rules[op] = rule
+		}
+	}
+
+	for op, rule := range rules {
+		m.injector.Add(op, rule)
+	}
+	return c, nil
+}
+
+// ShortRead configures a path to cap every Read and ReadAt at n bytes,
+// without an error (see NewShortRule).
+// Returns an error wrapping ErrUsage if n is negative.
+func (m *MockFS) ShortRead(filepath string, n int) error {
+	if n < 0 {
+		return fmt.Errorf("mockfs: %w: negative byte count %d", ErrUsage, n)
+	}
+	return m.addShortRule(OpRead, filepath, LimitBytes(n), nil)
+}
+
+// ShortReadRandom configures a path to return a random-length, non-empty
+// prefix of each Read and ReadAt request, without an error. The lengths come
+// from a generator seeded with seed (see LimitRandom).
+func (m *MockFS) ShortReadRandom(filepath string, seed uint64) error {
+	return m.addShortRule(OpRead, filepath, LimitRandom(seed), nil)
+}
+
+// ShortWrite configures a path to write only the first k bytes of every
+// Write, WriteAt, and WriteFile and then return err, or io.ErrShortWrite if
+// err is nil (see NewShortRule).
+// Returns an error wrapping ErrUsage if k is negative.
+func (m *MockFS) ShortWrite(filepath string, k int, err error) error {
+	if k < 0 {
+		return fmt.Errorf("mockfs: %w: negative byte count %d", ErrUsage, k)
+	}
+	return m.addShortRule(OpWrite, filepath, LimitBytes(k), err)
+}
+
+// FailReadRange configures a path so that every Read and ReadAt touching the
+// length bytes at off returns the bytes before them and then err, like a bad
+// sector (see NewRangeRule).
+// Returns an error wrapping ErrUsage if off is negative or length is not positive.
+func (m *MockFS) FailReadRange(filepath string, off, length int64, err error) error {
+	return m.addRangeRule(OpRead, filepath, off, length, err)
+}
+
+// FailWriteRange configures a path so that every Write, WriteAt, and
+// WriteFile touching the length bytes at off writes the bytes before them and
+// then returns err, or io.ErrShortWrite if err is nil (see NewRangeRule).
+// Returns an error wrapping ErrUsage if off is negative or length is not positive.
+func (m *MockFS) FailWriteRange(filepath string, off, length int64, err error) error {
+	return m.addRangeRule(OpWrite, filepath, off, length, err)
+}
+
+// addRangeRule adds an always-firing byte-range rule for an exact path.
+func (m *MockFS) addRangeRule(op Operation, filepath string, off, length int64, err error) error {
+	rule, ruleErr := NewRangeRule(off, length, err, ErrorModeAlways, 0, NewExactMatcher(filepath))
+	if ruleErr != nil {
+		return ruleErr
+	}
+	m.injector.Add(op, rule)
+	return nil
+}
+
+// CorruptRead configures a path so that every Read and ReadAt, and so every
+// ReadFile, succeeds with the data rewritten by c (see NewCorruptionRule).
+// Returns an error wrapping ErrUsage if c is nil.
+func (m *MockFS) CorruptRead(filepath string, c Corruption) error {
+	rule, err := NewCorruptionRule(c, ErrorModeAlways, 0, NewExactMatcher(filepath))
+	if err != nil {
+		return err
+	}
+	m.injector.Add(OpRead, rule)
+	return nil
+}
+
+// addShortRule adds an always-firing partial-transfer rule for an exact path.
+func (m *MockFS) addShortRule(op Operation, filepath string, limit TransferLimit, err error) error {
+	rule, ruleErr := NewShortRule(limit, err, ErrorModeAlways, 0, NewExactMatcher(filepath))
+	if ruleErr != nil {
+		return ruleErr
+	}
+	m.injector.Add(op, rule)
+	return nil
+}
+
 // MarkNonExistent configures paths to return ErrNotExist for all operations.
 // This removes the paths from the internal map and injects errors.
 //
@@ -855,9 +1269,11 @@ func (m *MockFS) Stats() Stats {
 	return m.stats.Snapshot()
 }
 
-// ResetStats resets all operation statistics to zero.
+// ResetStats resets all operation statistics, including HandleStats, to zero.
+// The statistics of open handles are left alone.
 func (m *MockFS) ResetStats() {
 	m.stats.Reset()
+	m.handleStats.Reset()
 }
 
 // --- WritableFS Implementation ---
@@ -867,7 +1283,9 @@ func (m *MockFS) ResetStats() {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) Mkdir(dirPath string, perm FileMode) (err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpMkdir, 0, err) }()
+	defer func() { m.stats.RecordPath(OpMkdir, dirPath, 0, err) }()
+	j := m.journal.begin(OpMkdir, dirPath)
+	defer func() { j.end(0, err) }()
 
 	// Simulation Layer: Validation, Injection, Latency
 	cleanPath, err := m.validateAndCleanPath(dirPath, OpMkdir)
@@ -879,16 +1297,24 @@ func (m *MockFS) Mkdir(dirPath string, perm FileMode) (err error) {
 		return &fs.PathError{Op: OpMkdir.String(), Path: dirPath, Err: ErrInvalid}
 	}
 	if err := m.injector.CheckAndApply(OpMkdir, cleanPath); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
-	m.latency.Simulate(OpMkdir)
+	j.simulate(m.latency, OpMkdir)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Logic Layer
-	return m.mkdir(OpMkdir.String(), cleanPath, perm)
+	resolved, err := m.resolvePath(OpMkdir.String(), dirPath, cleanPath, false)
+	if err != nil {
+		return err
+	}
+	if err := m.checkCreate(OpMkdir.String(), dirPath, resolved); err != nil {
+		return err
+	}
+	return m.mkdir(OpMkdir.String(), resolved, perm)
 }
 
 // MkdirAll creates a directory path and all parents if needed.
@@ -896,7 +1322,9 @@ func (m *MockFS) Mkdir(dirPath string, perm FileMode) (err error) {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) MkdirAll(dirPath string, perm FileMode) (err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpMkdirAll, 0, err) }()
+	defer func() { m.stats.RecordPath(OpMkdirAll, dirPath, 0, err) }()
+	j := m.journal.begin(OpMkdirAll, dirPath)
+	defer func() { j.end(0, err) }()
 
 	// Simulation Layer
 	cleanPath, err := m.validateAndCleanPath(dirPath, OpMkdirAll)
@@ -904,25 +1332,38 @@ func (m *MockFS) MkdirAll(dirPath string, perm FileMode) (err error) {
 		return err
 	}
 	if err := m.injector.CheckAndApply(OpMkdirAll, cleanPath); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
-	m.latency.Simulate(OpMkdirAll)
+	j.simulate(m.latency, OpMkdirAll)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Logic Layer
-	return m.mkdirAll(OpMkdirAll.String(), cleanPath, perm)
+	resolved, err := m.resolvePath(OpMkdirAll.String(), dirPath, cleanPath, true)
+	if err != nil {
+		return err
+	}
+	if err := m.checkCreateAll(OpMkdirAll.String(), dirPath, resolved); err != nil {
+		return err
+	}
+	return m.mkdirAll(OpMkdirAll.String(), resolved, perm)
 }
 
 // Remove removes a file or directory from the filesystem.
 // Directories must be empty to be removed.
 //
+// Remove unlinks the name only: handles that are still open keep reading and
+// writing the file until they are closed, and other hard links keep it reachable.
+//
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) Remove(filePath string) (err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpRemove, 0, err) }()
+	defer func() { m.stats.RecordPath(OpRemove, filePath, 0, err) }()
+	j := m.journal.begin(OpRemove, filePath)
+	defer func() { j.end(0, err) }()
 
 	cleanPath, err := m.validateAndCleanPath(filePath, OpRemove)
 	if err != nil {
@@ -930,31 +1371,32 @@ func (m *MockFS) Remove(filePath string) (err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpRemove, cleanPath); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
 
-	m.latency.Simulate(OpRemove)
+	j.simulate(m.latency, OpRemove)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	file, exists := m.files[cleanPath]
-	if !exists {
-		return &fs.PathError{Op: "Remove", Path: filePath, Err: ErrNotExist}
+	cleanPath, file, err := m.lookup("Remove", filePath, cleanPath, false)
+	if err != nil {
+		return err
+	}
+
+	if err := m.checkDelete("Remove", filePath, cleanPath, file); err != nil {
+		return err
 	}
 
 	// If it's a directory, check it's empty
-	if file.Mode.IsDir() {
-		prefix := cleanPath + "/"
-		for p := range m.files {
-			if strings.HasPrefix(p, prefix) {
-				return &fs.PathError{Op: "Remove", Path: filePath, Err: ErrNotEmpty}
-			}
-		}
+	if file.Mode.IsDir() && m.hasChildren(cleanPath) {
+		return &fs.PathError{Op: "Remove", Path: filePath, Err: ErrNotEmpty}
 	}
 
-	delete(m.files, cleanPath)
+	// Open handles keep the inode alive until they are closed
+	m.unlink(cleanPath)
 	return nil
 }
 
@@ -963,7 +1405,9 @@ func (m *MockFS) Remove(filePath string) (err error) {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) RemoveAll(filePath string) (err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpRemoveAll, 0, err) }()
+	defer func() { m.stats.RecordPath(OpRemoveAll, filePath, 0, err) }()
+	j := m.journal.begin(OpRemoveAll, filePath)
+	defer func() { j.end(0, err) }()
 
 	cleanPath, err := m.validateAndCleanPath(filePath, OpRemoveAll)
 	if err != nil {
@@ -971,33 +1415,48 @@ func (m *MockFS) RemoveAll(filePath string) (err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpRemoveAll, cleanPath); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
 
-	m.latency.Simulate(OpRemoveAll)
+	j.simulate(m.latency, OpRemoveAll)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Remove the path itself and all children
-	prefix := cleanPath + "/"
-	for p := range m.files {
-		if p == cleanPath || strings.HasPrefix(p, prefix) {
-			delete(m.files, p)
-		}
+	cleanPath, err = m.resolvePath(OpRemoveAll.String(), filePath, cleanPath, false)
+	if err != nil {
+		return err
+	}
+
+	if err := m.checkDeleteTree(OpRemoveAll.String(), filePath, cleanPath); err != nil {
+		return err
 	}
 
+	// Remove the path itself and all children
+	m.unlinkTree(cleanPath)
+
 	return nil
 }
 
-// Rename renames a file or directory in the filesystem.
-// If the destination already exists, it will be overwritten.
+// Rename renames a file or directory in the filesystem, mirroring [os.Rename].
+// The entry keeps its inode, so handles opened before the rename keep reading
+// and writing the same file, now reachable under newpath.
+//
+// If the destination already exists, it is atomically replaced: a file may
+// replace a file (ErrIsDir if newpath is a directory), and a directory may
+// replace an empty directory (ErrNotDir if newpath is a file, ErrNotEmpty if
+// it has entries). Moving a directory into itself returns ErrInvalid.
+// Renaming a path onto itself does nothing.
 //
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) Rename(oldpath, newpath string) (err error) {
 	// Record the result of this operation on exit
-	defer func() { m.stats.Record(OpRename, 0, err) }()
+	defer func() { m.stats.RecordPath(OpRename, oldpath, 0, err) }()
+	j := m.journal.begin(OpRename, oldpath)
+	j.to(newpath)
+	defer func() { j.end(0, err) }()
 
 	cleanOld, err := m.validateAndCleanPath(oldpath, OpRename)
 	if err != nil {
@@ -1010,45 +1469,26 @@ func (m *MockFS) Rename(oldpath, newpath string) (err error) {
 	}
 
 	if err := m.injector.CheckAndApply(OpRename, cleanOld); err != nil {
+		j.fault()
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
 		return err
 	}
 
-	m.latency.Simulate(OpRename)
+	j.simulate(m.latency, OpRename)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	oldFile, exists := m.files[cleanOld]
-	if !exists {
-		return &fs.PathError{Op: "Rename", Path: oldpath, Err: ErrNotExist}
-	}
-
-	// Copy to new location
-	newFile := *oldFile
-	newFile.Data = bytes.Clone(oldFile.Data)
-	newFile.ModTime = time.Now()
-	m.files[cleanNew] = &newFile
-
-	// If directory, rename all children
-	if oldFile.Mode.IsDir() {
-		oldPrefix := cleanOld + "/"
-		newPrefix := cleanNew + "/"
-		for p, f := range m.files {
-			if !strings.HasPrefix(p, oldPrefix) {
-				continue
-			}
-			newP := newPrefix + p[len(oldPrefix):]
-			childFile := *f
-			childFile.Data = bytes.Clone(f.Data)
-			m.files[newP] = &childFile
-			delete(m.files, p)
-		}
+	cleanOld, _, err = m.lookup("Rename", oldpath, cleanOld, false)
+	if err != nil {
+		return err
+	}
+	cleanNew, err = m.resolvePath("Rename", newpath, cleanNew, false)
+	if err != nil {
+		return err
 	}
 
-	// Remove old location
-	delete(m.files, cleanOld)
-	return nil
+	return m.renameEntry(newpath, cleanOld, cleanNew)
 }
 
 // WriteFile writes data to a file in the filesystem.
@@ -1056,25 +1496,33 @@ func (m *MockFS) Rename(oldpath, newpath string) (err error) {
 //nolint:nonamedreturns // Deferred function is using the named returns.
 func (m *MockFS) WriteFile(filePath string, data []byte, perm FileMode) (err error) {
 	// Record the result of this operation on exit
-	defer func() {
-		written := 0
-		if err == nil {
-			written = len(data)
-		}
-		m.stats.Record(OpWrite, written, err)
-	}()
+	written := 0
+	defer func() { m.stats.RecordPath(OpWrite, filePath, written, err) }()
+	j := m.journal.begin(OpWrite, filePath)
+	defer func() { j.end(written, err) }()
 
 	cleanPath, err := m.validateAndCleanPath(filePath, OpWrite)
 	if err != nil {
 		return err
 	}
 
-	if err := m.injector.CheckAndApply(OpWrite, cleanPath); err != nil {
+	off := m.writeFileOffset(filePath, cleanPath)
+	limit, injErr := checkTransfer(m.injector, OpWrite, cleanPath, off, len(data))
+	j.transfer(off, len(data), limit, injErr)
+	if injErr != nil && limit == 0 {
 		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
-		return err
+		return injErr
 	}
 
-	m.latency.Simulate(OpWrite)
+	// A partial-transfer rule lets only a prefix through, or fails the write
+	// after all of it
+	var shortErr error
+	if limit < len(data) || injErr != nil {
+		shortErr = shortWriteError(injErr)
+		data = data[:limit]
+	}
+
+	j.simulate(m.latency, OpWrite)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -1084,32 +1532,78 @@ func (m *MockFS) WriteFile(filePath string, data []byte, perm FileMode) (err err
 		return &fs.PathError{Op: "Write", Path: filePath, Err: ErrPermission}
 	}
 
+	cleanPath, err = m.resolvePath("Write", filePath, cleanPath, true)
+	if err != nil {
+		return err
+	}
+
 	// Find the existing file or create if it doesn't exist
 	existing, ok := m.files[cleanPath]
 	if !ok {
 		if !m.createIfMissing {
 			return &fs.PathError{Op: "Write", Path: filePath, Err: ErrNotExist}
 		}
+		if err := m.checkCreate("Write", filePath, cleanPath); err != nil {
+			return err
+		}
+		if err := m.checkInodeLimit("Write", filePath); err != nil {
+			return err
+		}
+		if fit := m.fitWrite(nil, []string{cleanPath}, 0, len(data), false); fit < len(data) {
+			shortErr = &fs.PathError{Op: "Write", Path: filePath, Err: ErrDiskFull}
+			data = data[:fit]
+		}
 
-		m.files[cleanPath] = &fstest.MapFile{
+		node := m.newInode(&fstest.MapFile{
 			Data:    bytes.Clone(data),
-			Mode:    perm &^ ModeDir,
+			Mode:    perm & ModePerm,
 			ModTime: time.Now(),
+		})
+		if keepsVersions(m.injector) {
+			node.previous = []byte{}
 		}
-		return nil
+		m.link(cleanPath, node)
+		if len(data) > 0 {
+			m.notify(cleanPath, EventWrite)
+		}
+		written = len(data)
+		return shortErr
+	}
+
+	if err := m.checkAccess("Write", filePath, existing, permWrite); err != nil {
+		return err
+	}
+
+	// Store what fits on the disk
+	off = 0
+	if m.writeMode == writeModeAppend {
+		off = existing.size()
+	}
+	if fit := m.fitWrite(existing, m.pathsOf(existing), off, len(data), m.writeMode != writeModeAppend); fit < len(data) {
+		if fit == 0 {
+			return &fs.PathError{Op: "Write", Path: filePath, Err: ErrDiskFull}
+		}
+		shortErr = &fs.PathError{Op: "Write", Path: filePath, Err: ErrDiskFull}
+		data = data[:fit]
 	}
 
 	// Apply write mode
+	existing.saveVersion(m.injector)
+	m.notify(cleanPath, EventWrite)
 	switch m.writeMode {
 	case writeModeAppend:
-		existing.Data = append(existing.Data, data...)
+		existing.recordWrite(existing.size(), data)
+		existing.writeAt(data, existing.size())
 		existing.ModTime = time.Now()
-		return nil
+		written = len(data)
+		return shortErr
 
-	case writeModeOverwrite:
-		existing.Data = bytes.Clone(data)
+	case writeModeOverwrite, writeModePositional:
+		existing.recordWrite(0, data)
+		existing.replace(data)
 		existing.ModTime = time.Now()
-		return nil
+		written = len(data)
+		return shortErr
 
 	default:
 		//nolint:forbidigo // Panic is intentional here to mark incorrect use
@@ -1119,6 +1613,165 @@ func (m *MockFS) WriteFile(filePath string, data []byte, perm FileMode) (err err
 
 // --- Internal Helpers ---
 
+// writeFileOffset returns the offset WriteFile writes at: the end of an
+// existing file in append mode, and 0 otherwise.
+func (m *MockFS) writeFileOffset(name, cleanPath string) int64 {
+	if m.writeMode != writeModeAppend {
+		return 0
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, node, err := m.lookup("Write", name, cleanPath, true); err == nil {
+		return node.size()
+	}
+	return 0
+}
+
+// newHandle creates a MockFile handle for an entry of this filesystem.
+// It is the single point where MockFS hands out file handles.
+// cleanName is the name the handle was opened with; resolved is the
+// symlink-free path of the entry, used to list directory contents.
+// flag is the open flags, kept for OpenHandles.
+// The caller must already have registered the handle with acquireInode;
+// closing the handle releases it.
+func (m *MockFS) newHandle(node *inode, cleanName, resolved string, mode writeMode, flag int) *MockFile {
+	// Create ReadDir handler for directories
+	var readDirHandler func(int) ([]fs.DirEntry, error)
+	if node.Mode.IsDir() {
+		readDirHandler = m.createReadDirHandler(resolved)
+	}
+
+	// Clone latency simulator to give each file handle independent Once() state
+	// while preserving duration configuration
+	clonedLatency := handleLatency(m.latency)
+
+	// Create MockFile with its own Stats for file-handle operations,
+	// also counted in the filesystem's HandleStats
+	f := newMockFile(
+		node,
+		cleanName,
+		mode,
+		m.injector,    // Share error injector
+		clonedLatency, // Independent per file
+		readDirHandler,
+		&handleRecorder{StatsRecorder: NewStatsRecorder(nil), fsys: m.handleStats},
+	)
+
+	// The handle follows the inode, not the name it was opened by
+	f.fsys = m
+	f.path = resolved
+	f.id = m.handles.Add(1)
+	m.live.add(f, flag)
+
+	return f
+}
+
+// openFileEntry resolves, creates, or truncates the entry for OpenFile according to flag.
+// It returns the resolved path of the entry along with the entry itself.
+// Caller must hold the write lock and have validated the path.
+func (m *MockFS) openFileEntry(
+	name, cleanName string,
+	flag int,
+	perm FileMode,
+	writable bool,
+) (string, *inode, error) {
+	opName := OpOpen.String()
+
+	// O_EXCL never follows a final symlink, matching open(2)
+	exclusive := flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL
+
+	resolved, err := m.resolvePath(opName, name, cleanName, !exclusive)
+	if err != nil {
+		return "", nil, err
+	}
+
+	node, exists := m.files[resolved]
+	if !exists {
+		if flag&os.O_CREATE == 0 {
+			return "", nil, &fs.PathError{Op: opName, Path: name, Err: ErrNotExist}
+		}
+		if m.writeMode == writeModeReadOnly {
+			return "", nil, &fs.PathError{Op: opName, Path: name, Err: ErrPermission}
+		}
+		if err := m.checkParentDir(opName, name, resolved); err != nil {
+			return "", nil, err
+		}
+		if err := m.checkCreate(opName, name, resolved); err != nil {
+			return "", nil, err
+		}
+		if err := m.checkInodeLimit(opName, name); err != nil {
+			return "", nil, err
+		}
+
+		node = m.newInode(&fstest.MapFile{
+			Mode:    (perm & ModePerm) &^ ModeDir,
+			ModTime: time.Now(),
+		})
+		if keepsVersions(m.injector) {
+			node.previous = []byte{}
+		}
+		m.link(resolved, node)
+
+		return resolved, node, nil
+	}
+
+	if exclusive {
+		return "", nil, &fs.PathError{Op: opName, Path: name, Err: ErrExist}
+	}
+
+	if node.Mode.IsDir() && writable {
+		return "", nil, &fs.PathError{Op: opName, Path: name, Err: ErrIsDir}
+	}
+
+	want := permRead
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		want = permWrite
+	case os.O_RDWR:
+		want = permRead | permWrite
+	}
+	if err := m.checkAccess(opName, name, node, want); err != nil {
+		return "", nil, err
+	}
+
+	if flag&os.O_TRUNC == 0 || !writable {
+		if err := node.refresh(name); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if flag&os.O_TRUNC != 0 && writable {
+		node.saveVersion(m.injector)
+		node.snapshot()
+		node.replace(nil)
+		node.ModTime = time.Now()
+		m.notify(resolved, EventWrite)
+	}
+
+	return resolved, node, nil
+}
+
+// checkParentDir verifies that the parent of cleanPath exists and is a directory.
+// Caller must hold the mutex and have validated the path.
+func (m *MockFS) checkParentDir(opName, name, cleanPath string) error {
+	parent := path.Dir(cleanPath)
+	if parent == "." {
+		return nil
+	}
+
+	parentFile, exists := m.files[parent]
+	if !exists {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrNotExist}
+	}
+	if !parentFile.Mode.IsDir() {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrNotDir}
+	}
+
+	return nil
+}
+
 // createReadDirHandler generates a ReadDir handler for a directory.
 // The handler returns fs.DirEntry implementations that delegate to MockFile.Stat().
 func (m *MockFS) createReadDirHandler(dirPath string) func(int) ([]fs.DirEntry, error) {
@@ -1182,12 +1835,7 @@ func (m *MockFS) collectDirEntries(dirPath, prefix string) []fs.DirEntry {
 			continue
 		}
 
-		entries = append(entries, &FileInfo{
-			name:    name,
-			mode:    child.Mode,
-			modTime: child.ModTime,
-			size:    int64(len(child.Data)),
-		})
+		entries = append(entries, child.fileInfo(name))
 	}
 
 	return entries
@@ -1237,50 +1885,51 @@ func (m *MockFS) validateSubdir(dir string) (string, fs.FileInfo, error) {
 
 	// Check if directory exists
 	m.mu.RLock()
-	mapFile, exists := m.files[cleanDir]
-	m.mu.RUnlock()
+	defer m.mu.RUnlock()
 
-	if !exists {
-		return "", nil, &fs.PathError{Op: "Sub", Path: dir, Err: ErrNotExist}
+	cleanDir, node, err := m.lookup("Sub", dir, cleanDir, true)
+	if err != nil {
+		return "", nil, err
 	}
 
-	if !mapFile.Mode.IsDir() {
+	if !node.Mode.IsDir() {
 		return "", nil, &fs.PathError{Op: "Sub", Path: dir, Err: ErrNotDir}
 	}
 
-	info := &FileInfo{
-		name:    path.Base(cleanDir),
-		size:    0,
-		mode:    mapFile.Mode,
-		modTime: mapFile.ModTime,
-	}
-
-	return cleanDir, info, nil
+	return cleanDir, node.fileInfo(path.Base(cleanDir)), nil
 }
 
 // copyFilesToSubFS populates the sub-filesystem's file map from the parent.
+// Each inode is copied once, so hard links within the subtree stay linked
+// in the sub-filesystem.
 // It assumes the caller has acquired the necessary read lock on the parent MockFS.
 func (m *MockFS) copyFilesToSubFS(subFS *MockFS, cleanDir string, dirInfo fs.FileInfo) {
 	prefix := cleanDir + "/"
 
 	// Map the directory itself to "." in the sub-filesystem
-	subFS.files["."] = &fstest.MapFile{
+	subFS.link(".", subFS.newInode(&fstest.MapFile{
 		Mode:    dirInfo.Mode() | ModeDir,
 		ModTime: dirInfo.ModTime(),
-	}
+	}))
 
 	// Copy all descendant files
-	for p, file := range m.files {
-		if strings.HasPrefix(p, prefix) {
-			// Get path relative to subDir
-			subPath := p[len(prefix):]
-
-			if file != nil {
-				newFile := *file
-				newFile.Data = bytes.Clone(file.Data)
-				subFS.files[subPath] = &newFile
-			}
+	copies := make(map[*inode]*inode)
+	for p, node := range m.files {
+		subPath, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
 		}
+
+		dup, seen := copies[node]
+		if !seen {
+			newFile := *node.MapFile
+			newFile.Data = bytes.Clone(node.Data)
+			dup = subFS.newInode(&newFile)
+			dup.virt, dup.dyn = node.virt.clone(), node.dyn
+			dup.atime, dup.uid, dup.gid = node.atime, node.uid, node.gid
+			copies[node] = dup
+		}
+		subFS.link(subPath, dup)
 	}
 }
 
@@ -1305,22 +1954,19 @@ func (m *MockFS) mkdir(opName, cleanPath string, perm FileMode) error {
 	}
 
 	// Check parent exists and is a directory
-	parent := path.Dir(cleanPath)
-	if parent != "." {
-		parentFile, exists := m.files[parent]
-		if !exists {
-			return &fs.PathError{Op: opName, Path: cleanPath, Err: ErrNotExist}
-		}
-		if !parentFile.Mode.IsDir() {
-			return &fs.PathError{Op: opName, Path: cleanPath, Err: ErrNotDir}
-		}
+	if err := m.checkParentDir(opName, cleanPath, cleanPath); err != nil {
+		return err
+	}
+
+	if err := m.checkInodeLimit(opName, cleanPath); err != nil {
+		return err
 	}
 
 	// Create the directory
-	m.files[cleanPath] = &fstest.MapFile{
+	m.link(cleanPath, m.newInode(&fstest.MapFile{
 		Mode:    (perm & ModePerm) | ModeDir,
 		ModTime: time.Now(),
-	}
+	}))
 
 	return nil
 }
@@ -1349,10 +1995,13 @@ func (m *MockFS) mkdirAll(opName, cleanPath string, perm FileMode) error {
 		}
 
 		// Create it
-		m.files[currentPath] = &fstest.MapFile{
+		if err := m.checkInodeLimit(opName, currentPath); err != nil {
+			return err
+		}
+		m.link(currentPath, m.newInode(&fstest.MapFile{
 			Mode:    (perm & ModePerm) | ModeDir,
 			ModTime: time.Now(),
-		}
+		}))
 	}
 
 	return nil
@@ -1,11 +1,13 @@
 package mockfs_test
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"path"
 	"strings"
 	"sync"
@@ -286,6 +288,31 @@ func TestNewMockFS_OptionError(t *testing.T) {
 			option:  mockfs.Dir("validdir", mockfs.File("", "content")),
 			errText: "empty file name",
 		},
+		{
+			name:    "zero open file limit",
+			option:  mockfs.WithMaxOpenFiles(0),
+			errText: "non-positive limit",
+		},
+		{
+			name:    "negative capacity",
+			option:  mockfs.WithCapacity(-1),
+			errText: "negative capacity",
+		},
+		{
+			name:    "zero inode limit",
+			option:  mockfs.WithInodeLimit(0),
+			errText: "non-positive limit",
+		},
+		{
+			name:    "invalid quota directory",
+			option:  mockfs.WithQuota("/logs", 1),
+			errText: "invalid directory",
+		},
+		{
+			name:    "negative quota",
+			option:  mockfs.WithQuota("logs", -1),
+			errText: "negative quota",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1410,6 +1437,21 @@ func TestMockFS_WriteFile(t *testing.T) {
 			data: []byte("content"),
 			perm: 0o644,
 		},
+		{
+			name: "type bits of perm are ignored",
+			opts: []mockfs.FsOption{mockfs.WithCreateIfMissing(true)},
+			path: "new.txt",
+			data: []byte("content"),
+			perm: mockfs.ModeSymlink | 0o644,
+			check: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				info, err := m.Lstat("new.txt")
+				requireNoError(t, err)
+				if info.Mode() != 0o644 {
+					t.Errorf("mode = %v, want %v", info.Mode(), mockfs.FileMode(0o644))
+				}
+			},
+		},
 		{
 			name:    "fail without createIfMissing",
 			opts:    nil,
@@ -1452,6 +1494,23 @@ func TestMockFS_WriteFile(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "positional mode replaces existing file",
+			opts: []mockfs.FsOption{mockfs.WithPositional()},
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "old content", 0o644)
+			},
+			path: "file.txt",
+			data: []byte("new"),
+			perm: 0o644,
+			check: func(t *testing.T, m *mockfs.MockFS) {
+				t.Helper()
+				content := mustReadFile(t, m, "file.txt")
+				if string(content) != "new" {
+					t.Errorf("content = %q, want %q", content, "new")
+				}
+			},
+		},
 		{
 			name:    "fail in read-only mode",
 			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
@@ -1515,6 +1574,449 @@ func TestMockFS_WriteViaOpenedFile(t *testing.T) {
 	}
 }
 
+func TestMockFS_OpenFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []mockfs.FsOption
+		path    string
+		flag    int
+		perm    mockfs.FileMode
+		wantErr error
+		check   func(*testing.T, *mockfs.MockFS, mockfs.WritableFile)
+	}{
+		{
+			name: "read-only handle rejects write",
+			path: "file.txt",
+			flag: os.O_RDONLY,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				_, err := f.Write([]byte("x"))
+				assertError(t, err, mockfs.ErrPermission, "write")
+				_, err = f.WriteAt([]byte("x"), 0)
+				assertError(t, err, mockfs.ErrPermission, "write at")
+				data, err := io.ReadAll(f)
+				requireNoError(t, err)
+				if string(data) != "content" {
+					t.Errorf("read = %q, want %q", data, "content")
+				}
+			},
+		},
+		{
+			name: "write-only handle rejects read",
+			path: "file.txt",
+			flag: os.O_WRONLY,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				_, err := f.Read(make([]byte, 4))
+				assertError(t, err, mockfs.ErrPermission, "read")
+				_, err = f.ReadAt(make([]byte, 4), 0)
+				assertError(t, err, mockfs.ErrPermission, "read at")
+				_, err = f.Write([]byte("new"))
+				requireNoError(t, err)
+				if got := mustReadFile(t, m, "file.txt"); string(got) != "newtent" {
+					t.Errorf("content = %q, want %q", got, "newtent")
+				}
+			},
+		},
+		{
+			name: "write-only handle reads consume no rule",
+			path: "file.txt",
+			flag: os.O_WRONLY,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				requireNoError(t, m.FailReadOnce("file.txt", mockfs.ErrTimeout))
+				_, err := f.Read(make([]byte, 4))
+				assertError(t, err, mockfs.ErrPermission, "read")
+				_, err = f.ReadAt(make([]byte, 4), 0)
+				assertError(t, err, mockfs.ErrPermission, "read at")
+				_, err = m.ReadFile("file.txt")
+				assertError(t, err, mockfs.ErrTimeout, "read file")
+			},
+		},
+		{
+			name: "writes at offset",
+			path: "file.txt",
+			flag: os.O_RDWR,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				// A buffered writer flushes in chunks; every chunk must survive
+				w := bufio.NewWriterSize(f, 16)
+				_, err := w.WriteString(strings.Repeat("x", 40))
+				requireNoError(t, err)
+				requireNoError(t, w.Flush())
+				if got := mustReadFile(t, m, "file.txt"); string(got) != strings.Repeat("x", 40) {
+					t.Errorf("content = %q, want 40 bytes of x", got)
+				}
+
+				_, err = f.Seek(2, io.SeekStart)
+				requireNoError(t, err)
+				_, err = f.Write([]byte("yy"))
+				requireNoError(t, err)
+				if got := mustReadFile(t, m, "file.txt"); string(got[:6]) != "xxyyxx" {
+					t.Errorf("content = %q, want prefix %q", got, "xxyyxx")
+				}
+			},
+		},
+		{
+			name: "positional regardless of filesystem write mode",
+			opts: []mockfs.FsOption{mockfs.WithAppend()},
+			path: "file.txt",
+			flag: os.O_WRONLY,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				_, err := f.Write([]byte("C"))
+				requireNoError(t, err)
+				if got := mustReadFile(t, m, "file.txt"); string(got) != "Content" {
+					t.Errorf("content = %q, want %q", got, "Content")
+				}
+			},
+		},
+		{
+			name: "create missing file",
+			path: "new.txt",
+			flag: os.O_RDWR | os.O_CREATE,
+			perm: 0o600,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				info, err := m.Stat("new.txt")
+				requireNoError(t, err)
+				if info.Mode() != 0o600 {
+					t.Errorf("mode = %v, want %v", info.Mode(), mockfs.FileMode(0o600))
+				}
+				if info.Size() != 0 {
+					t.Errorf("size = %d, want 0", info.Size())
+				}
+			},
+		},
+		{
+			name:    "create in missing directory",
+			path:    "missing/new.txt",
+			flag:    os.O_RDWR | os.O_CREATE,
+			perm:    0o644,
+			wantErr: mockfs.ErrNotExist,
+		},
+		{
+			name:    "create below a file",
+			path:    "file.txt/new.txt",
+			flag:    os.O_RDWR | os.O_CREATE,
+			perm:    0o644,
+			wantErr: mockfs.ErrNotDir,
+		},
+		{
+			name:    "missing file without create",
+			path:    "new.txt",
+			flag:    os.O_RDWR,
+			wantErr: mockfs.ErrNotExist,
+		},
+		{
+			name:    "exclusive create of existing file",
+			path:    "file.txt",
+			flag:    os.O_RDWR | os.O_CREATE | os.O_EXCL,
+			perm:    0o644,
+			wantErr: mockfs.ErrExist,
+		},
+		{
+			name: "exclusive create of missing file",
+			path: "new.txt",
+			flag: os.O_RDWR | os.O_CREATE | os.O_EXCL,
+			perm: 0o644,
+		},
+		{
+			name: "truncate existing file",
+			path: "file.txt",
+			flag: os.O_RDWR | os.O_TRUNC,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				if got := mustReadFile(t, m, "file.txt"); len(got) != 0 {
+					t.Errorf("content = %q, want empty", got)
+				}
+			},
+		},
+		{
+			name: "truncate ignored for read-only handle",
+			path: "file.txt",
+			flag: os.O_RDONLY | os.O_TRUNC,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				if got := mustReadFile(t, m, "file.txt"); string(got) != "content" {
+					t.Errorf("content = %q, want %q", got, "content")
+				}
+			},
+		},
+		{
+			name: "append",
+			path: "file.txt",
+			flag: os.O_WRONLY | os.O_APPEND,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				_, err := f.Write([]byte("-1"))
+				requireNoError(t, err)
+				_, err = f.Write([]byte("-2"))
+				requireNoError(t, err)
+				if got := mustReadFile(t, m, "file.txt"); string(got) != "content-1-2" {
+					t.Errorf("content = %q, want %q", got, "content-1-2")
+				}
+			},
+		},
+		{
+			name: "read directory",
+			path: "dir",
+			flag: os.O_RDONLY,
+			check: func(t *testing.T, m *mockfs.MockFS, f mockfs.WritableFile) {
+				t.Helper()
+				dir, ok := f.(fs.ReadDirFile)
+				if !ok {
+					t.Fatal("directory handle does not implement fs.ReadDirFile")
+				}
+				entries, err := dir.ReadDir(-1)
+				requireNoError(t, err)
+				if len(entries) != 1 {
+					t.Errorf("entries = %d, want 1", len(entries))
+				}
+			},
+		},
+		{
+			name:    "write directory",
+			path:    "dir",
+			flag:    os.O_RDWR,
+			wantErr: mockfs.ErrIsDir,
+		},
+		{
+			name:    "writable handle on read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			path:    "file.txt",
+			flag:    os.O_WRONLY,
+			wantErr: mockfs.ErrPermission,
+		},
+		{
+			name: "read-only handle on read-only filesystem",
+			opts: []mockfs.FsOption{mockfs.WithReadOnly()},
+			path: "file.txt",
+			flag: os.O_RDONLY,
+		},
+		{
+			name:    "read-only create on read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			path:    "new.txt",
+			flag:    os.O_RDONLY | os.O_CREATE,
+			perm:    0o644,
+			wantErr: mockfs.ErrPermission,
+		},
+		{
+			name: "read-only create of an existing file on read-only filesystem",
+			opts: []mockfs.FsOption{mockfs.WithReadOnly()},
+			path: "file.txt",
+			flag: os.O_RDONLY | os.O_CREATE,
+		},
+		{
+			name:    "invalid path",
+			path:    "../invalid",
+			flag:    os.O_RDONLY,
+			wantErr: mockfs.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := append([]mockfs.FsOption{
+				mockfs.File("file.txt", "content"),
+				mockfs.Dir("dir", mockfs.File("child.txt", "child")),
+			}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			f, err := mfs.OpenFile(tt.path, tt.flag, tt.perm)
+			assertError(t, err, tt.wantErr)
+			if err != nil {
+				if f != nil {
+					t.Errorf("OpenFile returned non-nil file on error: %v", f)
+				}
+				return
+			}
+			defer f.Close()
+
+			if tt.check != nil {
+				tt.check(t, mfs, f)
+			}
+		})
+	}
+}
+
+func TestMockFS_Open_Positional(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithPositional(), mockfs.File("file.txt", "0123456789"))
+	f, err := mfs.OpenMockFile("file.txt")
+	requireNoError(t, err)
+	defer f.Close()
+
+	_, err = f.Seek(4, io.SeekStart)
+	requireNoError(t, err)
+	_, err = f.Write([]byte("ab"))
+	requireNoError(t, err)
+	_, err = f.Write([]byte("cd"))
+	requireNoError(t, err)
+
+	if got := mustReadFile(t, mfs, "file.txt"); string(got) != "0123abcd89" {
+		t.Errorf("content = %q, want %q", got, "0123abcd89")
+	}
+}
+
+func TestMockFS_OpenFile_InjectionAndStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "content"))
+	requireNoError(t, mfs.FailOpenOnce("new.txt", mockfs.ErrDiskFull))
+
+	_, err := mfs.OpenFile("new.txt", os.O_RDWR|os.O_CREATE, 0o644)
+	assertError(t, err, mockfs.ErrDiskFull, "injected")
+
+	if _, err := mfs.Stat("new.txt"); !errors.Is(err, mockfs.ErrNotExist) {
+		t.Errorf("file created despite injected open error: %v", err)
+	}
+
+	f, err := mfs.OpenFile("new.txt", os.O_RDWR|os.O_CREATE, 0o644)
+	requireNoError(t, err, "second open")
+	requireNoError(t, f.Close())
+
+	mfs.Stats().Expect().
+		Count(mockfs.OpOpen, 2).
+		Failure(mockfs.OpOpen, 1).
+		Assert(t)
+}
+
+func TestMockFS_Create(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("file.txt", "old content"))
+
+	t.Run("truncates existing", func(t *testing.T) {
+		t.Parallel()
+		f, err := mfs.Create("file.txt")
+		requireNoError(t, err)
+		defer f.Close()
+
+		info, err := f.Stat()
+		requireNoError(t, err)
+		if info.Size() != 0 {
+			t.Errorf("size = %d, want 0", info.Size())
+		}
+	})
+
+	t.Run("creates missing", func(t *testing.T) {
+		t.Parallel()
+		f, err := mfs.Create("new.txt")
+		requireNoError(t, err)
+		defer f.Close()
+
+		_, err = f.Write([]byte("data"))
+		requireNoError(t, err)
+		_, err = f.Seek(0, io.SeekStart)
+		requireNoError(t, err)
+		data, err := io.ReadAll(f)
+		requireNoError(t, err)
+		if string(data) != "data" {
+			t.Errorf("content = %q, want %q", data, "data")
+		}
+
+		info, err := mfs.Stat("new.txt")
+		requireNoError(t, err)
+		if info.Mode() != 0o666 {
+			t.Errorf("mode = %v, want %v", info.Mode(), mockfs.FileMode(0o666))
+		}
+	})
+}
+
+func TestMockFS_MaxOpenFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		open func(m *mockfs.MockFS) error
+	}{
+		{name: "Open", open: func(m *mockfs.MockFS) error { _, err := m.Open("a"); return err }},
+		{name: "OpenFile", open: func(m *mockfs.MockFS) error { _, err := m.OpenFile("new", os.O_RDWR|os.O_CREATE, 0o644); return err }},
+		{name: "ReadFile", open: func(m *mockfs.MockFS) error { _, err := m.ReadFile("a"); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.WithMaxOpenFiles(2), mockfs.File("a", "data"))
+			f1, err := mfs.Open("a")
+			requireNoError(t, err)
+			f2, err := mfs.Open(".")
+			requireNoError(t, err)
+
+			err = tt.open(mfs)
+			assertError(t, err, mockfs.ErrTooManyHandles)
+			var pathErr *fs.PathError
+			if !errors.As(err, &pathErr) || pathErr.Op != mockfs.OpOpen.String() {
+				t.Errorf("err = %#v, want an Open *fs.PathError", err)
+			}
+			if _, statErr := mfs.Stat("new"); !errors.Is(statErr, fs.ErrNotExist) {
+				t.Errorf("Stat(new) = %v, want the failed open to create nothing", statErr)
+			}
+			if n := mfs.Stats().CountFailure(mockfs.OpOpen); n != 1 {
+				t.Errorf("failed opens = %d, want 1", n)
+			}
+
+			// Closing a handle frees its slot
+			requireNoError(t, f1.Close())
+			requireNoError(t, tt.open(mfs))
+			requireNoError(t, f2.Close())
+		})
+	}
+}
+
+func TestMockFS_MaxOpenFiles_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const limit, workers = 3, 20
+	mfs := mockfs.MustNewMockFS(mockfs.WithMaxOpenFiles(limit), mockfs.File("a", "data"))
+
+	var (
+		mu        sync.Mutex
+		open, top int
+		wg        sync.WaitGroup
+	)
+	for range workers {
+		wg.Go(func() {
+			for range 50 {
+				f, err := mfs.Open("a")
+				if errors.Is(err, mockfs.ErrTooManyHandles) {
+					continue
+				}
+				if err != nil {
+					t.Errorf("Open: %v", err)
+					return
+				}
+
+				mu.Lock()
+				open++
+				top = max(top, open)
+				mu.Unlock()
+
+				mu.Lock()
+				open--
+				mu.Unlock()
+				_ = f.Close()
+			}
+		})
+	}
+	wg.Wait()
+
+	if top > limit {
+		t.Errorf("%d handles open at once, want at most %d", top, limit)
+	}
+	if n := len(mfs.OpenHandles()); n != 0 {
+		t.Errorf("%d handles still open, want 0", n)
+	}
+}
+
 // --- Error Injection and Stats ---
 
 func TestMockFS_FailMethods(t *testing.T) {
@@ -1580,6 +2082,46 @@ func TestMockFS_FailMethods(t *testing.T) {
 			},
 			operation: func(m *mockfs.MockFS) error { return m.Rename("old.txt", "new.txt") },
 		},
+		{
+			name: "FailChmod",
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChmod("file.txt", injectedErr)
+			},
+			operation: func(m *mockfs.MockFS) error { return m.Chmod("file.txt", 0o600) },
+		},
+		{
+			name: "FailChtimes",
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChtimes("file.txt", injectedErr)
+			},
+			operation: func(m *mockfs.MockFS) error { return m.Chtimes("file.txt", time.Time{}, time.Now()) },
+		},
+		{
+			name: "FailChown",
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChown("file.txt", injectedErr)
+			},
+			operation: func(m *mockfs.MockFS) error { return m.Chown("file.txt", 1000, 1000) },
+		},
+		{
+			name: "FailTruncate",
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "data", 0o644)
+				m.FailTruncate("file.txt", injectedErr)
+			},
+			operation: func(m *mockfs.MockFS) error { return m.Truncate("file.txt", 0) },
+		},
+		{
+			name: "FailSync",
+			setup: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "data", 0o644)
+				m.FailSync("file.txt", injectedErr)
+			},
+			operation: syncFile("file.txt"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -1765,6 +2307,46 @@ func TestMockFS_ErrorInjection_Once(t *testing.T) {
 			},
 			op: func(m *mockfs.MockFS) error { return m.Rename("old.txt", "new.txt") },
 		},
+		{
+			name: "FailChmodOnce",
+			inject: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChmodOnce("file.txt", mockfs.ErrPermission)
+			},
+			op: func(m *mockfs.MockFS) error { return m.Chmod("file.txt", 0o600) },
+		},
+		{
+			name: "FailChtimesOnce",
+			inject: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChtimesOnce("file.txt", mockfs.ErrPermission)
+			},
+			op: func(m *mockfs.MockFS) error { return m.Chtimes("file.txt", time.Time{}, time.Now()) },
+		},
+		{
+			name: "FailChownOnce",
+			inject: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "", 0o644)
+				m.FailChownOnce("file.txt", mockfs.ErrPermission)
+			},
+			op: func(m *mockfs.MockFS) error { return m.Chown("file.txt", 1000, 1000) },
+		},
+		{
+			name: "FailTruncateOnce",
+			inject: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "data", 0o644)
+				m.FailTruncateOnce("file.txt", mockfs.ErrPermission)
+			},
+			op: func(m *mockfs.MockFS) error { return m.Truncate("file.txt", 0) },
+		},
+		{
+			name: "FailSyncOnce",
+			inject: func(m *mockfs.MockFS) {
+				_ = m.AddFile("file.txt", "data", 0o644)
+				m.FailSyncOnce("file.txt", mockfs.ErrPermission)
+			},
+			op: syncFile("file.txt"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -42,6 +42,11 @@ func (m *ExactMatcher) Matches(filepath string) bool {
 	return filepath == m.path
 }
 
+// String returns the matched path.
+func (m *ExactMatcher) String() string {
+	return m.path
+}
+
 // CloneForSub returns a matcher adjusted for a sub-namespace (used by SubFS).
 // It converts a parent-path matcher into a matcher that matches the relative path
 // inside the sub-file system. If the original path is not under prefix, it returns
@@ -86,6 +91,11 @@ func (m *RegexpMatcher) Matches(filepath string) bool {
 	return m.re.MatchString(filepath)
 }
 
+// String returns the regular expression between slashes, such as "/^logs/".
+func (m *RegexpMatcher) String() string {
+	return "/" + m.re.String() + "/"
+}
+
 // CloneForSub returns a matcher adjusted for a sub-namespace (used by SubFS).
 // The returned matcher will test the original regexp against the full parent path
 // assembled from the prefix and the candidate path inside the sub filesystem.
@@ -152,6 +162,11 @@ func (m *GlobMatcher) Matches(candidatePath string) bool {
 	return matched
 }
 
+// String returns the glob pattern.
+func (m *GlobMatcher) String() string {
+	return m.pattern
+}
+
 // CloneForSub returns a matcher adjusted for a sub-namespace (used by SubFS).
 // The returned matcher will test the original glob pattern against the full parent path
 // assembled from the prefix and the candidate path inside the sub filesystem.
@@ -216,6 +231,11 @@ func (m *WildcardMatcher) Matches(filepath string) bool {
 	return true
 }
 
+// String returns "*".
+func (m *WildcardMatcher) String() string {
+	return "*"
+}
+
 // CloneForSub essentially returns the same matcher (used by SubFS).
 func (m *WildcardMatcher) CloneForSub(prefix string) PathMatcher {
 	return m
@@ -1127,6 +1127,31 @@ func TestGlobParentMatcher_CloneForSub_EmptyPrefix(t *testing.T) {
 
 // --- Benchmarks ---
 
+func TestMatchers_String(t *testing.T) {
+	t.Parallel()
+
+	re, err := mockfs.NewRegexpMatcher(`^logs/.*\.log$`)
+	requireNoError(t, err)
+	glob, err := mockfs.NewGlobMatcher("*.tmp")
+	requireNoError(t, err)
+
+	tests := []struct {
+		matcher fmt.Stringer
+		want    string
+	}{
+		{mockfs.NewExactMatcher("dir/file.txt"), "dir/file.txt"},
+		{re, `/^logs/.*\.log$/`},
+		{glob, "*.tmp"},
+		{mockfs.NewWildcardMatcher(), "*"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.matcher.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
 // BenchmarkMatchers benchmarks the performance of different matchers on a given path.
 func BenchmarkMatchers(b *testing.B) {
 	path := "dir/subdir/file.txt"
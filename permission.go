@@ -0,0 +1,214 @@
+package mockfs
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Access bits checked against an inode's permission bits, as in access(2).
+const (
+	permRead  FileMode = 0o4
+	permWrite FileMode = 0o2
+	permExec  FileMode = 0o1
+)
+
+// WithIdentity enables permission checks (see WithPermissionChecks) and sets
+// the simulated user the checks are made for. The first gid is the primary
+// group; the others are supplementary groups.
+//
+// Entries present when NewMockFS returns, and entries created afterwards,
+// are owned by uid and the primary group (0 if no gids are given).
+// Use Chown to hand entries to other owners.
+func WithIdentity(uid int, gids ...int) FsOption {
+	return func(m *MockFS) error {
+		m.uid = uid
+		m.gids = slices.Clone(gids)
+		m.permChecks = true
+		return nil
+	}
+}
+
+// WithPermissionChecks enables enforcement of FileMode permission bits.
+// Without WithIdentity, the simulated user is uid 0 with group 0, which also
+// owns every entry by default, so the owner bits apply.
+//
+// When enabled, operations fail with ErrPermission as on a real filesystem:
+//   - Every path lookup needs search (x) permission on each ancestor directory.
+//   - Open, OpenFile and ReadFile need read permission for reading and write
+//     permission for writing. A handle returned by Open is read-only unless
+//     the file is also writable.
+//   - ReadDir needs read permission on the directory.
+//   - Creating an entry (Mkdir, MkdirAll, OpenFile with os.O_CREATE,
+//     WriteFile, Symlink, Link) needs write permission on its parent.
+//   - Remove, RemoveAll and Rename need write permission on the parent of
+//     every entry removed or moved; in a sticky directory the user must also
+//     own the entry or the directory. Moving a directory to a new parent
+//     needs write permission on the directory itself.
+//   - WriteFile and Truncate on an existing file need write permission on it.
+//   - Chmod and Chtimes require the user to own the file.
+//
+// There is no superuser: uid 0 is checked like any other user. Chown is not
+// restricted, so tests can still arrange ownership. Builder options, AddFile,
+// AddDir and RemoveEntry are fixture helpers and are never checked.
+func WithPermissionChecks() FsOption {
+	return func(m *MockFS) error {
+		m.permChecks = true
+		return nil
+	}
+}
+
+// primaryGid returns the group ID that owns newly created entries.
+func (m *MockFS) primaryGid() int {
+	if len(m.gids) == 0 {
+		return 0
+	}
+	return m.gids[0]
+}
+
+// canAccess reports whether the simulated user has all the want access bits
+// on n. Only the owner bits apply to the owner and only the group bits to
+// group members, as in Unix. Always true when permission checks are disabled.
+func (m *MockFS) canAccess(n *inode, want FileMode) bool {
+	if !m.permChecks {
+		return true
+	}
+
+	perm := n.Mode.Perm()
+	switch {
+	case n.uid == m.uid:
+		perm >>= 6
+	case slices.Contains(m.gids, n.gid):
+		perm >>= 3
+	}
+
+	return perm&want == want
+}
+
+// owns reports whether the simulated user owns n.
+// Always true when permission checks are disabled.
+func (m *MockFS) owns(n *inode) bool {
+	return !m.permChecks || n.uid == m.uid
+}
+
+// checkAccess returns ErrPermission unless the user has the want access bits on n.
+func (m *MockFS) checkAccess(opName, name string, n *inode, want FileMode) error {
+	if !m.canAccess(n, want) {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrPermission}
+	}
+	return nil
+}
+
+// checkSearch verifies search permission on every existing ancestor
+// directory of the resolved path, from the root down, stopping at the first
+// missing one. Caller must hold the mutex.
+func (m *MockFS) checkSearch(opName, name, resolved string) error {
+	if !m.permChecks || resolved == "." {
+		return nil
+	}
+
+	// The ancestors are the root and every prefix of resolved ending at a slash
+	ancestors := []string{"."}
+	for i := range len(resolved) {
+		if resolved[i] == '/' {
+			ancestors = append(ancestors, resolved[:i])
+		}
+	}
+
+	for _, dir := range ancestors {
+		node, exists := m.files[dir]
+		if !exists || !node.Mode.IsDir() {
+			return nil
+		}
+		if err := m.checkAccess(opName, name, node, permExec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCreate verifies write and search permission on the parent directory
+// of an entry about to be created at the resolved path.
+// Caller must hold the mutex.
+func (m *MockFS) checkCreate(opName, name, resolved string) error {
+	parent, exists := m.files[path.Dir(resolved)]
+	if !exists {
+		return nil // reported as ErrNotExist by the caller's parent check
+	}
+	return m.checkAccess(opName, name, parent, permWrite|permExec)
+}
+
+// checkDelete verifies the user may remove the entry n at the resolved path
+// from its parent directory, honouring the sticky bit.
+// Caller must hold the mutex.
+func (m *MockFS) checkDelete(opName, name, resolved string, n *inode) error {
+	parent, exists := m.files[path.Dir(resolved)]
+	if !exists {
+		return nil
+	}
+	if err := m.checkAccess(opName, name, parent, permWrite|permExec); err != nil {
+		return err
+	}
+	if parent.Mode&fs.ModeSticky != 0 && !m.owns(n) && !m.owns(parent) {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrPermission}
+	}
+	return nil
+}
+
+// checkCreateAll verifies that the first missing directory of the resolved
+// path may be created; the rest are created inside it.
+// Caller must hold the mutex.
+func (m *MockFS) checkCreateAll(opName, name, resolved string) error {
+	for i := 0; i <= len(resolved); i++ {
+		if i < len(resolved) && resolved[i] != '/' {
+			continue
+		}
+		if _, exists := m.files[resolved[:i]]; !exists {
+			return m.checkCreate(opName, name, resolved[:i])
+		}
+	}
+	return nil
+}
+
+// checkDeleteTree verifies that the entry at cleanPath and everything below
+// it may be removed. Caller must hold the mutex.
+func (m *MockFS) checkDeleteTree(opName, name, cleanPath string) error {
+	if !m.permChecks {
+		return nil
+	}
+
+	prefix := cleanPath + "/"
+	for p, n := range m.files {
+		if p != cleanPath && !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		if err := m.checkDelete(opName, name, p, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRename verifies that the entry n may be moved from cleanOld to
+// cleanNew, replacing any existing destination. Caller must hold the mutex.
+func (m *MockFS) checkRename(newpath, cleanOld, cleanNew string, n *inode) error {
+	if err := m.checkDelete("Rename", newpath, cleanOld, n); err != nil {
+		return err
+	}
+	if err := m.checkCreate("Rename", newpath, cleanNew); err != nil {
+		return err
+	}
+	if dst, exists := m.files[cleanNew]; exists {
+		if err := m.checkDelete("Rename", newpath, cleanNew, dst); err != nil {
+			return err
+		}
+	}
+
+	// A directory moving to a new parent rewrites its ".." entry
+	if n.Mode.IsDir() && path.Dir(cleanOld) != path.Dir(cleanNew) {
+		return m.checkAccess("Rename", newpath, n, permWrite)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package mockfs_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// newPermissionFS returns a filesystem checked for uid 1000 in groups 100 and 200.
+func newPermissionFS(tb testing.TB) *mockfs.MockFS {
+	tb.Helper()
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.WithIdentity(1000, 100, 200),
+		mockfs.WithCreateIfMissing(true),
+		mockfs.File("public.txt", "public", 0o644),
+		mockfs.File("secret.txt", "secret", 0o000),
+		mockfs.File("readable.txt", "readable", 0o444),
+		mockfs.File("group.txt", "group", 0o640),
+		mockfs.File("other.txt", "other", 0o604),
+		mockfs.Dir("locked", mockfs.FileMode(0o000), mockfs.File("inner.txt", "inner")),
+		mockfs.Dir("listonly", mockfs.FileMode(0o100), mockfs.File("inner.txt", "inner")),
+		mockfs.Dir("readonly", mockfs.FileMode(0o555), mockfs.File("keep.txt", "keep"), mockfs.Dir("sub")),
+		mockfs.Dir("shared", mockfs.FileMode(0o777), mockfs.File("theirs.txt", "theirs"), mockfs.File("mine.txt", "mine")),
+		mockfs.Dir("foreign", mockfs.Dir("sub")),
+	)
+
+	// Hand some entries to other users; Chmod needs ownership, so it comes first
+	requireNoError(tb, mfs.Chown("group.txt", 2000, 100))
+	requireNoError(tb, mfs.Chown("other.txt", 2000, 2000))
+	requireNoError(tb, mfs.Chmod("shared", fs.ModeSticky|0o777))
+	requireNoError(tb, mfs.Chown("shared", 2000, 2000))
+	requireNoError(tb, mfs.Chown("shared/theirs.txt", 2000, 2000))
+	requireNoError(tb, mfs.Chmod("foreign/sub", 0o555))
+	requireNoError(tb, mfs.Chown("foreign/sub", 2000, 2000))
+
+	return mfs
+}
+
+func TestMockFS_PermissionChecks(t *testing.T) {
+	t.Parallel()
+
+	readFile := func(name string) func(*mockfs.MockFS) error {
+		return func(m *mockfs.MockFS) error {
+			_, err := m.ReadFile(name)
+			return err
+		}
+	}
+	openFile := func(name string, flag int) func(*mockfs.MockFS) error {
+		return func(m *mockfs.MockFS) error {
+			f, err := m.OpenFile(name, flag, 0o644)
+			if err != nil {
+				return err
+			}
+			return f.Close()
+		}
+	}
+	readDir := func(name string) func(*mockfs.MockFS) error {
+		return func(m *mockfs.MockFS) error {
+			_, err := m.ReadDir(name)
+			return err
+		}
+	}
+	writeFile := func(name string) func(*mockfs.MockFS) error {
+		return func(m *mockfs.MockFS) error { return m.WriteFile(name, []byte("x"), 0o644) }
+	}
+
+	tests := []struct {
+		name    string
+		op      func(*mockfs.MockFS) error
+		wantErr error
+	}{
+		{name: "read own file", op: readFile("public.txt")},
+		{name: "read mode 000 file", op: readFile("secret.txt"), wantErr: mockfs.ErrPermission},
+		{name: "read via group bits", op: readFile("group.txt")},
+		{name: "read via other bits", op: readFile("other.txt")},
+		{name: "read without search on parent", op: readFile("locked/inner.txt"), wantErr: mockfs.ErrPermission},
+		{name: "read with search-only parent", op: readFile("listonly/inner.txt")},
+		{name: "open read-only file for writing", op: openFile("readable.txt", os.O_WRONLY), wantErr: mockfs.ErrPermission},
+		{name: "open group file read-write", op: openFile("group.txt", os.O_RDWR), wantErr: mockfs.ErrPermission},
+		{name: "create in read-only directory", op: openFile("readonly/new.txt", os.O_WRONLY|os.O_CREATE), wantErr: mockfs.ErrPermission},
+		{name: "create in own directory", op: openFile("new.txt", os.O_WRONLY|os.O_CREATE)},
+		{name: "list readable directory", op: readDir("readonly")},
+		{name: "list search-only directory", op: readDir("listonly"), wantErr: mockfs.ErrPermission},
+		{name: "stat without search on parent", op: func(m *mockfs.MockFS) error {
+			_, err := m.Stat("locked/inner.txt")
+			return err
+		}, wantErr: mockfs.ErrPermission},
+		{name: "mkdir in own directory", op: func(m *mockfs.MockFS) error { return m.Mkdir("newdir", 0o755) }},
+		{name: "mkdir in read-only directory", op: func(m *mockfs.MockFS) error { return m.Mkdir("readonly/newdir", 0o755) }, wantErr: mockfs.ErrPermission},
+		{name: "mkdirall below read-only directory", op: func(m *mockfs.MockFS) error { return m.MkdirAll("readonly/a/b", 0o755) }, wantErr: mockfs.ErrPermission},
+		{name: "mkdirall of existing path", op: func(m *mockfs.MockFS) error { return m.MkdirAll("readonly/sub", 0o755) }},
+		{name: "remove from own directory", op: func(m *mockfs.MockFS) error { return m.Remove("secret.txt") }},
+		{name: "remove from read-only directory", op: func(m *mockfs.MockFS) error { return m.Remove("readonly/keep.txt") }, wantErr: mockfs.ErrPermission},
+		{name: "remove own file from sticky directory", op: func(m *mockfs.MockFS) error { return m.Remove("shared/mine.txt") }},
+		{name: "remove foreign file from sticky directory", op: func(m *mockfs.MockFS) error { return m.Remove("shared/theirs.txt") }, wantErr: mockfs.ErrPermission},
+		{name: "removeall over read-only directory", op: func(m *mockfs.MockFS) error { return m.RemoveAll("readonly") }, wantErr: mockfs.ErrPermission},
+		{name: "removeall of unwritable directory", op: func(m *mockfs.MockFS) error { return m.RemoveAll("listonly") }, wantErr: mockfs.ErrPermission},
+		{name: "rename within own directory", op: func(m *mockfs.MockFS) error { return m.Rename("public.txt", "renamed.txt") }},
+		{name: "rename out of read-only directory", op: func(m *mockfs.MockFS) error { return m.Rename("readonly/keep.txt", "keep.txt") }, wantErr: mockfs.ErrPermission},
+		{name: "rename into read-only directory", op: func(m *mockfs.MockFS) error { return m.Rename("public.txt", "readonly/public.txt") }, wantErr: mockfs.ErrPermission},
+		{name: "move unwritable directory to new parent", op: func(m *mockfs.MockFS) error { return m.Rename("foreign/sub", "sub") }, wantErr: mockfs.ErrPermission},
+		{name: "write own file", op: writeFile("public.txt")},
+		{name: "write read-only file", op: writeFile("readable.txt"), wantErr: mockfs.ErrPermission},
+		{name: "write new file in read-only directory", op: writeFile("readonly/new.txt"), wantErr: mockfs.ErrPermission},
+		{name: "chmod own file", op: func(m *mockfs.MockFS) error { return m.Chmod("secret.txt", 0o600) }},
+		{name: "chmod foreign file", op: func(m *mockfs.MockFS) error { return m.Chmod("other.txt", 0o600) }, wantErr: mockfs.ErrPermission},
+		{name: "chtimes foreign file", op: func(m *mockfs.MockFS) error {
+			return m.Chtimes("other.txt", time.Time{}, time.Now())
+		}, wantErr: mockfs.ErrPermission},
+		{name: "chown foreign file", op: func(m *mockfs.MockFS) error { return m.Chown("other.txt", 1000, 100) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.op(newPermissionFS(t))
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+				return
+			}
+			assertNoError(t, err)
+		})
+	}
+}
+
+func TestMockFS_PermissionChecks_OpenHandle(t *testing.T) {
+	t.Parallel()
+
+	mfs := newPermissionFS(t)
+
+	// Open hands out a read-only handle for a file the user cannot write
+	f, err := mfs.OpenMockFile("readable.txt")
+	requireNoError(t, err)
+	_, err = f.Write([]byte("x"))
+	assertError(t, err, mockfs.ErrPermission)
+	requireNoError(t, f.Close())
+
+	f, err = mfs.OpenMockFile("public.txt")
+	requireNoError(t, err)
+	_, err = f.Write([]byte("x"))
+	assertNoError(t, err)
+	requireNoError(t, f.Close())
+}
+
+func TestMockFS_PermissionChecks_Ownership(t *testing.T) {
+	t.Parallel()
+
+	mfs := newPermissionFS(t)
+	requireNoError(t, mfs.Mkdir("created", 0o700))
+
+	for _, name := range []string{".", "public.txt", "created"} {
+		if ino := inodeOf(t, mfs, name); ino.Uid != 1000 || ino.Gid != 100 {
+			t.Errorf("%s owner = %d:%d, want 1000:100", name, ino.Uid, ino.Gid)
+		}
+	}
+
+	// Permission checks alone check for uid 0, who owns the fixture
+	plain := mockfs.MustNewMockFS(mockfs.WithPermissionChecks(), mockfs.File("secret.txt", "s", 0o000))
+	_, err := plain.ReadFile("secret.txt")
+	assertError(t, err, mockfs.ErrPermission)
+
+	// Without permission checks, modes are metadata only
+	off := mockfs.MustNewMockFS(mockfs.File("secret.txt", "s", 0o000))
+	_, err = off.ReadFile("secret.txt")
+	assertNoError(t, err)
+}
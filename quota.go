@@ -0,0 +1,290 @@
+package mockfs
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Usage is the space used in a MockFS. See WithCapacity and WithInodeLimit.
+type Usage struct {
+	Bytes  int64 // Size of the regular files, including removed files that are still open.
+	Inodes int   // Files, directories, and symbolic links, including the root and removed files that are still open.
+}
+
+// WithCapacity limits the total size of the regular files in the filesystem
+// to bytes, like the size of a disk. Write, WriteAt, and WriteFile store the
+// part of the data that fits and return a *PathError wrapping ErrDiskFull,
+// and Truncate fails with that error when it would grow a file past the
+// limit. Removing a file frees its space once its last handle is closed.
+// The entries added by builder options are not checked.
+// Returns an error if bytes is negative.
+func WithCapacity(bytes int64) FsOption {
+	return func(m *MockFS) error {
+		if bytes < 0 {
+			return fmt.Errorf("WithCapacity: negative capacity %d", bytes)
+		}
+		m.limits.capacity, m.limits.capped = bytes, true
+		return nil
+	}
+}
+
+// WithInodeLimit limits the number of files, directories, and symbolic
+// links in the filesystem, including the root, to n. Once it is reached,
+// operations that create an entry (Mkdir, MkdirAll, OpenFile with
+// os.O_CREATE, WriteFile, Symlink, AddFile, AddDir) fail with a *PathError
+// wrapping ErrDiskFull. The entries added by builder options are not checked.
+// Returns an error if n is not positive.
+func WithInodeLimit(n int) FsOption {
+	return func(m *MockFS) error {
+		if n <= 0 {
+			return fmt.Errorf("WithInodeLimit: non-positive limit %d", n)
+		}
+		m.limits.inodes = n
+		return nil
+	}
+}
+
+// WithQuota limits the total size of the regular files below dir to bytes,
+// as a directory or project quota does. It is enforced like WithCapacity,
+// and also by Rename, which fails with ErrDiskFull when moving an entry into
+// dir would exceed the quota. A file with hard links inside and outside dir
+// counts toward the quota. dir does not need to exist yet.
+// Returns an error if dir is not a valid path or bytes is negative.
+func WithQuota(dir string, bytes int64) FsOption {
+	return func(m *MockFS) error {
+		if !fs.ValidPath(dir) {
+			return fmt.Errorf("WithQuota: invalid directory %q", dir)
+		}
+		if bytes < 0 {
+			return fmt.Errorf("WithQuota: negative quota %d for %s", bytes, dir)
+		}
+		if m.limits.quotas == nil {
+			m.limits.quotas = make(map[string]int64)
+		}
+		m.limits.quotas[path.Clean(dir)] = bytes
+		return nil
+	}
+}
+
+// Usage reports the space used in the filesystem.
+func (m *MockFS) Usage() Usage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.usage()
+}
+
+// spaceLimits are the limits set by WithCapacity, WithInodeLimit, and WithQuota.
+type spaceLimits struct {
+	capacity int64            // Bytes of regular file data, if capped.
+	capped   bool             // Whether capacity applies.
+	inodes   int              // Number of inodes; 0 for no limit.
+	quotas   map[string]int64 // Bytes of regular file data below a directory, by cleaned path.
+}
+
+// forSub returns the limits for a sub-filesystem rooted at dir: the same
+// capacity and inode limit, and the quotas on directories within dir.
+func (l spaceLimits) forSub(dir string) spaceLimits {
+	sub := l
+	sub.quotas = nil
+	for q, bytes := range l.quotas {
+		rel, ok := relativeTo(q, dir)
+		if !ok {
+			continue
+		}
+		if sub.quotas == nil {
+			sub.quotas = make(map[string]int64)
+		}
+		sub.quotas[rel] = bytes
+	}
+
+	return sub
+}
+
+// limitsData reports whether the size of file data is limited.
+func (m *MockFS) limitsData() bool {
+	return m.limits.capped || len(m.limits.quotas) > 0
+}
+
+// usage returns the space used. Caller must hold the mutex.
+func (m *MockFS) usage() Usage {
+	u := Usage{Inodes: len(m.inodes)}
+	for _, n := range m.inodes {
+		if n.Mode.IsRegular() {
+			u.Bytes += n.size()
+		}
+	}
+
+	return u
+}
+
+// dirBytes returns the size of the regular files below dir, counting each
+// inode once. Caller must hold the mutex.
+func (m *MockFS) dirBytes(dir string) int64 {
+	seen := make(map[*inode]bool)
+	var bytes int64
+	for p, n := range m.files {
+		if _, ok := relativeTo(p, dir); ok && n.Mode.IsRegular() && !seen[n] {
+			seen[n] = true
+			bytes += n.size()
+		}
+	}
+
+	return bytes
+}
+
+// pathsOf returns the paths linked to n, for quota checks; nil without
+// quotas. Caller must hold the mutex.
+func (m *MockFS) pathsOf(n *inode) []string {
+	if len(m.limits.quotas) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for p, node := range m.files {
+		if node == n {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// room returns how many bytes the files at paths may grow by within the
+// capacity and the quotas of the directories above them, and whether any
+// of these limits applies. Caller must hold the mutex.
+func (m *MockFS) room(paths []string) (int64, bool) {
+	room, limited := int64(math.MaxInt64), false
+	if m.limits.capped {
+		room, limited = m.limits.capacity-m.usage().Bytes, true
+	}
+
+	for _, dir := range slices.Sorted(maps.Keys(m.limits.quotas)) {
+		covered := slices.ContainsFunc(paths, func(p string) bool {
+			_, ok := relativeTo(p, dir)
+			return ok
+		})
+		if covered {
+			room, limited = min(room, m.limits.quotas[dir]-m.dirBytes(dir)), true
+		}
+	}
+
+	return room, limited
+}
+
+// fitWrite returns how many of the n bytes written at off into the data of
+// node, linked at paths, fit within the capacity and quotas. replace reports
+// whether the write replaces the whole content; a nil node is a file about
+// to be created. Caller must hold the write lock.
+func (m *MockFS) fitWrite(node *inode, paths []string, off int64, n int, replace bool) int {
+	room, limited := m.room(paths)
+	if !limited {
+		return n
+	}
+
+	var size int64
+	if node != nil {
+		size = node.size()
+	}
+	if replace {
+		size, room = 0, room+size
+	}
+
+	end := off + int64(n)
+	if end <= size || end-size <= room {
+		return n
+	}
+	//nolint:gosec // the result lies in [0, n], so it fits in an int.
+	return int(min(max(size+room-off, 0), int64(n)))
+}
+
+// checkGrowth returns ErrDiskFull for an operation on name that grows the
+// files at paths by grow bytes past the capacity or a quota.
+// Caller must hold the mutex.
+func (m *MockFS) checkGrowth(opName, name string, grow int64, paths ...string) error {
+	if grow <= 0 {
+		return nil
+	}
+	if room, limited := m.room(paths); limited && grow > room {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrDiskFull}
+	}
+	return nil
+}
+
+// checkAddFile returns ErrDiskFull if AddFile cannot replace existing, the
+// entry at cleanPath or nil, with a file of size bytes.
+// Caller must hold the mutex.
+func (m *MockFS) checkAddFile(name, cleanPath string, existing *inode, size int64) error {
+	var freed int64
+	switch {
+	case existing == nil:
+		if err := m.checkInodeLimit("AddFile", name); err != nil {
+			return err
+		}
+	case existing.Mode.IsRegular() && existing.nlink <= 1 && existing.nopen == 0:
+		freed = existing.size()
+	}
+
+	return m.checkGrowth("AddFile", name, size-freed, cleanPath)
+}
+
+// checkInodeLimit returns ErrDiskFull for creating name when the inode
+// limit is reached. Caller must hold the mutex.
+func (m *MockFS) checkInodeLimit(opName, name string) error {
+	if m.limits.inodes > 0 && len(m.inodes) >= m.limits.inodes {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrDiskFull}
+	}
+	return nil
+}
+
+// checkMove returns ErrDiskFull for renaming cleanOld to cleanNew when the
+// entry would push a directory it moves into over its quota. The entry at
+// cleanNew, if any, is replaced. Caller must hold the mutex.
+func (m *MockFS) checkMove(newpath, cleanOld, cleanNew string) error {
+	for _, dir := range slices.Sorted(maps.Keys(m.limits.quotas)) {
+		_, from := relativeTo(cleanOld, dir)
+		_, to := relativeTo(cleanNew, dir)
+		if from || !to {
+			continue
+		}
+
+		moved := m.dirBytes(cleanOld)
+		if moved-m.dirBytes(cleanNew) > m.limits.quotas[dir]-m.dirBytes(dir) {
+			return &fs.PathError{Op: "Rename", Path: newpath, Err: ErrDiskFull}
+		}
+	}
+	return nil
+}
+
+// relativeTo returns p relative to dir, and whether p is dir or below it.
+func relativeTo(p, dir string) (string, bool) {
+	switch {
+	case dir == ".":
+		return p, true
+	case p == dir:
+		return ".", true
+	}
+
+	rel, ok := strings.CutPrefix(p, dir+"/")
+	return rel, ok
+}
+
+// fitWrite returns how many of the n bytes written at off fit within the
+// capacity and quotas of the owning filesystem; replace reports whether the
+// write replaces the whole content. Caller must hold f.mu and the write
+// lock from lockInode.
+func (f *MockFile) fitWrite(off int64, n int, replace bool) int {
+	if f.fsys == nil || !f.fsys.limitsData() {
+		return n
+	}
+	return f.fsys.fitWrite(f.node, f.fsys.pathsOf(f.node), off, n, replace)
+}
+
+// diskFull returns the error for a write on the handle that runs out of space.
+func (f *MockFile) diskFull() error {
+	return &fs.PathError{Op: OpWrite.String(), Path: f.name, Err: ErrDiskFull}
+}
@@ -0,0 +1,265 @@
+package mockfs_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// assertUsage fails the test if the filesystem does not use the given space.
+func assertUsage(t *testing.T, mfs *mockfs.MockFS, bytes int64, inodes int) {
+	t.Helper()
+
+	if u := mfs.Usage(); u.Bytes != bytes || u.Inodes != inodes {
+		t.Errorf("Usage() = %+v, want %d bytes in %d inodes", u, bytes, inodes)
+	}
+}
+
+func TestMockFS_Capacity_Writes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []mockfs.FsOption
+		write   func(m *mockfs.MockFS) (int, error)
+		wantN   int
+		wantErr error
+		content string
+	}{
+		{
+			name: "write straddling the limit",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenFile("f", os.O_WRONLY|os.O_APPEND, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				return f.Write([]byte("0123456789"))
+			},
+			wantN:   6,
+			wantErr: mockfs.ErrDiskFull,
+			content: "abcd012345",
+		},
+		{
+			name: "write that fits",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenFile("f", os.O_WRONLY|os.O_APPEND, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				return f.Write([]byte("0123"))
+			},
+			wantN:   4,
+			content: "abcd0123",
+		},
+		{
+			name: "write at with a gap",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenFile("f", os.O_WRONLY, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				return f.WriteAt([]byte("xyz"), 8)
+			},
+			wantN:   2,
+			wantErr: mockfs.ErrDiskFull,
+			content: "abcd\x00\x00\x00\x00xy",
+		},
+		{
+			name: "write at past the limit",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenFile("f", os.O_WRONLY, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				return f.WriteAt([]byte("x"), 12)
+			},
+			wantErr: mockfs.ErrDiskFull,
+			content: "abcd",
+		},
+		{
+			name: "overwriting within the file",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenFile("f", os.O_WRONLY, 0)
+				requireNoError(t, err)
+				defer f.Close()
+				return f.WriteAt([]byte("AB"), 1)
+			},
+			wantN:   2,
+			content: "aABd",
+		},
+		{
+			name: "write file replacing the content",
+			write: func(m *mockfs.MockFS) (int, error) {
+				return 0, m.WriteFile("f", []byte("0123456789abc"), 0o644)
+			},
+			wantErr: mockfs.ErrDiskFull,
+			content: "0123456789",
+		},
+		{
+			name: "write file appending",
+			opts: []mockfs.FsOption{mockfs.WithAppend()},
+			write: func(m *mockfs.MockFS) (int, error) {
+				return 0, m.WriteFile("f", []byte("0123456789"), 0o644)
+			},
+			wantErr: mockfs.ErrDiskFull,
+			content: "abcd012345",
+		},
+		{
+			name: "overwrite mode handle",
+			write: func(m *mockfs.MockFS) (int, error) {
+				f, err := m.OpenMockFile("f")
+				requireNoError(t, err)
+				defer f.Close()
+				return f.Write([]byte("0123456789abc"))
+			},
+			wantN:   10,
+			wantErr: mockfs.ErrDiskFull,
+			content: "0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]mockfs.FsOption{mockfs.WithCapacity(10), mockfs.File("f", "abcd")}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			n, err := tt.write(mfs)
+			assertError(t, err, tt.wantErr)
+			if tt.wantN != 0 && n != tt.wantN {
+				t.Errorf("n = %d, want %d", n, tt.wantN)
+			}
+			if tt.content != "" {
+				if got := string(mustReadFile(t, mfs, "f")); got != tt.content {
+					t.Errorf("content = %q, want %q", got, tt.content)
+				}
+			}
+			if u := mfs.Usage(); u.Bytes > 10 {
+				t.Errorf("Usage() = %+v, over the capacity", u)
+			}
+		})
+	}
+}
+
+func TestMockFS_Capacity_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.WithCapacity(10),
+		mockfs.WithCreateIfMissing(true),
+		mockfs.File("a", "0123456"),
+		mockfs.Dir("d"),
+	)
+	assertUsage(t, mfs, 7, 3)
+
+	err := mfs.WriteFile("b", []byte("xyzw"), 0o644)
+	assertError(t, err, mockfs.ErrDiskFull)
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "b" {
+		t.Errorf("err = %v, want a *PathError for b", err)
+	}
+	assertUsage(t, mfs, 10, 4)
+
+	// Growing a file is refused; shrinking is not
+	assertError(t, mfs.Truncate("b", 4), mockfs.ErrDiskFull)
+	requireNoError(t, mfs.Truncate("b", 0))
+	f, err := mfs.OpenMockFile("a")
+	requireNoError(t, err)
+	assertError(t, f.Truncate(11), mockfs.ErrDiskFull)
+	requireNoError(t, f.Truncate(10))
+
+	// A removed file keeps its space until its last handle is closed
+	requireNoError(t, mfs.Remove("a"))
+	assertUsage(t, mfs, 10, 4)
+	requireNoError(t, f.Close())
+	assertUsage(t, mfs, 0, 3)
+	requireNoError(t, mfs.WriteFile("b", []byte("0123456789"), 0o644))
+
+	// AddFile is all or nothing
+	assertError(t, mfs.AddFile("d/c", "x"), mockfs.ErrDiskFull)
+	requireNoError(t, mfs.AddFile("b", "replaced"))
+}
+
+func TestMockFS_InodeLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		create func(m *mockfs.MockFS) error
+	}{
+		{name: "Mkdir", create: func(m *mockfs.MockFS) error { return m.Mkdir("new", 0o755) }},
+		{name: "MkdirAll", create: func(m *mockfs.MockFS) error { return m.MkdirAll("new", 0o755) }},
+		{name: "OpenFile", create: func(m *mockfs.MockFS) error {
+			_, err := m.OpenFile("new", os.O_RDWR|os.O_CREATE, 0o644)
+			return err
+		}},
+		{name: "WriteFile", create: func(m *mockfs.MockFS) error { return m.WriteFile("new", nil, 0o644) }},
+		{name: "Symlink", create: func(m *mockfs.MockFS) error { return m.Symlink("a", "new") }},
+		{name: "AddFile", create: func(m *mockfs.MockFS) error { return m.AddFile("new", "") }},
+		{name: "AddDir", create: func(m *mockfs.MockFS) error { return m.AddDir("new") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(
+				mockfs.WithInodeLimit(3),
+				mockfs.WithCreateIfMissing(true),
+				mockfs.File("a", "data"),
+				mockfs.File("b", "data"),
+			)
+			assertError(t, tt.create(mfs), mockfs.ErrDiskFull)
+			if _, err := mfs.Lstat("new"); err == nil {
+				t.Error("new was created")
+			}
+
+			requireNoError(t, mfs.Remove("b"))
+			requireNoError(t, tt.create(mfs))
+			if u := mfs.Usage(); u.Inodes > 3 {
+				t.Errorf("Usage() = %+v, over the inode limit", u)
+			}
+		})
+	}
+}
+
+func TestMockFS_Quota(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.WithQuota("logs", 8),
+		mockfs.File("big", "0123456789"),
+		mockfs.File("small", "01"),
+		mockfs.Dir("logs", mockfs.File("old", "0123")),
+		mockfs.Dir("tmp"),
+	)
+
+	// Writes below the quota directory are cut short; others are not limited
+	err := mfs.WriteFile("logs/old", []byte("0123456789"), 0o644)
+	assertError(t, err, mockfs.ErrDiskFull)
+	if got := string(mustReadFile(t, mfs, "logs/old")); got != "01234567" {
+		t.Errorf("logs/old = %q, want the first 8 bytes", got)
+	}
+	requireNoError(t, mfs.WriteFile("big", []byte("0123456789abcdef"), 0o644))
+
+	// A hard link into the quota directory makes the file count there
+	requireNoError(t, mfs.Link("small", "logs/small"))
+	f, err := mfs.OpenFile("small", os.O_WRONLY|os.O_APPEND, 0)
+	requireNoError(t, err)
+	_, err = f.Write([]byte("x"))
+	assertError(t, err, mockfs.ErrDiskFull)
+	requireNoError(t, f.Close())
+
+	// Moving an entry in counts its data; moving within the directory does not
+	assertError(t, mfs.Rename("big", "logs/big"), mockfs.ErrDiskFull)
+	requireNoError(t, mfs.Rename("logs/old", "logs/older"))
+	requireNoError(t, mfs.Remove("logs/small"))
+	requireNoError(t, mfs.Rename("logs/older", "tmp/older"))
+	requireNoError(t, mfs.Rename("small", "logs/small"))
+
+	// A sub-filesystem keeps the quota below its root
+	subFS, err := mfs.Sub("logs")
+	requireNoError(t, err)
+	err = subFS.(*mockfs.MockFS).WriteFile("small", []byte("0123456789"), 0o644)
+	assertError(t, err, mockfs.ErrDiskFull)
+}
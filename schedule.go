@@ -0,0 +1,301 @@
+package mockfs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scheduleErrors are the sentinels a decoded FaultSchedule draws from, by index.
+var scheduleErrors = [...]error{
+	ErrDiskFull,
+	ErrTimeout,
+	ErrCorrupted,
+	ErrTooManyHandles,
+	ErrPermission,
+	ErrNotExist,
+	ErrExist,
+	ErrInvalid,
+	ErrClosed,
+	ErrUnexpectedEOF,
+	ErrNotDir,
+	ErrIsDir,
+	ErrNotEmpty,
+}
+
+// scheduleDelays are the latencies a decoded FaultSchedule draws from, by index.
+var scheduleDelays = [...]time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+}
+
+// FaultStep is what a FaultSchedule does to one call.
+type FaultStep struct {
+	Call  int           // Number of the call, counted from 1 over every call that reaches the injector.
+	Delay time.Duration // Latency added to the call.
+	Err   error         // Error the call fails with; nil to let it succeed.
+	Limit int           // Bytes a Read or Write transfers before Err, at most those requested; -1 to fail it whole.
+}
+
+// String returns a one-line description of the step.
+func (s FaultStep) String() string {
+	var parts []string
+	if s.Delay > 0 {
+		parts = append(parts, "delay "+s.Delay.String())
+	}
+	if s.Limit >= 0 {
+		parts = append(parts, fmt.Sprintf("transfer %d bytes", s.Limit))
+	}
+	if s.Err != nil {
+		parts = append(parts, "fail with "+s.Err.Error())
+	}
+	return fmt.Sprintf("call %d: %s", s.Call, strings.Join(parts, ", "))
+}
+
+// FaultSchedule is a deterministic plan of faults over the calls the code
+// under test makes, in the order they reach the filesystem's error injector.
+// Calls without a step are left alone.
+type FaultSchedule struct {
+	Steps []FaultStep // Steps in increasing Call order.
+}
+
+// DecodeFaultSchedule turns arbitrary bytes, typically the input of a fuzz
+// target, into a FaultSchedule. Every input decodes to a valid schedule, and
+// the same input always decodes to the same one, so a failing fuzz input is a
+// replayable fault scenario:
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//		sched := mockfs.DecodeFaultSchedule(data)
+//		mfs := mockfs.MustNewMockFS(mockfs.WithErrorInjector(sched.Injector(nil)))
+//		// run the code under test against mfs
+//	})
+//
+// The input is read three bytes per step: the number of calls to skip since
+// the previous step (modulo 8), the kind of step, and its argument. Kinds,
+// modulo 4, are a failure with one of the package's sentinel errors, a short
+// transfer of up to 15 bytes without an error, a delay, and a short transfer
+// followed by a sentinel error. Trailing bytes that do not make a whole step
+// are ignored.
+func DecodeFaultSchedule(data []byte) FaultSchedule {
+	var s FaultSchedule
+
+	call := 0
+	for ; len(data) >= 3; data = data[3:] {
+		gap, kind, arg := int(data[0]%8), data[1]%4, int(data[2])
+		call += 1 + gap
+
+		step := FaultStep{Call: call, Limit: -1}
+		switch kind {
+		case 0:
+			step.Err = scheduleErrors[arg%len(scheduleErrors)]
+		case 1:
+			step.Limit = arg % 16
+		case 2:
+			step.Delay = scheduleDelays[arg%len(scheduleDelays)]
+		case 3:
+			step.Limit = arg % 16
+			step.Err = scheduleErrors[(arg/16)%len(scheduleErrors)]
+		}
+		s.Steps = append(s.Steps, step)
+	}
+
+	return s
+}
+
+// String returns the steps of the schedule, one per line.
+func (s FaultSchedule) String() string {
+	if len(s.Steps) == 0 {
+		return "no faults"
+	}
+
+	lines := make([]string, len(s.Steps))
+	for i, step := range s.Steps {
+		lines[i] = step.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Injector returns an ErrorInjector that applies the schedule and delegates
+// every call without a step, and every rule added to it, to base. A nil base
+// is replaced with NewErrorInjector(). Calls are counted across the injector
+// and its clones for Sub filesystems.
+//
+// A Read or Write whose step lets all the requested bytes through still
+// returns the step's error after them. Delays are simulated latency: given
+// to NewMockFS with WithErrorInjector, the injector adds them to the
+// filesystem's LatencySimulator, so they count as the Latency of the
+// delayed calls in the journal. Like other latency, a delay is not applied
+// to a filesystem operation that an injected error fails, and nothing is
+// delayed without a MockFS.
+func (s FaultSchedule) Injector(base ErrorInjector) ErrorInjector {
+	if base == nil {
+		base = NewErrorInjector()
+	}
+
+	steps := make(map[int]FaultStep, len(s.Steps))
+	for _, step := range s.Steps {
+		steps[step.Call] = step
+	}
+
+	return &scheduleInjector{ErrorInjector: base, state: &scheduleState{steps: steps}}
+}
+
+// scheduleState is the call counter a schedule injector shares with its
+// clones and its latency simulators.
+type scheduleState struct {
+	mu    sync.Mutex
+	calls int
+	steps map[int]FaultStep
+	paid  int      // Last call whose delay was applied or dropped.
+	log   faultLog // Faults injected through the schedule injector, by it or the injector it wraps.
+}
+
+// next counts a call and returns its step, if any. The delay of the call
+// before, if not applied yet, is dropped.
+func (st *scheduleState) next() (FaultStep, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.paid = max(st.paid, st.calls)
+	st.calls++
+	step, ok := st.steps[st.calls]
+	return step, ok
+}
+
+// delay returns the delay of the call whose latency is simulated, once. A
+// filesystem operation simulates latency after the injector counted it, and
+// an operation on a handle before.
+func (st *scheduleState) delay(handle bool) time.Duration {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	call := st.calls
+	if handle {
+		call++
+	}
+	if call <= st.paid {
+		return 0
+	}
+	st.paid = call
+	return st.steps[call].Delay
+}
+
+// scheduleInjector applies a FaultSchedule on top of another ErrorInjector.
+type scheduleInjector struct {
+	ErrorInjector
+
+	state *scheduleState
+}
+
+// Ensure scheduleInjector supports partial transfers, corruption, and recording.
+var (
+	_ transferChecker = (*scheduleInjector)(nil)
+	_ corrupter       = (*scheduleInjector)(nil)
+	_ FaultRecorder   = (*scheduleInjector)(nil)
+)
+
+// CheckAndApply applies the call's step, and otherwise delegates to the
+// wrapped injector. A short transfer without an error does not affect
+// operations that move no bytes.
+func (si *scheduleInjector) CheckAndApply(op Operation, path string) error {
+	call := si.state.log.call(op, path)
+	err := si.apply(op, path)
+	si.state.log.record(call, err)
+	return err
+}
+
+// apply decides the outcome of a call that moves no bytes.
+func (si *scheduleInjector) apply(op Operation, path string) error {
+	if step, ok := si.state.next(); ok && step.Err != nil {
+		return step.Err
+	}
+	//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is
+	return si.ErrorInjector.CheckAndApply(op, path)
+}
+
+// checkTransfer applies the call's step to a transfer, and otherwise
+// delegates to the wrapped injector.
+func (si *scheduleInjector) checkTransfer(op Operation, path string, off int64, requested int) (int, error) {
+	call := si.state.log.call(op, path)
+	n, err := si.transfer(op, path, off, requested)
+	si.state.log.recordTransfer(call, n, requested, err)
+	return n, err
+}
+
+// transfer decides the outcome of a call that moves requested bytes at off.
+func (si *scheduleInjector) transfer(op Operation, path string, off int64, requested int) (int, error) {
+	step, ok := si.state.next()
+	if ok {
+		switch {
+		case step.Limit >= 0:
+			return min(step.Limit, requested), step.Err
+		case step.Err != nil:
+			return 0, step.Err
+		}
+	}
+	return checkTransfer(si.ErrorInjector, op, path, off, requested)
+}
+
+// InjectedFaults returns the faults injected through the injector, in order,
+// whether by the schedule or by the injector it wraps.
+func (si *scheduleInjector) InjectedFaults() []InjectedFault {
+	return si.state.log.faults()
+}
+
+// corruption delegates to the wrapped injector without counting a call.
+func (si *scheduleInjector) corruption(op Operation, path string) Corruption {
+	return corruptionFor(si.ErrorInjector, op, path)
+}
+
+// hasCorruption delegates to the wrapped injector.
+func (si *scheduleInjector) hasCorruption() bool {
+	return keepsVersions(si.ErrorInjector)
+}
+
+// CloneForSub returns a clone of the wrapped injector for a sub-namespace
+// that shares the schedule and its call counter.
+func (si *scheduleInjector) CloneForSub(prefix string) ErrorInjector {
+	return &scheduleInjector{ErrorInjector: si.ErrorInjector.CloneForSub(prefix), state: si.state}
+}
+
+// wrapLatency returns l with the delays of the schedule added.
+func (si *scheduleInjector) wrapLatency(l LatencySimulator) LatencySimulator {
+	return &scheduleLatency{LatencySimulator: l, state: si.state}
+}
+
+// latencyWrapper is implemented by injectors whose delays are simulated as
+// latency by the filesystem's LatencySimulator.
+type latencyWrapper interface {
+	wrapLatency(l LatencySimulator) LatencySimulator
+}
+
+// scheduleLatency adds the delays of a schedule to another LatencySimulator.
+type scheduleLatency struct {
+	LatencySimulator
+
+	state  *scheduleState
+	handle bool // Whether it simulates the latency of a handle's operations.
+}
+
+// Simulate simulates the latency of the wrapped simulator, then the delay of
+// the call.
+func (sl *scheduleLatency) Simulate(op Operation, opts ...SimOpt) {
+	sl.LatencySimulator.Simulate(op, opts...)
+	time.Sleep(sl.state.delay(sl.handle))
+}
+
+// Clone returns a clone of the wrapped simulator that adds the same delays.
+func (sl *scheduleLatency) Clone() LatencySimulator {
+	return &scheduleLatency{LatencySimulator: sl.LatencySimulator.Clone(), state: sl.state, handle: sl.handle}
+}
+
+// handleLatency returns the latency simulator for a handle opened from a
+// filesystem simulating latency with l.
+func handleLatency(l LatencySimulator) LatencySimulator {
+	if sl, ok := l.(*scheduleLatency); ok {
+		return &scheduleLatency{LatencySimulator: sl.LatencySimulator.Clone(), state: sl.state, handle: true}
+	}
+	return l.Clone()
+}
@@ -0,0 +1,240 @@
+package mockfs_test
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestDecodeFaultSchedule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data []byte
+		want []mockfs.FaultStep
+	}{
+		{name: "empty", data: nil, want: nil},
+		{name: "partial step ignored", data: []byte{0, 0}, want: nil},
+		{
+			name: "failure",
+			data: []byte{2, 0, 1},
+			want: []mockfs.FaultStep{{Call: 3, Err: mockfs.ErrTimeout, Limit: -1}},
+		},
+		{
+			name: "short transfer",
+			data: []byte{0, 1, 0x23},
+			want: []mockfs.FaultStep{{Call: 1, Limit: 3}},
+		},
+		{
+			name: "delay",
+			data: []byte{0, 2, 1},
+			want: []mockfs.FaultStep{{Call: 1, Delay: time.Millisecond, Limit: -1}},
+		},
+		{
+			name: "short transfer then error",
+			data: []byte{0, 3, 0x12},
+			want: []mockfs.FaultStep{{Call: 1, Limit: 2, Err: mockfs.ErrTimeout}},
+		},
+		{
+			name: "gaps accumulate",
+			data: []byte{9, 0, 0, 1, 0, 0},
+			want: []mockfs.FaultStep{
+				{Call: 2, Err: mockfs.ErrDiskFull, Limit: -1},
+				{Call: 4, Err: mockfs.ErrDiskFull, Limit: -1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := mockfs.DecodeFaultSchedule(tt.data).Steps
+			if len(got) != len(tt.want) {
+				t.Fatalf("steps = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("step %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFaultSchedule_String(t *testing.T) {
+	t.Parallel()
+
+	if got := (mockfs.FaultSchedule{}).String(); got != "no faults" {
+		t.Errorf("String() = %q, want %q", got, "no faults")
+	}
+
+	s := mockfs.FaultSchedule{Steps: []mockfs.FaultStep{
+		{Call: 2, Delay: time.Millisecond, Limit: 3, Err: mockfs.ErrDiskFull},
+		{Call: 5, Limit: -1, Err: mockfs.ErrTimeout},
+	}}
+	want := "call 2: delay 1ms, transfer 3 bytes, fail with disk full\ncall 5: fail with operation timeout"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestFaultSchedule_Injector(t *testing.T) {
+	t.Parallel()
+
+	sched := mockfs.FaultSchedule{Steps: []mockfs.FaultStep{
+		{Call: 2, Limit: 2},                          // first Read is short
+		{Call: 3, Limit: -1, Err: mockfs.ErrTimeout}, // second Read fails
+		{Call: 5, Limit: -1, Err: mockfs.ErrDiskFull},
+	}}
+
+	// Rules of a custom base injector keep working
+	base := mockfs.NewErrorInjector()
+	requireNoError(t, base.AddExact(mockfs.OpStat, "missing", mockfs.ErrNotExist, mockfs.ErrorModeAlways, 0))
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.File("data.bin", "0123456789"),
+		mockfs.WithErrorInjector(sched.Injector(base)),
+	)
+
+	f, err := mfs.Open("data.bin") // call 1
+	requireNoError(t, err)
+
+	buf := make([]byte, 8)
+	n, err := f.Read(buf) // call 2
+	requireNoError(t, err)
+	if n != 2 {
+		t.Errorf("short read n = %d, want 2", n)
+	}
+
+	_, err = f.Read(buf) // call 3
+	assertError(t, err, mockfs.ErrTimeout)
+
+	_, err = io.ReadAll(f) // calls 4 and 5
+	assertError(t, err, mockfs.ErrDiskFull)
+
+	_, err = mfs.Stat("missing")
+	assertError(t, err, mockfs.ErrNotExist)
+}
+
+func TestFaultSchedule_Delays(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		sched := mockfs.FaultSchedule{Steps: []mockfs.FaultStep{
+			{Call: 1, Limit: -1, Delay: time.Second},                             // Open
+			{Call: 2, Limit: -1, Delay: 2 * time.Second},                         // Read
+			{Call: 4, Limit: -1, Delay: 3 * time.Second, Err: mockfs.ErrTimeout}, // Stat
+			{Call: 5, Limit: -1, Delay: 4 * time.Second},                         // Close
+		}}
+		mfs := mockfs.MustNewMockFS(
+			mockfs.WithJournal(),
+			mockfs.File("data.bin", "0123456789"),
+			mockfs.WithErrorInjector(sched.Injector(nil)),
+		)
+
+		f, err := mfs.Open("data.bin")
+		requireNoError(t, err)
+		_, err = f.Read(make([]byte, 4))
+		requireNoError(t, err)
+		_, err = mfs.Stat("data.bin") // call 3
+		requireNoError(t, err)
+		_, err = mfs.Stat("data.bin")
+		assertError(t, err, mockfs.ErrTimeout)
+		requireNoError(t, f.Close())
+
+		// Delays are the latency of the delayed calls; a failed Stat has none
+		var got []time.Duration
+		for e := range mfs.Journal() {
+			got = append(got, e.Latency)
+		}
+		want := []time.Duration{time.Second, 2 * time.Second, 0, 0, 4 * time.Second}
+		if !slices.Equal(got, want) {
+			t.Errorf("latencies = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestFaultSchedule_ErrorAfterFullTransfer(t *testing.T) {
+	t.Parallel()
+
+	sched := mockfs.FaultSchedule{Steps: []mockfs.FaultStep{
+		{Call: 2, Limit: 15, Err: mockfs.ErrCorrupted}, // Read of 10 bytes
+		{Call: 3, Limit: 15, Err: mockfs.ErrDiskFull},  // Write of 2 bytes
+	}}
+	mfs := mockfs.MustNewMockFS(
+		mockfs.File("data.bin", "0123456789"),
+		mockfs.WithErrorInjector(sched.Injector(nil)),
+	)
+
+	f, err := mfs.OpenFile("data.bin", os.O_RDWR, 0)
+	requireNoError(t, err)
+	defer f.Close()
+
+	n, err := f.Read(make([]byte, 10))
+	assertError(t, err, mockfs.ErrCorrupted)
+	if n != 10 {
+		t.Errorf("Read n = %d, want 10", n)
+	}
+	n, err = f.Write([]byte("ab"))
+	assertError(t, err, mockfs.ErrDiskFull)
+	if n != 2 {
+		t.Errorf("Write n = %d, want 2", n)
+	}
+
+	faults := mfs.InjectedFaults()
+	if len(faults) != 2 || !faults[0].Short || faults[0].N != 10 || faults[1].N != 2 {
+		t.Errorf("InjectedFaults = %v, want both transfers recorded", faults)
+	}
+}
+
+func TestFaultSchedule_InjectorSub(t *testing.T) {
+	t.Parallel()
+
+	sched := mockfs.FaultSchedule{Steps: []mockfs.FaultStep{{Call: 2, Limit: -1, Err: mockfs.ErrTimeout}}}
+	mfs := mockfs.MustNewMockFS(
+		mockfs.Dir("app", mockfs.File("cfg", "x")),
+		mockfs.WithErrorInjector(sched.Injector(nil)),
+	)
+
+	_, err := mfs.Stat("app/cfg") // call 1
+	requireNoError(t, err)
+
+	sub, err := mfs.Sub("app")
+	requireNoError(t, err)
+
+	// The sub filesystem continues the count
+	_, err = fs.Stat(sub, "cfg") // call 2
+	assertError(t, err, mockfs.ErrTimeout)
+}
+
+func FuzzDecodeFaultSchedule(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 0, 0})
+	f.Add([]byte{1, 1, 200, 7, 3, 255, 0, 2, 2})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		first := mockfs.DecodeFaultSchedule(data)
+		if first.String() != mockfs.DecodeFaultSchedule(data).String() {
+			t.Fatal("decoding is not deterministic")
+		}
+		if len(first.Steps) != len(data)/3 {
+			t.Fatalf("steps = %d, want %d", len(first.Steps), len(data)/3)
+		}
+
+		// Any schedule can drive a filesystem without panicking
+		mfs := mockfs.MustNewMockFS(
+			mockfs.File("data.bin", "0123456789"),
+			mockfs.WithErrorInjector(first.Injector(nil)),
+		)
+		_, _ = mfs.ReadFile("data.bin")
+		_ = mfs.WriteFile("data.bin", []byte("abc"), 0o644)
+	})
+}
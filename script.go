@@ -0,0 +1,118 @@
+package mockfs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrRepeat marks the end of a repeating script (see NewScriptRule). It is
+// never returned by an operation.
+var ErrRepeat = errors.New("repeat script")
+
+// ShortTransfer returns a script outcome (see NewScriptRule) that cuts a Read
+// or Write short, as a partial-transfer rule does: the call transfers at most
+// n bytes and then returns err, which may be nil. For an operation that moves
+// no bytes, the outcome injects err, or lets the call through if err is nil.
+func ShortTransfer(n int, err error) error {
+	return &shortTransfer{n: n, err: err}
+}
+
+// shortTransfer is the script outcome returned by ShortTransfer.
+type shortTransfer struct {
+	n   int
+	err error
+}
+
+// Error implements error.
+func (s *shortTransfer) Error() string {
+	return fmt.Sprintf("short transfer of %d bytes: %v", s.n, s.err)
+}
+
+// Unwrap returns the error the short transfer reports.
+func (s *shortTransfer) Unwrap() error {
+	return s.err
+}
+
+// NewScriptRule creates a rule that steps through script, one outcome per
+// matching call, to model flaky behaviour such as "EAGAIN, EAGAIN, EIO, then
+// succeed". A nil outcome lets the call through, ShortTransfer cuts a
+// transfer short, and any other error is injected.
+// Once the script is exhausted the rule no longer fires, unless its last
+// outcome is ErrRepeat, which starts the script over:
+//
+//	// Fail, succeed, fail, succeed, ...
+//	rule, _ := mockfs.NewScriptRule([]error{mockfs.ErrTimeout, nil, mockfs.ErrRepeat},
+//		mockfs.NewExactMatcher("data.bin"))
+//
+// Each rule keeps its own cursor, advanced only by calls it matches. Its Mode
+// is ErrorModeScript.
+//
+// Returns an error wrapping ErrUsage if script is empty, or if ErrRepeat is
+// anywhere but last or is the only outcome.
+func NewScriptRule(script []error, matchers ...PathMatcher) (*ErrorRule, error) {
+	if err := validateScript(script); err != nil {
+		return nil, err
+	}
+
+	rule := newValidatedErrorRule(nil, ErrorModeScript, 0, matchers...)
+	rule.script = slices.Clone(script)
+
+	return rule, nil
+}
+
+// NewCallsRule creates a rule that injects err on the given call numbers,
+// counted from 1 over the calls the rule matches, and lets every other call
+// through. For example, calls {3, 7, 8} fail the third, seventh, and eighth
+// matching calls. It is a scripted rule; see NewScriptRule.
+//
+// Returns an error wrapping ErrUsage if calls is empty or holds a number less than 1.
+func NewCallsRule(err error, calls []int, matchers ...PathMatcher) (*ErrorRule, error) {
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("mockfs: %w: no call numbers", ErrUsage)
+	}
+	if lowest := slices.Min(calls); lowest < 1 {
+		return nil, fmt.Errorf("mockfs: %w: invalid call number %d — must be >= 1", ErrUsage, lowest)
+	}
+
+	script := make([]error, slices.Max(calls))
+	for _, call := range calls {
+		script[call-1] = err
+	}
+
+	return NewScriptRule(script, matchers...)
+}
+
+// validateScript checks that script has outcomes to step through and that
+// ErrRepeat, if present, only ends it.
+func validateScript(script []error) error {
+	if len(script) == 0 {
+		return fmt.Errorf("mockfs: %w: empty script", ErrUsage)
+	}
+
+	i := slices.Index(script, ErrRepeat)
+	switch {
+	case i == 0:
+		return fmt.Errorf("mockfs: %w: script has nothing to repeat", ErrUsage)
+	case i > 0 && i < len(script)-1:
+		return fmt.Errorf("mockfs: %w: ErrRepeat at %d must end the script", ErrUsage, i)
+	}
+
+	return nil
+}
+
+// step advances the script cursor and reports whether this call fails, along
+// with the error to inject.
+func (r *ErrorRule) step() (bool, error) {
+	call := r.hits.Add(1) - 1
+	length := uint64(len(r.script))
+
+	if r.script[length-1] == ErrRepeat { //nolint:errorlint // ErrRepeat is a marker compared by identity, never wrapped
+		call %= length - 1
+	} else if call >= length {
+		return false, nil
+	}
+
+	err := r.script[call]
+	return err != nil, err
+}
@@ -0,0 +1,194 @@
+package mockfs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+var errAgain = errors.New("resource temporarily unavailable")
+
+// outcomes runs n checks of op on path and returns the injected errors.
+func outcomes(inj mockfs.ErrorInjector, op mockfs.Operation, path string, n int) []error {
+	got := make([]error, n)
+	for i := range got {
+		got[i] = inj.CheckAndApply(op, path)
+	}
+	return got
+}
+
+// assertOutcomes compares injected errors call by call.
+func assertOutcomes(tb testing.TB, got, want []error) {
+	tb.Helper()
+
+	for i := range want {
+		if !errors.Is(got[i], want[i]) {
+			tb.Errorf("call %d: got %v, want %v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestNewScriptRule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script []error
+		want   []error
+	}{
+		{
+			name:   "then succeed forever",
+			script: []error{errAgain, errAgain, mockfs.ErrTimeout},
+			want:   []error{errAgain, errAgain, mockfs.ErrTimeout, nil, nil, nil},
+		},
+		{
+			name:   "alternate",
+			script: []error{nil, mockfs.ErrTimeout, mockfs.ErrRepeat},
+			want:   []error{nil, mockfs.ErrTimeout, nil, mockfs.ErrTimeout, nil, mockfs.ErrTimeout},
+		},
+		{
+			name:   "repeat a single outcome",
+			script: []error{mockfs.ErrDiskFull, mockfs.ErrRepeat},
+			want:   []error{mockfs.ErrDiskFull, mockfs.ErrDiskFull, mockfs.ErrDiskFull},
+		},
+		{
+			name:   "short transfers only inject their error",
+			script: []error{mockfs.ShortTransfer(1, mockfs.ErrTimeout), mockfs.ShortTransfer(1, nil)},
+			want:   []error{mockfs.ErrTimeout, nil, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := mockfs.NewScriptRule(tt.script, mockfs.NewExactMatcher("data.bin"))
+			requireNoError(t, err)
+			if rule.Mode() != mockfs.ErrorModeScript {
+				t.Errorf("Mode() = %v, want ErrorModeScript", rule.Mode())
+			}
+
+			inj := mockfs.NewErrorInjector()
+			inj.Add(mockfs.OpRead, rule)
+			assertOutcomes(t, outcomes(inj, mockfs.OpRead, "data.bin", len(tt.want)), tt.want)
+		})
+	}
+}
+
+func TestNewScriptRule_CursorOnlyAdvancesOnMatch(t *testing.T) {
+	t.Parallel()
+
+	rule, err := mockfs.NewScriptRule([]error{nil, mockfs.ErrTimeout}, mockfs.NewExactMatcher("data.bin"))
+	requireNoError(t, err)
+	inj := mockfs.NewErrorInjector()
+	inj.Add(mockfs.OpRead, rule)
+
+	assertNoError(t, inj.CheckAndApply(mockfs.OpRead, "data.bin"))
+	assertNoError(t, inj.CheckAndApply(mockfs.OpRead, "other.bin"))
+	assertNoError(t, inj.CheckAndApply(mockfs.OpWrite, "data.bin"))
+	assertError(t, inj.CheckAndApply(mockfs.OpRead, "data.bin"), mockfs.ErrTimeout)
+}
+
+func TestNewScriptRule_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script []error
+	}{
+		{name: "empty", script: nil},
+		{name: "only repeat", script: []error{mockfs.ErrRepeat}},
+		{name: "repeat in the middle", script: []error{nil, mockfs.ErrRepeat, mockfs.ErrTimeout}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := mockfs.NewScriptRule(tt.script, mockfs.NewWildcardMatcher())
+			assertError(t, err, mockfs.ErrUsage)
+		})
+	}
+
+	_, err := mockfs.NewErrorRule(mockfs.ErrTimeout, mockfs.ErrorModeScript, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "script mode without a script")
+}
+
+func TestNewCallsRule(t *testing.T) {
+	t.Parallel()
+
+	rule, err := mockfs.NewCallsRule(mockfs.ErrTimeout, []int{8, 3, 7}, mockfs.NewExactMatcher("db"))
+	requireNoError(t, err)
+	inj := mockfs.NewErrorInjector()
+	inj.Add(mockfs.OpOpen, rule)
+
+	e := mockfs.ErrTimeout
+	assertOutcomes(t, outcomes(inj, mockfs.OpOpen, "db", 10), []error{nil, nil, e, nil, nil, nil, e, e, nil, nil})
+
+	_, err = mockfs.NewCallsRule(mockfs.ErrTimeout, nil, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "no calls")
+
+	_, err = mockfs.NewCallsRule(mockfs.ErrTimeout, []int{2, 0}, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "call zero")
+}
+
+func TestErrorModeEvery(t *testing.T) {
+	t.Parallel()
+
+	inj := mockfs.NewErrorInjector()
+	requireNoError(t, inj.AddExact(mockfs.OpSync, "db", mockfs.ErrTimeout, mockfs.ErrorModeEvery, 3))
+
+	e := mockfs.ErrTimeout
+	assertOutcomes(t, outcomes(inj, mockfs.OpSync, "db", 7), []error{nil, nil, e, nil, nil, e, nil})
+
+	assertError(t, inj.AddExact(mockfs.OpSync, "db", e, mockfs.ErrorModeEvery, 0), mockfs.ErrUsage)
+}
+
+func TestMockFS_FailScript(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("data.bin", "data"))
+	requireNoError(t, mfs.FailScript(mockfs.OpOpen, "data.bin", errAgain, errAgain, mockfs.ErrTimeout))
+
+	// A retry loop gets through on the fourth attempt
+	var attempts int
+	for {
+		attempts++
+		f, err := mfs.Open("data.bin")
+		if err == nil {
+			requireNoError(t, f.Close())
+			break
+		}
+		if attempts > 10 {
+			t.Fatalf("still failing after %d attempts: %v", attempts, err)
+		}
+	}
+	if attempts != 4 {
+		t.Errorf("attempts = %d, want 4", attempts)
+	}
+
+	assertError(t, mfs.FailScript(mockfs.OpOpen, "data.bin"), mockfs.ErrUsage)
+}
+
+func TestMockFS_FailEveryAndCalls(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "a"), mockfs.File("b", "b"))
+	requireNoError(t, mfs.FailEvery(mockfs.OpStat, "a", mockfs.ErrTimeout, 2))
+	requireNoError(t, mfs.FailCalls(mockfs.OpStat, "b", mockfs.ErrTimeout, 1, 3))
+
+	var gotA, gotB []error
+	for range 4 {
+		_, err := mfs.Stat("a")
+		gotA = append(gotA, err)
+		_, err = mfs.Stat("b")
+		gotB = append(gotB, err)
+	}
+
+	e := mockfs.ErrTimeout
+	assertOutcomes(t, gotA, []error{nil, e, nil, e})
+	assertOutcomes(t, gotB, []error{e, nil, e, nil})
+
+	assertError(t, mfs.FailEvery(mockfs.OpStat, "a", e, 0), mockfs.ErrUsage)
+	assertError(t, mfs.FailCalls(mockfs.OpStat, "a", e), mockfs.ErrUsage)
+}
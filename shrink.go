@@ -0,0 +1,340 @@
+package mockfs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InjectedFault is a fault an ErrorInjector injected: the call it failed, or
+// cut short, and the error the call returned.
+type InjectedFault struct {
+	FaultPoint
+	Err   error // Error injected; nil for a short transfer that reported none.
+	Short bool  // Whether the call transferred N bytes before Err, or fewer than requested.
+	N     int   // Bytes transferred by a short call.
+}
+
+// String returns a one-line description of the fault.
+func (f InjectedFault) String() string {
+	if f.Short {
+		return fmt.Sprintf("%s: short transfer of %d bytes: %v", f.FaultPoint, f.N, f.Err)
+	}
+	return fmt.Sprintf("%s: %v", f.FaultPoint, f.Err)
+}
+
+// FaultRecorder is implemented by error injectors that record the faults they
+// inject, such as those returned by NewErrorInjector and FaultSchedule.Injector.
+type FaultRecorder interface {
+	// InjectedFaults returns the faults injected so far, in order.
+	InjectedFaults() []InjectedFault
+}
+
+// faultLog counts the calls that reach an injector and records the faults it
+// injects. The zero value is ready to use.
+type faultLog struct {
+	mu    sync.Mutex
+	calls map[FaultPoint]int // Calls so far, by point with a zero Call.
+	fired []InjectedFault    // Faults injected, in order.
+}
+
+// call counts a call of op on path and returns its point.
+func (l *faultLog) call(op Operation, path string) FaultPoint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.calls == nil {
+		l.calls = make(map[FaultPoint]int)
+	}
+	key := FaultPoint{Op: op, Path: path}
+	l.calls[key]++
+
+	return FaultPoint{Op: op, Path: path, Call: l.calls[key]}
+}
+
+// record notes that the call at p failed with err; a nil err is not a fault.
+func (l *faultLog) record(p FaultPoint, err error) {
+	if err == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fired = append(l.fired, InjectedFault{FaultPoint: p, Err: err})
+}
+
+// recordTransfer notes the outcome of the call at p, a transfer of requested
+// bytes that moved n of them: a fault if it failed outright, fell short, or
+// failed after the transfer.
+func (l *faultLog) recordTransfer(p FaultPoint, n, requested int, err error) {
+	switch {
+	case n == 0 && err != nil:
+		l.record(p, err)
+	case n < requested || err != nil:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.fired = append(l.fired, InjectedFault{FaultPoint: p, Err: err, Short: true, N: n})
+	}
+}
+
+// faults returns the faults recorded so far.
+func (l *faultLog) faults() []InjectedFault {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Clone(l.fired)
+}
+
+// InjectedFaults returns the faults the filesystem's error injector has
+// injected, in order, or nil if the injector is not a FaultRecorder.
+func (m *MockFS) InjectedFaults() []InjectedFault {
+	if r, ok := m.injector.(FaultRecorder); ok {
+		return r.InjectedFaults()
+	}
+	return nil
+}
+
+// ShrinkFaults finds a minimal subset of faults that still makes a test fail,
+// by delta debugging: it repeatedly re-runs fails with parts of the set
+// removed and keeps any smaller set for which fails still returns true. The
+// result is 1-minimal: removing any single fault from it makes the failure go
+// away. fails should build a fresh fixture, inject exactly the faults it is
+// given (see MockFS.InjectFaults), run the code under test, and report
+// whether the failure reproduced.
+//
+// If fails does not return true for the whole set, the set is returned
+// unchanged, as nothing smaller can be shown to reproduce the failure.
+func ShrinkFaults(faults []InjectedFault, fails func([]InjectedFault) bool) []InjectedFault {
+	current := slices.Clone(faults)
+	if len(current) == 0 || !fails(current) {
+		return current
+	}
+	if fails(nil) {
+		return nil
+	}
+
+	n := 2
+	for len(current) >= 2 {
+		chunks := splitFaults(current, n)
+
+		reduced := false
+		for _, chunk := range chunks {
+			if fails(chunk) {
+				current, n, reduced = chunk, 2, true
+				break
+			}
+		}
+		if !reduced && n > 2 {
+			for i := range chunks {
+				complement := slices.Concat(slices.Concat(chunks[:i]...), slices.Concat(chunks[i+1:]...))
+				if fails(complement) {
+					current, n, reduced = complement, max(n-1, 2), true
+					break
+				}
+			}
+		}
+
+		if !reduced {
+			if n >= len(current) {
+				break
+			}
+			n = min(2*n, len(current))
+		}
+	}
+
+	return current
+}
+
+// splitFaults splits faults into n chunks of nearly equal size, keeping order.
+func splitFaults(faults []InjectedFault, n int) [][]InjectedFault {
+	chunks := make([][]InjectedFault, 0, n)
+	for i := range n {
+		start, end := i*len(faults)/n, (i+1)*len(faults)/n
+		chunks = append(chunks, slices.Clone(faults[start:end]))
+	}
+	return chunks
+}
+
+// faultGroup is the faults injected at one operation on one path.
+type faultGroup struct {
+	op     Operation
+	path   string
+	script []error // Outcome of each call, nil for calls that succeed.
+}
+
+// groupFaults groups faults by operation and path, in order of first
+// appearance, with a script covering calls up to the last failing one. A
+// short call becomes a ShortTransfer outcome.
+// Returns an error wrapping ErrUsage if a fault has a call number below 1, or
+// a nil Err without being short.
+func groupFaults(faults []InjectedFault) ([]*faultGroup, error) {
+	var groups []*faultGroup
+	byPoint := make(map[FaultPoint]*faultGroup)
+
+	for _, f := range faults {
+		if f.Call < 1 || (f.Err == nil && !f.Short) {
+			return nil, fmt.Errorf("mockfs: %w: invalid fault %v", ErrUsage, f)
+		}
+
+		key := FaultPoint{Op: f.Op, Path: f.Path}
+		g, ok := byPoint[key]
+		if !ok {
+			g = &faultGroup{op: f.Op, path: f.Path}
+			byPoint[key] = g
+			groups = append(groups, g)
+		}
+		if len(g.script) < f.Call {
+			g.script = append(g.script, make([]error, f.Call-len(g.script))...)
+		}
+		g.script[f.Call-1] = f.Err
+		if f.Short {
+			g.script[f.Call-1] = ShortTransfer(f.N, f.Err)
+		}
+	}
+
+	return groups, nil
+}
+
+// InjectFaults adds rules that inject exactly faults: each fault fails its
+// call of its operation on its path, as counted by the injector, with its
+// error, or cuts it short after the bytes it transferred. The rules are
+// those FaultsAsCode prints, so the printed code reproduces the same faults.
+// Use it to replay recorded faults, for example in the predicate of
+// ShrinkFaults.
+//
+// Returns an error wrapping ErrUsage if a fault has a call number below 1
+// or a nil Err without being short, in which case no rule is added.
+func (m *MockFS) InjectFaults(faults []InjectedFault) error {
+	groups, err := groupFaults(faults)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		rule, ruleErr := NewScriptRule(g.script, NewExactMatcher(g.path))
+		if ruleErr != nil {
+			return ruleErr
+		}
+		m.injector.Add(g.op, rule)
+	}
+	return nil
+}
+
+// FaultsAsCode returns Go statements that inject faults into a MockFS named
+// mfs, one per operation and path, ready to paste into a test: a FailXOnce
+// helper or an AddExact call for a single fault on a first call, FailCalls
+// for calls failing with one error, and FailScript otherwise. Errors that are
+// package sentinels are named; others are written as errors.New calls.
+func FaultsAsCode(faults []InjectedFault) string {
+	groups, err := groupFaults(faults)
+	if err != nil {
+		return "// " + err.Error()
+	}
+
+	lines := make([]string, 0, len(groups))
+	for _, g := range groups {
+		lines = append(lines, g.code())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// code returns the statement that injects the group's faults.
+func (g *faultGroup) code() string {
+	path := strconv.Quote(g.path)
+
+	var calls []string
+	var errs []error
+	for i, err := range g.script {
+		if err != nil {
+			calls = append(calls, strconv.Itoa(i+1))
+			errs = append(errs, err)
+		}
+	}
+
+	sameErr := !slices.ContainsFunc(errs[1:], func(err error) bool {
+		return err != errs[0] //nolint:errorlint // the same error value, not a wrapped one
+	})
+	_, short := errs[0].(*shortTransfer) //nolint:errorlint // a script outcome is compared by type, never wrapped
+
+	switch {
+	case len(g.script) == 1 && !short:
+		if helper, ok := failOnceHelpers[g.op]; ok {
+			return fmt.Sprintf("_ = mfs.%s(%s, %s)", helper, path, errorCode(errs[0]))
+		}
+		return fmt.Sprintf("_ = mfs.ErrorInjector().AddExact(%s, %s, %s, mockfs.ErrorModeOnce, 0)", operationCode(g.op), path, errorCode(errs[0]))
+	case sameErr && !short:
+		return fmt.Sprintf("_ = mfs.FailCalls(%s, %s, %s, %s)", operationCode(g.op), path, errorCode(errs[0]), strings.Join(calls, ", "))
+	default:
+		outcomes := make([]string, len(g.script))
+		for i, err := range g.script {
+			outcomes[i] = errorCode(err)
+		}
+		return fmt.Sprintf("_ = mfs.FailScript(%s, %s, %s)", operationCode(g.op), path, strings.Join(outcomes, ", "))
+	}
+}
+
+// failOnceHelpers names the MockFS helper that fails the first call of an operation once.
+var failOnceHelpers = map[Operation]string{
+	OpStat:      "FailStatOnce",
+	OpOpen:      "FailOpenOnce",
+	OpRead:      "FailReadOnce",
+	OpWrite:     "FailWriteOnce",
+	OpReadDir:   "FailReadDirOnce",
+	OpClose:     "FailCloseOnce",
+	OpMkdir:     "FailMkdirOnce",
+	OpMkdirAll:  "FailMkdirAllOnce",
+	OpRemove:    "FailRemoveOnce",
+	OpRemoveAll: "FailRemoveAllOnce",
+	OpRename:    "FailRenameOnce",
+	OpChmod:     "FailChmodOnce",
+	OpChtimes:   "FailChtimesOnce",
+	OpChown:     "FailChownOnce",
+	OpTruncate:  "FailTruncateOnce",
+	OpSync:      "FailSyncOnce",
+}
+
+// sentinelNames names the package's sentinel errors for generated code.
+var sentinelNames = map[error]string{
+	ErrInvalid:        "ErrInvalid",
+	ErrPermission:     "ErrPermission",
+	ErrExist:          "ErrExist",
+	ErrNotExist:       "ErrNotExist",
+	ErrClosed:         "ErrClosed",
+	ErrUnexpectedEOF:  "ErrUnexpectedEOF",
+	ErrDiskFull:       "ErrDiskFull",
+	ErrTimeout:        "ErrTimeout",
+	ErrCorrupted:      "ErrCorrupted",
+	ErrTooManyHandles: "ErrTooManyHandles",
+	ErrNotDir:         "ErrNotDir",
+	ErrIsDir:          "ErrIsDir",
+	ErrNotEmpty:       "ErrNotEmpty",
+	ErrNegativeOffset: "ErrNegativeOffset",
+	ErrSymlinkLoop:    "ErrSymlinkLoop",
+	ErrInjected:       "ErrInjected",
+}
+
+// errorCode returns a Go expression for err.
+func errorCode(err error) string {
+	if err == nil {
+		return "nil"
+	}
+	if short, ok := err.(*shortTransfer); ok { //nolint:errorlint // a script outcome is compared by type, never wrapped
+		return fmt.Sprintf("mockfs.ShortTransfer(%d, %s)", short.n, errorCode(short.err))
+	}
+	if name, ok := sentinelNames[err]; ok {
+		return "mockfs." + name
+	}
+	return fmt.Sprintf("errors.New(%q)", err.Error())
+}
+
+// operationCode returns the Go name of the constant for op.
+func operationCode(op Operation) string {
+	if op == OpReadlink {
+		return "mockfs.OpReadlink"
+	}
+	return "mockfs.Op" + op.String()
+}
@@ -0,0 +1,232 @@
+package mockfs_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestMockFS_InjectedFaults(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"), mockfs.File("b", "data"))
+	requireNoError(t, mfs.FailCalls(mockfs.OpStat, "a", mockfs.ErrTimeout, 2))
+	requireNoError(t, mfs.FailReadOnce("b", mockfs.ErrCorrupted))
+
+	for range 3 {
+		_, _ = mfs.Stat("a")
+	}
+	_, _ = mfs.ReadFile("b")
+	_, _ = mfs.ReadFile("b")
+
+	want := []mockfs.InjectedFault{
+		{FaultPoint: mockfs.FaultPoint{Op: mockfs.OpStat, Path: "a", Call: 2}, Err: mockfs.ErrTimeout},
+		{FaultPoint: mockfs.FaultPoint{Op: mockfs.OpRead, Path: "b", Call: 1}, Err: mockfs.ErrCorrupted},
+	}
+	if got := mfs.InjectedFaults(); !slices.Equal(got, want) {
+		t.Errorf("InjectedFaults() = %v, want %v", got, want)
+	}
+}
+
+func TestMockFS_InjectedFaults_ShortTransfer(t *testing.T) {
+	t.Parallel()
+
+	// read reads b twice through one handle and returns what each read got
+	read := func(m *mockfs.MockFS) []string {
+		f, err := m.Open("b")
+		requireNoError(t, err)
+		defer f.Close()
+
+		var got []string
+		buf := make([]byte, 4)
+		for range 2 {
+			n, err := f.Read(buf)
+			requireNoError(t, err)
+			got = append(got, string(buf[:n]))
+		}
+		return got
+	}
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("b", "data"))
+	requireNoError(t, mfs.ShortRead("b", 2))
+	want := read(mfs)
+
+	// A short read without an error is a fault, and replays as one
+	faults := mfs.InjectedFaults()
+	wantFaults := []mockfs.InjectedFault{
+		{FaultPoint: mockfs.FaultPoint{Op: mockfs.OpRead, Path: "b", Call: 1}, Short: true, N: 2},
+	}
+	if !slices.Equal(faults, wantFaults) {
+		t.Fatalf("InjectedFaults() = %v, want %v", faults, wantFaults)
+	}
+
+	replay := mockfs.MustNewMockFS(mockfs.File("b", "data"))
+	requireNoError(t, replay.InjectFaults(faults))
+	if got := read(replay); !slices.Equal(got, want) {
+		t.Errorf("replayed reads = %q, want %q", got, want)
+	}
+
+	code := `_ = mfs.FailScript(mockfs.OpRead, "b", mockfs.ShortTransfer(2, nil))`
+	if got := mockfs.FaultsAsCode(faults); got != code {
+		t.Errorf("FaultsAsCode() = %s, want %s", got, code)
+	}
+}
+
+func TestShrinkFaults(t *testing.T) {
+	t.Parallel()
+
+	faults := make([]mockfs.InjectedFault, 20)
+	for i := range faults {
+		faults[i] = mockfs.InjectedFault{FaultPoint: mockfs.FaultPoint{Op: mockfs.OpRead, Path: "f", Call: i + 1}, Err: mockfs.ErrTimeout}
+	}
+	culprits := []mockfs.InjectedFault{faults[3], faults[11]}
+
+	var runs int
+	fails := func(set []mockfs.InjectedFault) bool {
+		runs++
+		return slices.Contains(set, culprits[0]) && slices.Contains(set, culprits[1])
+	}
+
+	if got := mockfs.ShrinkFaults(faults, fails); !slices.Equal(got, culprits) {
+		t.Errorf("ShrinkFaults() = %v, want %v", got, culprits)
+	}
+	if runs > len(faults)*4 {
+		t.Errorf("ShrinkFaults ran the test %d times", runs)
+	}
+
+	t.Run("not reproducible", func(t *testing.T) {
+		t.Parallel()
+		got := mockfs.ShrinkFaults(faults, func([]mockfs.InjectedFault) bool { return false })
+		if !slices.Equal(got, faults) {
+			t.Errorf("ShrinkFaults() = %v, want the input", got)
+		}
+	})
+
+	t.Run("fails without faults", func(t *testing.T) {
+		t.Parallel()
+		if got := mockfs.ShrinkFaults(faults, func([]mockfs.InjectedFault) bool { return true }); len(got) != 0 {
+			t.Errorf("ShrinkFaults() = %v, want none", got)
+		}
+	})
+}
+
+func TestFaultsAsCode(t *testing.T) {
+	t.Parallel()
+
+	fault := func(op mockfs.Operation, path string, call int, err error) mockfs.InjectedFault {
+		return mockfs.InjectedFault{FaultPoint: mockfs.FaultPoint{Op: op, Path: path, Call: call}, Err: err}
+	}
+
+	tests := []struct {
+		name   string
+		faults []mockfs.InjectedFault
+		want   string
+	}{
+		{
+			name:   "first call with a helper",
+			faults: []mockfs.InjectedFault{fault(mockfs.OpRead, "data.bin", 1, mockfs.ErrTimeout)},
+			want:   `_ = mfs.FailReadOnce("data.bin", mockfs.ErrTimeout)`,
+		},
+		{
+			name:   "first call without a helper",
+			faults: []mockfs.InjectedFault{fault(mockfs.OpReadlink, "link", 1, mockfs.ErrTimeout)},
+			want:   `_ = mfs.ErrorInjector().AddExact(mockfs.OpReadlink, "link", mockfs.ErrTimeout, mockfs.ErrorModeOnce, 0)`,
+		},
+		{
+			name: "later calls with one error",
+			faults: []mockfs.InjectedFault{
+				fault(mockfs.OpWrite, "out", 2, mockfs.ErrDiskFull),
+				fault(mockfs.OpWrite, "out", 4, mockfs.ErrDiskFull),
+			},
+			want: `_ = mfs.FailCalls(mockfs.OpWrite, "out", mockfs.ErrDiskFull, 2, 4)`,
+		},
+		{
+			name: "mixed errors",
+			faults: []mockfs.InjectedFault{
+				fault(mockfs.OpOpen, "db", 1, mockfs.ErrTimeout),
+				fault(mockfs.OpOpen, "db", 3, errors.New("custom")),
+				fault(mockfs.OpStat, "db", 1, mockfs.ErrNotExist),
+			},
+			want: `_ = mfs.FailScript(mockfs.OpOpen, "db", mockfs.ErrTimeout, nil, errors.New("custom"))` + "\n" +
+				`_ = mfs.FailStatOnce("db", mockfs.ErrNotExist)`,
+		},
+		{
+			name:   "invalid fault",
+			faults: []mockfs.InjectedFault{fault(mockfs.OpOpen, "db", 0, mockfs.ErrTimeout)},
+			want:   "// mockfs: usage error: invalid fault Open db (call 0): operation timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := mockfs.FaultsAsCode(tt.faults); got != tt.want {
+				t.Errorf("FaultsAsCode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// statAll stats every name and fails only if c timed out, standing in for
+// code with a bug on one error path.
+func statAll(m *mockfs.MockFS) error {
+	var bug error
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		for range 3 {
+			if _, err := m.Stat(name); name == "c" && errors.Is(err, mockfs.ErrTimeout) {
+				bug = err
+			}
+		}
+	}
+	return bug
+}
+
+func statFixture() *mockfs.MockFS {
+	return mockfs.MustNewMockFS(
+		mockfs.File("a", ""), mockfs.File("b", ""), mockfs.File("c", ""), mockfs.File("d", ""), mockfs.File("e", ""),
+	)
+}
+
+func TestShrinkFaults_ChaosReplay(t *testing.T) {
+	t.Parallel()
+
+	// Find a chaos run that hits the bug, with plenty of irrelevant faults
+	var faults []mockfs.InjectedFault
+	for seed := range uint64(100) {
+		mfs := statFixture()
+		_, err := mfs.InjectChaos(seed, map[mockfs.Operation]float64{mockfs.OpStat: 0.5}, mockfs.ErrTimeout)
+		requireNoError(t, err)
+		if statAll(mfs) != nil && len(mfs.InjectedFaults()) > 3 {
+			faults = mfs.InjectedFaults()
+			break
+		}
+	}
+	if faults == nil {
+		t.Fatal("no seed reproduced the bug")
+	}
+
+	fails := func(set []mockfs.InjectedFault) bool {
+		mfs := statFixture()
+		requireNoError(t, mfs.InjectFaults(set))
+		return statAll(mfs) != nil
+	}
+
+	minimal := mockfs.ShrinkFaults(faults, fails)
+	if len(minimal) != 1 || minimal[0].Path != "c" {
+		t.Fatalf("ShrinkFaults() = %v, want a single fault on c", minimal)
+	}
+	want := `_ = mfs.FailCalls(mockfs.OpStat, "c", mockfs.ErrTimeout, ` + strconv.Itoa(minimal[0].Call) + ")"
+	if minimal[0].Call == 1 {
+		want = `_ = mfs.FailStatOnce("c", mockfs.ErrTimeout)`
+	}
+	if got := mockfs.FaultsAsCode(minimal); got != want {
+		t.Errorf("FaultsAsCode() = %s, want %s", got, want)
+	}
+
+	assertError(t, mockfs.MustNewMockFS().InjectFaults(
+		[]mockfs.InjectedFault{{FaultPoint: mockfs.FaultPoint{Op: mockfs.OpStat, Path: "c", Call: 1}}},
+	), mockfs.ErrUsage)
+}
@@ -3,7 +3,9 @@ package mockfs
 import (
 	"fmt"
 	"iter"
+	"maps"
 	"math"
+	"path"
 	"slices"
 	"sync"
 )
@@ -18,6 +20,23 @@ type TestReporter interface {
 	Helper()
 }
 
+// CleanupReporter is a TestReporter that can also log, register cleanup
+// functions, and tell whether the test has failed. Chaos.ReportOnFailure
+// and CheckLeaks need it, and report a TestReporter that lacks it.
+// Both [*testing.T] and [*testing.B] satisfy this interface.
+type CleanupReporter interface {
+	TestReporter
+
+	// Logf records a message in the test log.
+	Logf(format string, args ...any)
+
+	// Cleanup registers a function to be called when the test completes.
+	Cleanup(f func())
+
+	// Failed reports whether the test has failed.
+	Failed() bool
+}
+
 // Stats is the read-only statistics interface returned by MockFS.Stats() and MockFile.Stats().
 // It represents an immutable snapshot of operation statistics at a point in time.
 //
@@ -59,6 +78,23 @@ type Stats interface {
 	// Empty reports whether no operations have been recorded.
 	Empty() bool
 
+	// Path breakdown
+
+	// ForPath returns the statistics of the operations on the given path,
+	// which is cleaned first. An operation counts toward the path that error
+	// injection matches it against: the old path for Rename and Link, the
+	// new name for Symlink. Operations recorded without a path only count
+	// toward the totals.
+	ForPath(path string) Stats
+
+	// ForMatcher returns the statistics of the operations on all paths that
+	// m matches, as for ForPath. A nil matcher matches every path.
+	ForMatcher(m PathMatcher) Stats
+
+	// Paths returns an iterator over the paths with recorded operations,
+	// in sorted order.
+	Paths() iter.Seq[string]
+
 	// Comparison
 
 	// Delta returns the difference between this and other stats.
@@ -105,6 +141,14 @@ type StatsAssertion interface {
 	// BytesWritten asserts the total number of bytes written.
 	BytesWritten(expected int) StatsAssertion
 
+	// PathCount asserts the number of times the given operation was called
+	// on path. See Stats.ForPath.
+	PathCount(path string, op Operation, expected int) StatsAssertion
+
+	// MatcherCount asserts the number of times the given operation was
+	// called on the paths m matches. See Stats.ForMatcher.
+	MatcherCount(m PathMatcher, op Operation, expected int) StatsAssertion
+
 	// Assert runs the assertions.
 	Assert(TestReporter)
 }
@@ -123,7 +167,14 @@ type StatsRecorder interface {
 	// Panics if the operation is invalid: this is a programmer error, not a runtime condition.
 	Record(op Operation, bytes int, err error)
 
+	// RecordPath is like Record, and also counts the operation toward the
+	// given path, which is cleaned first. See Stats.ForPath.
+	//
+	// Panics if the operation is invalid: this is a programmer error, not a runtime condition.
+	RecordPath(op Operation, path string, bytes int, err error)
+
 	// Set directly sets the total and failure counts for an operation.
+	// Per-path counters are left unchanged.
 	//
 	// Panics if the operation is invalid, failures is negative, or failures > total.
 	// This is a programmer error, not a runtime condition.
@@ -144,15 +195,22 @@ type StatsRecorder interface {
 
 // --- StatsRecorder Implementation ---
 
-// statsRecorder is the internal mutable implementation.
-type statsRecorder struct {
+// opCounters are the mutable counters of a statsRecorder, for all
+// operations or for the operations on one path.
+type opCounters struct {
 	bytesRead    uint64
 	bytesWritten uint64
 	ops          [NumOperations]struct {
 		total   uint64
 		failure uint64
 	}
-	mu sync.RWMutex
+}
+
+// statsRecorder is the internal mutable implementation.
+type statsRecorder struct {
+	opCounters                        // Counters of all operations.
+	paths      map[string]*opCounters // Counters per cleaned path.
+	mu         sync.RWMutex
 }
 
 // Ensure interface implementations.
@@ -176,11 +234,35 @@ func NewStatsRecorder(initial Stats) StatsRecorder {
 			}
 		}
 		r.SetBytes(initial.BytesRead(), initial.BytesWritten())
+
+		for p := range initial.Paths() {
+			if r.paths == nil {
+				r.paths = make(map[string]*opCounters)
+			}
+			r.paths[p] = countersOf(initial.ForPath(p))
+		}
 	}
 
 	return r
 }
 
+// countersOf returns the counters of s, ignoring its path breakdown.
+// It panics on negative counts, as Set and SetBytes do.
+func countersOf(s Stats) *opCounters {
+	var r statsRecorder
+	for op := range NumOperations {
+		if !op.IsValid() {
+			continue
+		}
+		if total, failures := s.Count(op), s.CountFailure(op); total > 0 || failures > 0 {
+			r.Set(op, total, failures)
+		}
+	}
+	r.SetBytes(s.BytesRead(), s.BytesWritten())
+
+	return &r.opCounters
+}
+
 // Record logs an operation result and bytes transferred.
 //
 // Panics if the operation is invalid: this is a programmer error, not a runtime condition.
@@ -193,18 +275,50 @@ func (r *statsRecorder) Record(op Operation, bytes int, err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.ops[op].total++
+	r.record(op, bytes, err)
+}
+
+// RecordPath is like Record, and also counts the operation toward the
+// given path, which is cleaned first.
+//
+// Panics if the operation is invalid: this is a programmer error, not a runtime condition.
+func (r *statsRecorder) RecordPath(op Operation, name string, bytes int, err error) {
+	if !op.IsValid() {
+		//nolint:forbidigo // Panic is intentional here to mark incorrect use
+		panic(fmt.Sprintf("mockfs: StatsRecorder.RecordPath called with invalid operation: %d", op))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record(op, bytes, err)
+
+	name = path.Clean(name)
+	c, ok := r.paths[name]
+	if !ok {
+		if r.paths == nil {
+			r.paths = make(map[string]*opCounters)
+		}
+		c = &opCounters{}
+		r.paths[name] = c
+	}
+	c.record(op, bytes, err)
+}
+
+// record counts an operation result and the bytes it transferred.
+func (c *opCounters) record(op Operation, bytes int, err error) {
+	c.ops[op].total++
 	if err != nil {
-		r.ops[op].failure++
+		c.ops[op].failure++
 	}
 
 	// Always record bytes, even on partial read/write or other errors
 	if bytes > 0 {
 		switch op {
 		case OpRead:
-			r.bytesRead += uint64(bytes)
+			c.bytesRead += uint64(bytes)
 		case OpWrite:
-			r.bytesWritten += uint64(bytes)
+			c.bytesWritten += uint64(bytes)
 		default:
 		}
 	}
@@ -260,12 +374,8 @@ func (r *statsRecorder) Reset() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.bytesRead = 0
-	r.bytesWritten = 0
-	for i := range int(NumOperations) {
-		r.ops[i].total = 0
-		r.ops[i].failure = 0
-	}
+	r.opCounters = opCounters{}
+	r.paths = nil
 }
 
 // Snapshot returns an immutable Stats view of the current state.
@@ -273,13 +383,26 @@ func (r *statsRecorder) Snapshot() Stats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	snap := statsSnapshot{
-		bytesRead:    clampToInt(r.bytesRead),
-		bytesWritten: clampToInt(r.bytesWritten),
+	snap := statsSnapshot{snapshotCounters: r.opCounters.snapshot()}
+	if len(r.paths) > 0 {
+		snap.paths = make(map[string]snapshotCounters, len(r.paths))
+		for p, c := range r.paths {
+			snap.paths[p] = c.snapshot()
+		}
+	}
+
+	return snap
+}
+
+// snapshot returns an immutable copy of the counters.
+func (c *opCounters) snapshot() snapshotCounters {
+	snap := snapshotCounters{
+		bytesRead:    clampToInt(c.bytesRead),
+		bytesWritten: clampToInt(c.bytesWritten),
 	}
 	for i := range int(NumOperations) {
-		snap.ops[i].total = clampToInt(r.ops[i].total)
-		snap.ops[i].failure = clampToInt(r.ops[i].failure)
+		snap.ops[i].total = clampToInt(c.ops[i].total)
+		snap.ops[i].failure = clampToInt(c.ops[i].failure)
 	}
 
 	return snap
@@ -429,10 +552,26 @@ func (r *statsRecorder) Expect() StatsAssertion {
 	return r.Snapshot().Expect()
 }
 
+// ForPath returns the statistics of the operations on the given path.
+func (r *statsRecorder) ForPath(name string) Stats {
+	return r.Snapshot().ForPath(name)
+}
+
+// ForMatcher returns the statistics of the operations on all paths that m matches.
+func (r *statsRecorder) ForMatcher(m PathMatcher) Stats {
+	return r.Snapshot().ForMatcher(m)
+}
+
+// Paths returns an iterator over the paths with recorded operations, in sorted order.
+func (r *statsRecorder) Paths() iter.Seq[string] {
+	return r.Snapshot().Paths()
+}
+
 // --- Stats Snapshot Implementation ---
 
-// statsSnapshot is the internal immutable implementation.
-type statsSnapshot struct {
+// snapshotCounters are the immutable counters of a statsSnapshot, for all
+// operations or for the operations on one path.
+type snapshotCounters struct {
 	bytesRead    int
 	bytesWritten int
 	ops          [NumOperations]struct {
@@ -441,6 +580,12 @@ type statsSnapshot struct {
 	}
 }
 
+// statsSnapshot is the internal immutable implementation.
+type statsSnapshot struct {
+	snapshotCounters                             // Counters of all operations.
+	paths            map[string]snapshotCounters // Counters per cleaned path.
+}
+
 // Ensure interface implementation.
 var _ Stats = (*statsSnapshot)(nil)
 
@@ -531,41 +676,144 @@ func (s statsSnapshot) Empty() bool {
 	return s.Operations() == 0
 }
 
-// Delta returns the difference between this and other stats.
+// Delta returns the difference between this and other stats,
+// path by path.
 func (s statsSnapshot) Delta(other Stats) Stats {
-	delta := statsSnapshot{
-		bytesRead:    s.bytesRead - other.BytesRead(),
-		bytesWritten: s.bytesWritten - other.BytesWritten(),
+	delta := statsSnapshot{snapshotCounters: s.minus(other)}
+	for _, p := range s.pathUnion(other) {
+		if d := s.paths[p].minus(other.ForPath(p)); d != (snapshotCounters{}) {
+			if delta.paths == nil {
+				delta.paths = make(map[string]snapshotCounters)
+			}
+			delta.paths[p] = d
+		}
+	}
+
+	return delta
+}
+
+// Equal reports whether this Stats has the same values as other,
+// path by path.
+func (s statsSnapshot) Equal(other Stats) bool {
+	if s.minus(other) != (snapshotCounters{}) {
+		return false
+	}
+
+	for _, p := range s.pathUnion(other) {
+		if s.paths[p].minus(other.ForPath(p)) != (snapshotCounters{}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// minus returns the difference between the counters and those of other.
+func (c snapshotCounters) minus(other Stats) snapshotCounters {
+	d := snapshotCounters{
+		bytesRead:    c.bytesRead - other.BytesRead(),
+		bytesWritten: c.bytesWritten - other.BytesWritten(),
 	}
 	for i := range int(NumOperations) {
 		op := Operation(i)
 		if !op.IsValid() {
 			continue
 		}
-		delta.ops[i].total = s.ops[i].total - other.Count(op)
-		delta.ops[i].failure = s.ops[i].failure - other.CountFailure(op)
+		d.ops[i].total = c.ops[i].total - other.Count(op)
+		d.ops[i].failure = c.ops[i].failure - other.CountFailure(op)
 	}
 
-	return delta
+	return d
 }
 
-// Equal reports whether this Stats has the same values as other.
-func (s statsSnapshot) Equal(other Stats) bool {
-	if s.bytesRead != other.BytesRead() || s.bytesWritten != other.BytesWritten() {
-		return false
+// plus returns the sum of the counters and those of other.
+func (c snapshotCounters) plus(other Stats) snapshotCounters {
+	sum := snapshotCounters{
+		bytesRead:    c.bytesRead + other.BytesRead(),
+		bytesWritten: c.bytesWritten + other.BytesWritten(),
 	}
-
 	for i := range int(NumOperations) {
 		op := Operation(i)
 		if !op.IsValid() {
 			continue
 		}
-		if s.ops[i].total != other.Count(op) || s.ops[i].failure != other.CountFailure(op) {
-			return false
+		sum.ops[i].total = c.ops[i].total + other.Count(op)
+		sum.ops[i].failure = c.ops[i].failure + other.CountFailure(op)
+	}
+
+	return sum
+}
+
+// sumStats returns the sum of all, path by path.
+func sumStats(all ...Stats) Stats {
+	var sum statsSnapshot
+	for _, s := range all {
+		sum.snapshotCounters = sum.plus(s)
+		for p := range s.Paths() {
+			if sum.paths == nil {
+				sum.paths = make(map[string]snapshotCounters)
+			}
+			sum.paths[p] = sum.paths[p].plus(s.ForPath(p))
 		}
 	}
 
-	return true
+	return sum
+}
+
+// pathUnion returns the paths with recorded operations in s or other, sorted.
+func (s statsSnapshot) pathUnion(other Stats) []string {
+	paths := slices.Collect(maps.Keys(s.paths))
+	for p := range other.Paths() {
+		if _, ok := s.paths[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+// ForPath returns the statistics of the operations on the given path.
+func (s statsSnapshot) ForPath(name string) Stats {
+	name = path.Clean(name)
+	return s.filter(func(p string) bool { return p == name })
+}
+
+// ForMatcher returns the statistics of the operations on all paths that m matches.
+// A nil matcher matches every path.
+func (s statsSnapshot) ForMatcher(m PathMatcher) Stats {
+	if m == nil {
+		return s.filter(func(string) bool { return true })
+	}
+	return s.filter(m.Matches)
+}
+
+// Paths returns an iterator over the paths with recorded operations, in sorted order.
+func (s statsSnapshot) Paths() iter.Seq[string] {
+	return slices.Values(slices.Sorted(maps.Keys(s.paths)))
+}
+
+// filter returns the statistics of the paths that keep accepts.
+func (s statsSnapshot) filter(keep func(string) bool) statsSnapshot {
+	var sub statsSnapshot
+	for p, c := range s.paths {
+		if !keep(p) {
+			continue
+		}
+		if sub.paths == nil {
+			sub.paths = make(map[string]snapshotCounters)
+		}
+		sub.paths[p] = c
+
+		sub.bytesRead += c.bytesRead
+		sub.bytesWritten += c.bytesWritten
+		for i := range int(NumOperations) {
+			sub.ops[i].total += c.ops[i].total
+			sub.ops[i].failure += c.ops[i].failure
+		}
+	}
+
+	return sub
 }
 
 // String returns a human-readable summary.
@@ -664,6 +912,31 @@ func (sa *statsAssertion) BytesWritten(expected int) StatsAssertion {
 	return sa
 }
 
+// PathCount asserts the number of times the given operation was called on path.
+func (sa *statsAssertion) PathCount(name string, op Operation, expected int) StatsAssertion {
+	sa.checks = append(sa.checks, func(t TestReporter) {
+		if got := sa.stats.ForPath(name).Count(op); got != expected {
+			t.Helper()
+			t.Errorf("ForPath(%q).Count(%s) = %d, want %d", name, op, got, expected)
+		}
+	})
+	return sa
+}
+
+// MatcherCount asserts the number of times the given operation was called
+// on the paths m matches.
+func (sa *statsAssertion) MatcherCount(m PathMatcher, op Operation, expected int) StatsAssertion {
+	sa.checks = append(sa.checks, func(t TestReporter) {
+		s := sa.stats.ForMatcher(m)
+		if got := s.Count(op); got != expected {
+			t.Helper()
+			t.Errorf("ForMatcher(%s).Count(%s) = %d, want %d (paths: %v)",
+				matcherString(m), op, got, expected, slices.Collect(s.Paths()))
+		}
+	})
+	return sa
+}
+
 // Assert runs the assertions.
 func (sa *statsAssertion) Assert(t TestReporter) {
 	t.Helper()
@@ -96,6 +96,7 @@ func TestStatsRecorder_Panics(t *testing.T) {
 	}{
 		{"record invalid op -1", func(s mockfs.StatsRecorder) { s.Record(mockfs.Operation(-1), 0, nil) }},
 		{"record invalid op NumOps", func(s mockfs.StatsRecorder) { s.Record(mockfs.NumOperations, 0, nil) }},
+		{"record path invalid op", func(s mockfs.StatsRecorder) { s.RecordPath(mockfs.NumOperations, "a", 0, nil) }},
 		{"set invalid op -1", func(s mockfs.StatsRecorder) { s.Set(mockfs.Operation(-1), 1, 0) }},
 		{"set invalid op NumOps", func(s mockfs.StatsRecorder) { s.Set(mockfs.NumOperations, 1, 0) }},
 		{"set negative failures", func(s mockfs.StatsRecorder) { s.Set(mockfs.OpStat, 5, -1) }},
@@ -114,6 +115,125 @@ func TestStatsRecorder_Panics(t *testing.T) {
 	}
 }
 
+// TestStats_Paths verifies the per-path breakdown.
+func TestStats_Paths(t *testing.T) {
+	t.Parallel()
+
+	r := mockfs.NewStatsRecorder(nil)
+	r.RecordPath(mockfs.OpRead, "config.yaml", 10, nil)
+	r.RecordPath(mockfs.OpRead, "./config.yaml", 5, errors.New("fail"))
+	r.RecordPath(mockfs.OpWrite, "cache/a", 3, nil)
+	r.RecordPath(mockfs.OpWrite, "cache/b", 4, nil)
+	r.Record(mockfs.OpStat, 0, nil)
+
+	cache, err := mockfs.NewGlobMatcher("cache/*")
+	requireNoError(t, err)
+
+	for _, s := range []mockfs.Stats{r, r.Snapshot()} {
+		if got := slices.Collect(s.Paths()); !slices.Equal(got, []string{"cache/a", "cache/b", "config.yaml"}) {
+			t.Errorf("Paths() = %v", got)
+		}
+
+		cfg := s.ForPath("config.yaml")
+		assertOpCount(t, cfg, mockfs.OpRead, 2, 1)
+		assertBytes(t, cfg, 15, 0)
+		if got := slices.Collect(cfg.Paths()); !slices.Equal(got, []string{"config.yaml"}) {
+			t.Errorf("ForPath(config.yaml).Paths() = %v", got)
+		}
+
+		cached := s.ForMatcher(cache)
+		assertOpCount(t, cached, mockfs.OpWrite, 2, 0)
+		assertBytes(t, cached, 0, 7)
+		assertOpCount(t, cached.ForPath("cache/b"), mockfs.OpWrite, 1, 0)
+
+		assertOpCount(t, s.ForPath("missing"), mockfs.OpRead, 0, 0)
+		assertOpCount(t, s.ForMatcher(nil), mockfs.OpStat, 0, 0)
+		assertOpCount(t, s.ForMatcher(nil), mockfs.OpRead, 2, 1)
+		assertOpCount(t, s, mockfs.OpStat, 1, 0)
+	}
+
+	t.Run("copy, delta, and equality", func(t *testing.T) {
+		t.Parallel()
+
+		before := r.Snapshot()
+		c := mockfs.NewStatsRecorder(before)
+		if !c.Equal(before) {
+			t.Errorf("copy %v not equal to %v", c, before)
+		}
+
+		c.RecordPath(mockfs.OpRead, "config.yaml", 1, nil)
+		if c.Equal(before) {
+			t.Error("Equal ignores the path breakdown")
+		}
+
+		delta := c.Delta(before)
+		if got := slices.Collect(delta.Paths()); !slices.Equal(got, []string{"config.yaml"}) {
+			t.Errorf("Delta paths = %v, want [config.yaml]", got)
+		}
+		assertOpCount(t, delta.ForPath("config.yaml"), mockfs.OpRead, 1, 0)
+
+		c.Reset()
+		if got := slices.Collect(c.Paths()); len(got) != 0 {
+			t.Errorf("Paths() after Reset = %v", got)
+		}
+	})
+
+	t.Run("assertions", func(t *testing.T) {
+		t.Parallel()
+
+		r.Expect().
+			PathCount("config.yaml", mockfs.OpRead, 2).
+			MatcherCount(cache, mockfs.OpWrite, 2).
+			Assert(t)
+
+		mt := &mockReporter{}
+		r.Expect().
+			PathCount("config.yaml", mockfs.OpRead, 1).
+			MatcherCount(cache, mockfs.OpWrite, 0).
+			Assert(mt)
+
+		want := []string{
+			`ForPath("config.yaml").Count(Read) = 2, want 1`,
+			`ForMatcher(cache/*).Count(Write) = 2, want 0 (paths: [cache/a cache/b])`,
+		}
+		if !slices.Equal(mt.errors, want) {
+			t.Errorf("errors = %q, want %q", mt.errors, want)
+		}
+	})
+}
+
+// TestMockFS_PathStats verifies that filesystem and handle operations are
+// counted per path.
+func TestMockFS_PathStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(
+		mockfs.File("config.yaml", "key: value"),
+		mockfs.Dir("cache", mockfs.File("a", "a")),
+	)
+	_ = mustReadFile(t, mfs, "config.yaml")
+	requireNoError(t, mfs.WriteFile("cache/a", []byte("b"), 0o644))
+	requireNoError(t, mfs.Rename("cache/a", "cache/c"))
+
+	f, err := mfs.OpenMockFile("config.yaml")
+	requireNoError(t, err)
+	_, err = f.Read(make([]byte, 3))
+	requireNoError(t, err)
+	requireNoError(t, f.Close())
+
+	mfs.Stats().Expect().
+		PathCount("config.yaml", mockfs.OpOpen, 2).
+		PathCount("cache/a", mockfs.OpWrite, 1).
+		PathCount("cache/a", mockfs.OpRename, 1).
+		PathCount("cache/c", mockfs.OpRename, 0).
+		Assert(t)
+
+	f.Stats().Expect().
+		PathCount("config.yaml", mockfs.OpRead, 1).
+		PathCount("config.yaml", mockfs.OpClose, 1).
+		Assert(t)
+}
+
 // TestStatsRecorder_Set verifies Set method.
 func TestStatsRecorder_Set(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,266 @@
+package mockfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"testing/fstest"
+	"time"
+)
+
+// ModeSymlink is the file mode bit marking a symbolic link.
+// See [io/fs.ModeSymlink].
+const ModeSymlink FileMode = fs.ModeSymlink
+
+// maxSymlinkHops is the number of symbolic links followed while resolving
+// a single path before giving up with ErrSymlinkLoop (Linux's MAXSYMLINKS).
+const maxSymlinkHops = 40
+
+// Symlink adds a symbolic link at the current context path.
+// The target is stored verbatim and is not required to exist; relative
+// targets are resolved against the directory containing the link, and
+// targets starting with "/" are resolved against the filesystem root.
+//
+// Note: Symlink does not create parent directories.
+// The hierarchy must be built explicitly using Dir().
+func Symlink(name, target string) FsOption {
+	opName := "Symlink"
+
+	return func(m *MockFS) error {
+		if name == "" {
+			return fmt.Errorf("%s: empty link name", opName)
+		}
+		// Strict hierarchical validation: name must be a single segment
+		if strings.ContainsRune(name, '/') || name == "." || name == ".." {
+			return fmt.Errorf("%s: invalid name %q (must be a single path segment)", opName, name)
+		}
+		if target == "" {
+			return fmt.Errorf("%s: empty target for %q", opName, name)
+		}
+
+		fullPath := name
+		if m.buildCtx != "." {
+			fullPath = path.Join(m.buildCtx, name)
+		}
+
+		m.link(path.Clean(fullPath), m.newInode(newSymlinkFile(target)))
+		return nil
+	}
+}
+
+// Symlink creates newname as a symbolic link to oldname, mirroring [os.Symlink].
+// The target is not required to exist. Returns ErrExist if newname already exists.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Symlink(oldname, newname string) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpSymlink, newname, 0, err) }()
+	j := m.journal.begin(OpSymlink, newname)
+	j.to(oldname)
+	defer func() { j.end(0, err) }()
+
+	cleanNew, err := m.validateAndCleanPath(newname, OpSymlink)
+	if err != nil {
+		return err
+	}
+	if oldname == "" || cleanNew == "." {
+		return &fs.PathError{Op: OpSymlink.String(), Path: newname, Err: ErrInvalid}
+	}
+
+	if err := m.injector.CheckAndApply(OpSymlink, cleanNew); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	j.simulate(m.latency, OpSymlink)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.writeMode == writeModeReadOnly {
+		return &fs.PathError{Op: OpSymlink.String(), Path: newname, Err: ErrPermission}
+	}
+
+	resolved, err := m.resolvePath(OpSymlink.String(), newname, cleanNew, false)
+	if err != nil {
+		return err
+	}
+	if _, exists := m.files[resolved]; exists {
+		return &fs.PathError{Op: OpSymlink.String(), Path: newname, Err: ErrExist}
+	}
+	if err := m.checkParentDir(OpSymlink.String(), newname, resolved); err != nil {
+		return err
+	}
+	if err := m.checkCreate(OpSymlink.String(), newname, resolved); err != nil {
+		return err
+	}
+	if err := m.checkInodeLimit(OpSymlink.String(), newname); err != nil {
+		return err
+	}
+
+	m.link(resolved, m.newInode(newSymlinkFile(oldname)))
+	return nil
+}
+
+// ReadLink returns the destination of the named symbolic link.
+// It implements the fs.ReadLinkFS interface. Returns ErrInvalid if the
+// named file is not a symbolic link.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) ReadLink(name string) (target string, err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpReadlink, name, 0, err) }()
+	j := m.journal.begin(OpReadlink, name)
+	defer func() { j.end(0, err) }()
+
+	cleanName, err := m.validateAndCleanPath(name, OpReadlink)
+	if err != nil {
+		return "", err
+	}
+
+	if err := m.injector.CheckAndApply(OpReadlink, cleanName); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return "", err
+	}
+
+	j.simulate(m.latency, OpReadlink)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, node, err := m.lookup(OpReadlink.String(), name, cleanName, false)
+	if err != nil {
+		return "", err
+	}
+	if node.Mode.Type() != ModeSymlink {
+		return "", &fs.PathError{Op: OpReadlink.String(), Path: name, Err: ErrInvalid}
+	}
+
+	return string(node.Data), nil
+}
+
+// Lstat returns file information for the given path without following a
+// final symbolic link. It implements the fs.ReadLinkFS interface.
+// Symbolic links in the parent directories are still followed.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Lstat(name string) (fi fs.FileInfo, err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpLstat, name, 0, err) }()
+	j := m.journal.begin(OpLstat, name)
+	defer func() { j.end(0, err) }()
+
+	cleanName, err := m.validateAndCleanPath(name, OpLstat)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.injector.CheckAndApply(OpLstat, cleanName); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return nil, err
+	}
+
+	j.simulate(m.latency, OpLstat)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, node, err := m.lookup(OpLstat.String(), name, cleanName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.fileInfo(path.Base(cleanName)), nil
+}
+
+// newSymlinkFile returns the map entry representing a symbolic link to target.
+func newSymlinkFile(target string) *fstest.MapFile {
+	return &fstest.MapFile{
+		Data:    []byte(target),
+		Mode:    ModeSymlink | ModePerm,
+		ModTime: time.Now(),
+	}
+}
+
+// lookup resolves cleanName and returns the resolved path and its inode.
+// If followLast is false, a symbolic link in the final element is returned
+// as-is rather than followed. Caller must hold the mutex.
+func (m *MockFS) lookup(opName, name, cleanName string, followLast bool) (string, *inode, error) {
+	resolved, err := m.resolvePath(opName, name, cleanName, followLast)
+	if err != nil {
+		return "", nil, err
+	}
+
+	node, exists := m.files[resolved]
+	if !exists {
+		return "", nil, &fs.PathError{Op: opName, Path: name, Err: ErrNotExist}
+	}
+
+	return resolved, node, nil
+}
+
+// resolvePath is resolveSymlinks with the error wrapped in a PathError.
+// With permission checks enabled, it also verifies search permission on the
+// ancestors of the resolved path. Caller must hold the mutex.
+func (m *MockFS) resolvePath(opName, name, cleanName string, followLast bool) (string, error) {
+	resolved, err := m.resolveSymlinks(cleanName, followLast)
+	if err != nil {
+		return "", &fs.PathError{Op: opName, Path: name, Err: err}
+	}
+	if err := m.checkSearch(opName, name, resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// resolveSymlinks rewrites cleanName into the path of the entry it refers to,
+// following symbolic links in every element except, when followLast is false,
+// the final one. Elements that do not exist are kept lexically, so resolving
+// a path about to be created yields where it would be created.
+// Returns ErrSymlinkLoop after maxSymlinkHops links. Caller must hold the mutex.
+func (m *MockFS) resolveSymlinks(cleanName string, followLast bool) (string, error) {
+	resolved := "."
+	rest := cleanName
+	hops := 0
+
+	for rest != "" {
+		var elem string
+		elem, rest, _ = strings.Cut(rest, "/")
+		next := path.Join(resolved, elem)
+
+		entry, exists := m.files[next]
+		if !exists || entry.Mode.Type() != ModeSymlink || (rest == "" && !followLast) {
+			resolved = next
+			continue
+		}
+
+		hops++
+		if hops > maxSymlinkHops {
+			return "", ErrSymlinkLoop
+		}
+
+		// Resolve the target against the link's directory, clamping ".." at
+		// the root, then restart the walk with the unresolved remainder.
+		target := string(entry.Data)
+		if !strings.HasPrefix(target, "/") {
+			target = path.Join("/", resolved, target)
+		}
+		target = strings.TrimPrefix(path.Clean(target), "/")
+		if target == "" {
+			target = "."
+		}
+
+		if rest != "" {
+			rest = target + "/" + rest
+		} else {
+			rest = target
+		}
+		resolved = "."
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,372 @@
+package mockfs_test
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// newSymlinkFS returns a filesystem with a file, a directory, and links to both.
+func newSymlinkFS(tb testing.TB, opts ...mockfs.FsOption) *mockfs.MockFS {
+	tb.Helper()
+
+	base := []mockfs.FsOption{
+		mockfs.File("file.txt", "content"),
+		mockfs.Dir("dir",
+			mockfs.File("child.txt", "child"),
+			mockfs.Symlink("up.txt", "../file.txt"),
+			mockfs.Symlink("abs.txt", "/file.txt"),
+		),
+		mockfs.Symlink("link.txt", "file.txt"),
+		mockfs.Symlink("linkdir", "dir"),
+		mockfs.Symlink("dangling", "missing.txt"),
+		mockfs.Symlink("loop1", "loop2"),
+		mockfs.Symlink("loop2", "loop1"),
+	}
+
+	mfs, err := mockfs.NewMockFS(append(base, opts...)...)
+	requireNoError(tb, err)
+
+	return mfs
+}
+
+func TestSymlink_Builder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		link   string
+		target string
+	}{
+		{"empty name", "", "target"},
+		{"empty target", "link", ""},
+		{"nested name", "a/b", "target"},
+		{"dot name", ".", "target"},
+		{"dot-dot name", "..", "target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := mockfs.NewMockFS(mockfs.Symlink(tt.link, tt.target))
+			assertError(t, err, mockfs.ErrUsage)
+		})
+	}
+}
+
+func TestMockFS_Symlink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []mockfs.FsOption
+		oldname string
+		newname string
+		wantErr error
+	}{
+		{name: "create link", oldname: "file.txt", newname: "new-link"},
+		{name: "dangling target allowed", oldname: "nowhere", newname: "new-link"},
+		{name: "create inside linked directory", oldname: "child.txt", newname: "linkdir/new-link"},
+		{name: "existing file", oldname: "file.txt", newname: "dir", wantErr: mockfs.ErrExist},
+		{name: "existing link", oldname: "file.txt", newname: "dangling", wantErr: mockfs.ErrExist},
+		{name: "missing parent", oldname: "file.txt", newname: "missing/link", wantErr: mockfs.ErrNotExist},
+		{name: "empty target", oldname: "", newname: "new-link", wantErr: mockfs.ErrInvalid},
+		{name: "invalid path", oldname: "file.txt", newname: "../link", wantErr: mockfs.ErrInvalid},
+		{
+			name:    "read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			oldname: "file.txt",
+			newname: "new-link",
+			wantErr: mockfs.ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mfs := newSymlinkFS(t, tt.opts...)
+
+			err := mfs.Symlink(tt.oldname, tt.newname)
+			assertError(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+
+			target, err := mfs.ReadLink(tt.newname)
+			requireNoError(t, err)
+			if target != tt.oldname {
+				t.Errorf("ReadLink = %q, want %q", target, tt.oldname)
+			}
+		})
+	}
+}
+
+func TestMockFS_ReadLink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "relative target", path: "link.txt", want: "file.txt"},
+		{name: "dangling link", path: "dangling", want: "missing.txt"},
+		{name: "link through linked parent", path: "linkdir/up.txt", want: "../file.txt"},
+		{name: "regular file", path: "file.txt", wantErr: mockfs.ErrInvalid},
+		{name: "missing", path: "missing", wantErr: mockfs.ErrNotExist},
+		{name: "invalid path", path: "/abs", wantErr: mockfs.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mfs := newSymlinkFS(t)
+
+			got, err := fs.ReadLink(mfs, tt.path)
+			assertError(t, err, tt.wantErr)
+			if got != tt.want {
+				t.Errorf("ReadLink(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockFS_Lstat(t *testing.T) {
+	t.Parallel()
+
+	mfs := newSymlinkFS(t)
+
+	info, err := fs.Lstat(mfs, "link.txt")
+	requireNoError(t, err)
+	if info.Mode().Type() != fs.ModeSymlink {
+		t.Errorf("Lstat mode = %v, want symlink", info.Mode())
+	}
+	if info.Name() != "link.txt" {
+		t.Errorf("Lstat name = %q, want %q", info.Name(), "link.txt")
+	}
+	if info.Size() != int64(len("file.txt")) {
+		t.Errorf("Lstat size = %d, want %d", info.Size(), len("file.txt"))
+	}
+
+	info, err = mfs.Stat("link.txt")
+	requireNoError(t, err)
+	if !info.Mode().IsRegular() {
+		t.Errorf("Stat mode = %v, want regular file", info.Mode())
+	}
+	if info.Name() != "link.txt" {
+		t.Errorf("Stat name = %q, want %q", info.Name(), "link.txt")
+	}
+	if info.Size() != int64(len("content")) {
+		t.Errorf("Stat size = %d, want %d", info.Size(), len("content"))
+	}
+
+	_, err = mfs.Lstat("dangling")
+	requireNoError(t, err, "Lstat dangling")
+	_, err = mfs.Stat("dangling")
+	assertError(t, err, mockfs.ErrNotExist, "Stat dangling")
+}
+
+func TestMockFS_SymlinkResolution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "link to file", path: "link.txt", want: "content"},
+		{name: "file in linked directory", path: "linkdir/child.txt", want: "child"},
+		{name: "relative dot-dot target", path: "dir/up.txt", want: "content"},
+		{name: "absolute target", path: "dir/abs.txt", want: "content"},
+		{name: "chained links", path: "linkdir/up.txt", want: "content"},
+		{name: "dangling link", path: "dangling", wantErr: mockfs.ErrNotExist},
+		{name: "link cycle", path: "loop1", wantErr: mockfs.ErrSymlinkLoop},
+		{name: "link cycle as parent", path: "loop1/file.txt", wantErr: mockfs.ErrSymlinkLoop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mfs := newSymlinkFS(t)
+
+			got, err := mfs.ReadFile(tt.path)
+			assertError(t, err, tt.wantErr)
+			if string(got) != tt.want {
+				t.Errorf("ReadFile(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockFS_SymlinkReadDir(t *testing.T) {
+	t.Parallel()
+
+	mfs := newSymlinkFS(t)
+
+	entries, err := mfs.ReadDir("linkdir")
+	requireNoError(t, err)
+
+	want := map[string]fs.FileMode{
+		"abs.txt":   fs.ModeSymlink,
+		"child.txt": 0,
+		"up.txt":    fs.ModeSymlink,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("ReadDir returned %d entries, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		if e.Type() != want[e.Name()] {
+			t.Errorf("entry %q type = %v, want %v", e.Name(), e.Type(), want[e.Name()])
+		}
+	}
+
+	f, err := mfs.Open("linkdir")
+	requireNoError(t, err)
+	defer f.Close()
+
+	dir, ok := f.(fs.ReadDirFile)
+	if !ok {
+		t.Fatal("opened linked directory does not implement fs.ReadDirFile")
+	}
+	handleEntries, err := dir.ReadDir(-1)
+	requireNoError(t, err)
+	if len(handleEntries) != len(want) {
+		t.Errorf("handle ReadDir returned %d entries, want %d", len(handleEntries), len(want))
+	}
+}
+
+func TestMockFS_SymlinkMutations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("remove deletes link only", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		requireNoError(t, mfs.Remove("link.txt"))
+		_, err := mfs.Lstat("link.txt")
+		assertError(t, err, mockfs.ErrNotExist, "link")
+		_, err = mfs.Stat("file.txt")
+		requireNoError(t, err, "target")
+	})
+
+	t.Run("remove all on linked directory keeps target", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		requireNoError(t, mfs.RemoveAll("linkdir"))
+		_, err := mfs.Stat("dir/child.txt")
+		requireNoError(t, err)
+	})
+
+	t.Run("rename moves link", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		requireNoError(t, mfs.Rename("link.txt", "renamed.txt"))
+		target, err := mfs.ReadLink("renamed.txt")
+		requireNoError(t, err)
+		if target != "file.txt" {
+			t.Errorf("ReadLink = %q, want %q", target, "file.txt")
+		}
+	})
+
+	t.Run("write file follows link", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		requireNoError(t, mfs.WriteFile("link.txt", []byte("updated"), 0o644))
+		if got := mustReadFile(t, mfs, "file.txt"); string(got) != "updated" {
+			t.Errorf("target content = %q, want %q", got, "updated")
+		}
+	})
+
+	t.Run("mkdir inside linked directory", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		requireNoError(t, mfs.Mkdir("linkdir/sub", 0o755))
+		info, err := mfs.Stat("dir/sub")
+		requireNoError(t, err)
+		if !info.IsDir() {
+			t.Error("dir/sub is not a directory")
+		}
+	})
+
+	t.Run("open file creates through dangling link", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		f, err := mfs.OpenFile("dangling", os.O_RDWR|os.O_CREATE, 0o644)
+		requireNoError(t, err)
+		requireNoError(t, f.Close())
+		_, err = mfs.Stat("missing.txt")
+		requireNoError(t, err)
+	})
+
+	t.Run("exclusive create does not follow link", func(t *testing.T) {
+		t.Parallel()
+		mfs := newSymlinkFS(t)
+
+		_, err := mfs.OpenFile("dangling", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		assertError(t, err, mockfs.ErrExist)
+	})
+}
+
+func TestMockFS_SymlinkInjectionAndStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := newSymlinkFS(t)
+	injector := mfs.ErrorInjector()
+	requireNoError(t, injector.AddExact(mockfs.OpSymlink, "new-link", mockfs.ErrDiskFull, mockfs.ErrorModeOnce, 0))
+	requireNoError(t, injector.AddExact(mockfs.OpReadlink, "link.txt", mockfs.ErrTimeout, mockfs.ErrorModeOnce, 0))
+	requireNoError(t, injector.AddExact(mockfs.OpLstat, "link.txt", mockfs.ErrCorrupted, mockfs.ErrorModeOnce, 0))
+
+	err := mfs.Symlink("file.txt", "new-link")
+	assertError(t, err, mockfs.ErrDiskFull, "symlink")
+	_, err = mfs.ReadLink("link.txt")
+	assertError(t, err, mockfs.ErrTimeout, "readlink")
+	_, err = mfs.Lstat("link.txt")
+	assertError(t, err, mockfs.ErrCorrupted, "lstat")
+
+	requireNoError(t, mfs.Symlink("file.txt", "new-link"))
+	_, err = mfs.ReadLink("link.txt")
+	requireNoError(t, err)
+	_, err = mfs.Lstat("link.txt")
+	requireNoError(t, err)
+
+	mfs.Stats().Expect().
+		Count(mockfs.OpSymlink, 2).
+		Failure(mockfs.OpSymlink, 1).
+		Count(mockfs.OpReadlink, 2).
+		Failure(mockfs.OpReadlink, 1).
+		Count(mockfs.OpLstat, 2).
+		Failure(mockfs.OpLstat, 1).
+		Assert(t)
+}
+
+func TestMockFS_SymlinkOpenedHandle(t *testing.T) {
+	t.Parallel()
+
+	mfs := newSymlinkFS(t)
+
+	f, err := mfs.OpenMockFile("link.txt")
+	requireNoError(t, err)
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	requireNoError(t, err)
+	if string(data) != "content" {
+		t.Errorf("read = %q, want %q", data, "content")
+	}
+
+	info, err := f.Stat()
+	requireNoError(t, err)
+	if info.Name() != "link.txt" {
+		t.Errorf("handle Stat name = %q, want %q", info.Name(), "link.txt")
+	}
+}
@@ -0,0 +1,181 @@
+package mockfs
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
+)
+
+// TransferLimit decides how many of the requested bytes a partial Read or
+// Write transfers. Results outside [0, requested] are clamped.
+type TransferLimit func(requested int) int
+
+// LimitBytes returns a TransferLimit that caps every transfer at n bytes.
+// A negative n is treated as 0.
+func LimitBytes(n int) TransferLimit {
+	return func(requested int) int {
+		return min(max(n, 0), requested)
+	}
+}
+
+// LimitRandom returns a TransferLimit that transfers a random-length,
+// non-empty prefix of each request, drawn from a generator seeded with seed,
+// so that a failing run can be replayed. It is safe for concurrent use.
+func LimitRandom(seed uint64) TransferLimit {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewPCG(seed, seed)) //nolint:gosec // deterministic fault injection, not security
+
+	return func(requested int) int {
+		if requested <= 0 {
+			return 0
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return 1 + rng.IntN(requested)
+	}
+}
+
+// NewShortRule creates a rule for a partial transfer. Added for OpRead or
+// OpWrite, it matches like any other rule, and when it fires the operation
+// transfers only limit(requested) bytes and then returns err.
+//
+// A short read may use a nil err: Read then returns fewer bytes than asked
+// for without an error, as io.Reader allows. ReadAt, whose contract requires
+// an error with a short count, returns io.ErrUnexpectedEOF in that case.
+// A short write with a nil err returns io.ErrShortWrite. A transfer the limit
+// does not cut short completes normally.
+//
+// Partial-transfer rules only apply to Read, ReadAt, Write, WriteAt, and
+// MockFS.WriteFile; other operations skip them.
+//
+// Returns an error wrapping ErrUsage if limit is nil, mode is invalid, or
+// after is negative for ErrorModeAfterSuccesses or ErrorModeNext.
+func NewShortRule(limit TransferLimit, err error, mode ErrorMode, after int, matchers ...PathMatcher) (*ErrorRule, error) {
+	if limit == nil {
+		return nil, fmt.Errorf("mockfs: %w: nil TransferLimit", ErrUsage)
+	}
+
+	rule, ruleErr := NewErrorRule(err, mode, after, matchers...)
+	if ruleErr != nil {
+		return nil, ruleErr
+	}
+	rule.limit = limit
+
+	return rule, nil
+}
+
+// NewRangeRule creates a rule for a fault in the length bytes starting at off,
+// like an unreadable sector. Added for OpRead or OpWrite, it only fires when
+// a transfer touches that range: the bytes before the range are transferred,
+// and then err is returned, as ReadAt returns io.EOF after a short read.
+// A transfer that starts inside the range fails without moving any bytes.
+// A nil err behaves as in NewShortRule.
+//
+// Range rules apply to the same operations as partial-transfer rules. A read
+// only touches the bytes that exist, so a range past the end of the file is
+// never hit by reads.
+//
+// Returns an error wrapping ErrUsage if off is negative, length is not
+// positive, mode is invalid, or after is negative for ErrorModeAfterSuccesses
+// or ErrorModeNext.
+func NewRangeRule(off, length int64, err error, mode ErrorMode, after int, matchers ...PathMatcher) (*ErrorRule, error) {
+	if off < 0 || length <= 0 {
+		return nil, fmt.Errorf("mockfs: %w: invalid byte range at %d of length %d", ErrUsage, off, length)
+	}
+
+	rule, ruleErr := NewErrorRule(err, mode, after, matchers...)
+	if ruleErr != nil {
+		return nil, ruleErr
+	}
+	rule.span = &byteRange{start: off, end: off + length}
+
+	return rule, nil
+}
+
+// transfer is the byte range an operation moves: n bytes starting at off.
+type transfer struct {
+	off int64
+	n   int
+}
+
+// byteRange is the half-open range of file offsets [start, end).
+type byteRange struct {
+	start, end int64
+}
+
+// overlaps reports whether the transfer t touches the range.
+func (br *byteRange) overlaps(t *transfer) bool {
+	return t.n > 0 && t.off < br.end && t.off+int64(t.n) > br.start
+}
+
+// admits reports whether the rule may fire for the transfer: any rule but a
+// corruption rule, and a range rule only when t overlaps its range.
+func (t *transfer) admits(r *ErrorRule) bool {
+	return r.corrupt == nil && (r.span == nil || r.span.overlaps(t))
+}
+
+// plain reports whether the rule fails an operation outright, rather than
+// shaping a transfer or corrupting data.
+func (r *ErrorRule) plain() bool {
+	return r.limit == nil && r.span == nil && r.corrupt == nil
+}
+
+// transferChecker is implemented by injectors that support partial-transfer rules.
+type transferChecker interface {
+	checkTransfer(op Operation, path string, off int64, requested int) (int, error)
+}
+
+// Ensure errorInjector implements transferChecker.
+var _ transferChecker = (*errorInjector)(nil)
+
+// checkTransfer applies the first firing rule to a transfer of requested
+// bytes at off. It returns how many bytes to transfer and the error to report
+// afterwards; a full failure transfers 0 bytes.
+func (ei *errorInjector) checkTransfer(op Operation, path string, off int64, requested int) (int, error) {
+	call := ei.log.call(op, path)
+	t := &transfer{off: off, n: requested}
+	r, err := ei.fire(op, path, t.admits)
+
+	n := requested
+	//nolint:errorlint // a script outcome is compared by type, never wrapped
+	switch short, isShort := err.(*shortTransfer); {
+	case r == nil:
+	case isShort:
+		n, err = min(max(short.n, 0), requested), short.err
+	case r.span != nil:
+		n = int(min(max(r.span.start-off, 0), int64(requested)))
+	case r.limit != nil:
+		n = min(max(r.limit(requested), 0), requested)
+	case err != nil:
+		n = 0
+	}
+
+	ei.log.recordTransfer(call, n, requested, err)
+	return n, err
+}
+
+// checkTransfer applies error injection to an operation that moves requested
+// bytes at off. Injectors that do not support partial transfers fall back to
+// CheckAndApply, so their rules either fail the operation or let it through.
+func checkTransfer(injector ErrorInjector, op Operation, path string, off int64, requested int) (int, error) {
+	if tc, ok := injector.(transferChecker); ok {
+		return tc.checkTransfer(op, path, off, requested)
+	}
+
+	if err := injector.CheckAndApply(op, path); err != nil {
+		return 0, err
+	}
+	return requested, nil
+}
+
+// shortWriteError returns the error for a write a rule cut short:
+// the rule's error, or io.ErrShortWrite if it has none.
+func shortWriteError(err error) error {
+	if err == nil {
+		return io.ErrShortWrite
+	}
+	return err
+}
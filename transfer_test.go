@@ -0,0 +1,483 @@
+package mockfs_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestLimitBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		n         int
+		requested int
+		want      int
+	}{
+		{name: "below request", n: 3, requested: 10, want: 3},
+		{name: "above request", n: 30, requested: 10, want: 10},
+		{name: "zero", n: 0, requested: 10, want: 0},
+		{name: "negative", n: -1, requested: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := mockfs.LimitBytes(tt.n)(tt.requested); got != tt.want {
+				t.Errorf("LimitBytes(%d)(%d) = %d, want %d", tt.n, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitRandom(t *testing.T) {
+	t.Parallel()
+
+	a, b := mockfs.LimitRandom(42), mockfs.LimitRandom(42)
+	for range 100 {
+		got := a(8)
+		if got < 1 || got > 8 {
+			t.Fatalf("LimitRandom(42)(8) = %d, want 1..8", got)
+		}
+		if replay := b(8); replay != got {
+			t.Fatalf("same seed diverged: %d != %d", replay, got)
+		}
+	}
+
+	if got := a(0); got != 0 {
+		t.Errorf("LimitRandom(42)(0) = %d, want 0", got)
+	}
+}
+
+func TestNewShortRule_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := mockfs.NewShortRule(nil, nil, mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "nil limit")
+
+	_, err = mockfs.NewShortRule(mockfs.LimitBytes(1), nil, mockfs.ErrorMode(99), 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "invalid mode")
+}
+
+func TestMockFile_ShortRead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("read is capped without error", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("data.bin", "0123456789"))
+		requireNoError(t, mfs.ShortRead("data.bin", 3))
+
+		f, err := mfs.OpenMockFile("data.bin")
+		requireNoError(t, err)
+		defer f.Close()
+
+		buf := make([]byte, 8)
+		n, err := f.Read(buf)
+		requireNoError(t, err)
+		if n != 3 || string(buf[:n]) != "012" {
+			t.Errorf("Read = %d %q, want 3 %q", n, buf[:n], "012")
+		}
+
+		// io.ReadAll copes with short reads and still sees everything
+		rest, err := io.ReadAll(f)
+		requireNoError(t, err)
+		if string(rest) != "3456789" {
+			t.Errorf("rest = %q, want %q", rest, "3456789")
+		}
+
+		// Stats record the bytes actually transferred
+		if got := f.Stats().BytesRead(); got != 10 {
+			t.Errorf("BytesRead = %d, want 10", got)
+		}
+	})
+
+	t.Run("read at reports unexpected EOF", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("data.bin", "0123456789"))
+		requireNoError(t, mfs.ShortRead("data.bin", 4))
+
+		f, err := mfs.OpenMockFile("data.bin")
+		requireNoError(t, err)
+		defer f.Close()
+
+		buf := make([]byte, 6)
+		n, err := f.ReadAt(buf, 2)
+		assertError(t, err, io.ErrUnexpectedEOF)
+		if n != 4 || string(buf[:n]) != "2345" {
+			t.Errorf("ReadAt = %d %q, want 4 %q", n, buf[:n], "2345")
+		}
+	})
+
+	t.Run("random prefix is replayable", func(t *testing.T) {
+		t.Parallel()
+
+		lengths := func() []int {
+			mfs := mockfs.MustNewMockFS(mockfs.File("data.bin", bytes.Repeat([]byte("x"), 64)))
+			requireNoError(t, mfs.ShortReadRandom("data.bin", 7))
+			f, err := mfs.OpenMockFile("data.bin")
+			requireNoError(t, err)
+			defer f.Close()
+
+			var got []int
+			buf := make([]byte, 16)
+			for {
+				n, err := f.Read(buf)
+				if err == io.EOF {
+					return got
+				}
+				requireNoError(t, err)
+				if n < 1 || n > len(buf) {
+					t.Fatalf("Read = %d, want 1..%d", n, len(buf))
+				}
+				got = append(got, n)
+			}
+		}
+
+		first, second := lengths(), lengths()
+		if len(first) != len(second) {
+			t.Fatalf("runs differ: %v vs %v", first, second)
+		}
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatalf("runs differ: %v vs %v", first, second)
+			}
+		}
+	})
+
+	t.Run("rule with error fails after the prefix", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("data.bin", "0123456789")
+		rule, err := mockfs.NewShortRule(mockfs.LimitBytes(2), mockfs.ErrTimeout, mockfs.ErrorModeOnce, 0, mockfs.NewExactMatcher("data.bin"))
+		requireNoError(t, err)
+		f.ErrorInjector().Add(mockfs.OpRead, rule)
+
+		buf := make([]byte, 5)
+		n, err := f.Read(buf)
+		assertError(t, err, mockfs.ErrTimeout)
+		if n != 2 {
+			t.Errorf("n = %d, want 2", n)
+		}
+
+		// Once mode: the next read is whole again
+		n, err = f.Read(buf)
+		requireNoError(t, err)
+		if string(buf[:n]) != "23456" {
+			t.Errorf("second read = %q, want %q", buf[:n], "23456")
+		}
+	})
+}
+
+func TestMockFile_ShortWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		err     error
+		write   func(mockfs.WritableFile) (int, error)
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "write fails with short write",
+			write:   func(f mockfs.WritableFile) (int, error) { return f.Write([]byte("abcdef")) },
+			wantErr: io.ErrShortWrite,
+			want:    "abcd456789",
+		},
+		{
+			name:    "write fails with rule error",
+			err:     mockfs.ErrDiskFull,
+			write:   func(f mockfs.WritableFile) (int, error) { return f.Write([]byte("abcdef")) },
+			wantErr: mockfs.ErrDiskFull,
+			want:    "abcd456789",
+		},
+		{
+			name:    "write at",
+			write:   func(f mockfs.WritableFile) (int, error) { return f.WriteAt([]byte("abcdef"), 8) },
+			wantErr: io.ErrShortWrite,
+			want:    "01234567abcd",
+		},
+		{
+			name:  "write within the limit",
+			write: func(f mockfs.WritableFile) (int, error) { return f.Write([]byte("ab")) },
+			want:  "ab23456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.File("out.bin", "0123456789"))
+			requireNoError(t, mfs.ShortWrite("out.bin", 4, tt.err))
+
+			f, err := mfs.OpenFile("out.bin", os.O_RDWR, 0)
+			requireNoError(t, err)
+			defer f.Close()
+
+			n, err := tt.write(f)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+			} else {
+				requireNoError(t, err)
+			}
+			if want := min(4, n); n != want {
+				t.Errorf("n = %d, want %d", n, want)
+			}
+			if got := string(mustReadFile(t, mfs, "out.bin")); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if got := f.(*mockfs.MockFile).Stats().BytesWritten(); got != n {
+				t.Errorf("BytesWritten = %d, want %d", got, n)
+			}
+		})
+	}
+}
+
+func TestMockFS_ShortWrite_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.WithCreateIfMissing(true))
+	requireNoError(t, mfs.ShortWrite("out.bin", 3, nil))
+
+	err := mfs.WriteFile("out.bin", []byte("abcdef"), 0o644)
+	assertError(t, err, io.ErrShortWrite)
+	if got := string(mustReadFile(t, mfs, "out.bin")); got != "abc" {
+		t.Errorf("content = %q, want %q", got, "abc")
+	}
+	if got := mfs.Stats().BytesWritten(); got != 3 {
+		t.Errorf("BytesWritten = %d, want 3", got)
+	}
+
+	assertError(t, mfs.ShortWrite("out.bin", -1, nil), mockfs.ErrUsage)
+	assertError(t, mfs.ShortRead("out.bin", -1), mockfs.ErrUsage)
+}
+
+func TestErrorInjector_CheckAndApply_SkipsShortRules(t *testing.T) {
+	t.Parallel()
+
+	inj := mockfs.NewErrorInjector()
+	rule, err := mockfs.NewShortRule(mockfs.LimitBytes(1), mockfs.ErrDiskFull, mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+	requireNoError(t, err)
+	inj.Add(mockfs.OpWrite, rule)
+
+	assertNoError(t, inj.CheckAndApply(mockfs.OpWrite, "any"))
+}
+
+func TestNewRangeRule_Validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := mockfs.NewRangeRule(-1, 4, nil, mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "negative offset")
+
+	_, err = mockfs.NewRangeRule(0, 0, nil, mockfs.ErrorModeAlways, 0, mockfs.NewWildcardMatcher())
+	assertError(t, err, mockfs.ErrUsage, "empty range")
+
+	mfs := mockfs.MustNewMockFS()
+	assertError(t, mfs.FailReadRange("data.bin", 0, -1, nil), mockfs.ErrUsage)
+	assertError(t, mfs.FailWriteRange("data.bin", -1, 1, nil), mockfs.ErrUsage)
+}
+
+func TestMockFile_ReadRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		read    func(*mockfs.MockFile, []byte) (int, error)
+		size    int
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "read stops before the bad sector",
+			read:    func(f *mockfs.MockFile, b []byte) (int, error) { return f.Read(b) },
+			size:    8,
+			want:    "0123",
+			wantErr: mockfs.ErrCorrupted,
+		},
+		{
+			name: "read before the bad sector",
+			read: func(f *mockfs.MockFile, b []byte) (int, error) { return f.Read(b) },
+			size: 4,
+			want: "0123",
+		},
+		{
+			name:    "read at inside the bad sector",
+			read:    func(f *mockfs.MockFile, b []byte) (int, error) { return f.ReadAt(b, 5) },
+			size:    2,
+			want:    "",
+			wantErr: mockfs.ErrCorrupted,
+		},
+		{
+			name:    "read at past the bad sector",
+			read:    func(f *mockfs.MockFile, b []byte) (int, error) { return f.ReadAt(b, 6) },
+			size:    8,
+			want:    "6789",
+			wantErr: io.EOF,
+		},
+		{
+			name:    "read at spanning the bad sector",
+			read:    func(f *mockfs.MockFile, b []byte) (int, error) { return f.ReadAt(b, 2) },
+			size:    8,
+			want:    "23",
+			wantErr: mockfs.ErrCorrupted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", "0123456789"))
+			requireNoError(t, mfs.FailReadRange("disk.img", 4, 2, mockfs.ErrCorrupted))
+
+			f, err := mfs.OpenMockFile("disk.img")
+			requireNoError(t, err)
+			defer f.Close()
+
+			buf := make([]byte, tt.size)
+			n, err := tt.read(f, buf)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+			} else {
+				requireNoError(t, err)
+			}
+			if got := string(buf[:n]); got != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockFile_ReadRange_PastEnd(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", "0123"))
+	requireNoError(t, mfs.FailReadRange("disk.img", 4, 512, mockfs.ErrCorrupted))
+
+	// Reads only touch the bytes that exist, so the range is never hit
+	got := mustReadFile(t, mfs, "disk.img")
+	if string(got) != "0123" {
+		t.Errorf("data = %q, want %q", got, "0123")
+	}
+}
+
+func TestMockFile_WriteRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		flag    int
+		write   func(mockfs.WritableFile) (int, error)
+		err     error
+		wantN   int
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "write at spanning the bad sector",
+			flag:    os.O_RDWR,
+			write:   func(f mockfs.WritableFile) (int, error) { return f.WriteAt([]byte("abcd"), 2) },
+			err:     mockfs.ErrDiskFull,
+			wantN:   2,
+			wantErr: mockfs.ErrDiskFull,
+			want:    "01ab456789",
+		},
+		{
+			name:    "write defaults to short write",
+			flag:    os.O_RDWR,
+			write:   func(f mockfs.WritableFile) (int, error) { return f.Write([]byte("abcdef")) },
+			wantN:   4,
+			wantErr: io.ErrShortWrite,
+			want:    "abcd456789",
+		},
+		{
+			name:  "append past the bad sector",
+			flag:  os.O_WRONLY | os.O_APPEND,
+			write: func(f mockfs.WritableFile) (int, error) { return f.Write([]byte("x")) },
+			wantN: 1,
+			want:  "0123456789x",
+		},
+		{
+			name:  "write missing the bad sector",
+			flag:  os.O_RDWR,
+			write: func(f mockfs.WritableFile) (int, error) { return f.WriteAt([]byte("xy"), 8) },
+			wantN: 2,
+			want:  "01234567xy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", "0123456789"))
+			requireNoError(t, mfs.FailWriteRange("disk.img", 4, 2, tt.err))
+
+			f, err := mfs.OpenFile("disk.img", tt.flag, 0)
+			requireNoError(t, err)
+			defer f.Close()
+
+			n, err := tt.write(f)
+			if tt.wantErr != nil {
+				assertError(t, err, tt.wantErr)
+			} else {
+				requireNoError(t, err)
+			}
+			if n != tt.wantN {
+				t.Errorf("n = %d, want %d", n, tt.wantN)
+			}
+			if got := string(mustReadFile(t, mfs, "disk.img")); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockFS_WriteRange_WriteFile(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", "0123"), mockfs.WithAppend())
+	requireNoError(t, mfs.FailWriteRange("disk.img", 6, 2, mockfs.ErrDiskFull))
+
+	// Appending at offset 4 reaches the bad sector after two bytes
+	err := mfs.WriteFile("disk.img", []byte("abcd"), 0o644)
+	assertError(t, err, mockfs.ErrDiskFull)
+	if got := string(mustReadFile(t, mfs, "disk.img")); got != "0123ab" {
+		t.Errorf("content = %q, want %q", got, "0123ab")
+	}
+}
+
+func TestRangeRule_MissDoesNotConsumeMode(t *testing.T) {
+	t.Parallel()
+
+	f := mockfs.NewMockFileFromString("disk.img", "0123456789")
+	rule, err := mockfs.NewRangeRule(8, 1, mockfs.ErrCorrupted, mockfs.ErrorModeOnce, 0, mockfs.NewExactMatcher("disk.img"))
+	requireNoError(t, err)
+	f.ErrorInjector().Add(mockfs.OpRead, rule)
+
+	buf := make([]byte, 4)
+	for range 2 {
+		_, err := f.Read(buf)
+		requireNoError(t, err)
+	}
+
+	n, err := f.Read(buf)
+	assertError(t, err, mockfs.ErrCorrupted)
+	if string(buf[:n]) != "" {
+		t.Errorf("read %q at the bad byte, want nothing", buf[:n])
+	}
+
+	// Once mode: the fault is spent
+	n, err = f.Read(buf)
+	requireNoError(t, err)
+	if string(buf[:n]) != "89" {
+		t.Errorf("read %q, want %q", buf[:n], "89")
+	}
+}
@@ -0,0 +1,131 @@
+package mockfs
+
+import (
+	"io/fs"
+	"time"
+)
+
+// truncate changes the size of the inode's data to size, dropping the tail
+// or extending it with zero bytes, and updates the modification time.
+func (n *inode) truncate(size int64) {
+	n.snapshot()
+	n.resize(size)
+	n.ModTime = time.Now()
+}
+
+// Truncate changes the size of the named file, mirroring [os.Truncate].
+// A shorter size drops the tail of the file; a longer size extends it with
+// zero bytes. A symbolic link is followed.
+//
+// Returns ErrInvalid for a negative size, ErrIsDir for a directory, and
+// ErrPermission on a read-only filesystem (WithReadOnly) or, with permission
+// checks enabled, without write permission on the file.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (m *MockFS) Truncate(name string, size int64) (err error) {
+	// Record the result of this operation on exit
+	defer func() { m.stats.RecordPath(OpTruncate, name, 0, err) }()
+	j := m.journal.begin(OpTruncate, name)
+	j.at(size)
+	defer func() { j.end(0, err) }()
+
+	cleanName, err := m.validateAndCleanPath(name, OpTruncate)
+	if err != nil {
+		return err
+	}
+
+	if err := m.injector.CheckAndApply(OpTruncate, cleanName); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	j.simulate(m.latency, OpTruncate)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	opName := OpTruncate.String()
+
+	if size < 0 {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrInvalid}
+	}
+	if m.writeMode == writeModeReadOnly {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrPermission}
+	}
+
+	resolved, node, err := m.lookup(opName, name, cleanName, true)
+	if err != nil {
+		return err
+	}
+	if node.Mode.IsDir() {
+		return &fs.PathError{Op: opName, Path: name, Err: ErrIsDir}
+	}
+	if err := m.checkAccess(opName, name, node, permWrite); err != nil {
+		return err
+	}
+	if err := m.checkGrowth(opName, name, size-node.size(), m.pathsOf(node)...); err != nil {
+		return err
+	}
+
+	node.saveVersion(m.injector)
+	node.truncate(size)
+	m.notify(resolved, EventWrite)
+	return nil
+}
+
+// Truncate changes the size of the file, mirroring [os.File.Truncate].
+// A shorter size drops the tail of the file; a longer size extends it with
+// zero bytes. The I/O offset is not changed.
+//
+// Returns ErrInvalid for a negative size, ErrIsDir for a directory, and
+// ErrPermission for a read-only file handle.
+//
+//nolint:nonamedreturns // Deferred function is using the named returns.
+func (f *MockFile) Truncate(size int64) (err error) {
+	<-f.mu
+	defer func() { f.mu <- struct{}{} }()
+
+	// Record the result of this operation on exit
+	defer func() { f.stats.RecordPath(OpTruncate, f.name, 0, err) }()
+	j := f.track(OpTruncate)
+	j.at(size)
+	defer func() { j.end(0, err) }()
+
+	if f.closed {
+		return fs.ErrClosed
+	}
+
+	// Simulate latency before checking for errors (models real I/O timing)
+	j.simulate(f.latency, OpTruncate)
+
+	if err := f.injector.CheckAndApply(OpTruncate, f.name); err != nil {
+		j.fault()
+		//nolint:wrapcheck // returned verbatim: injected/sentinel errors must match exactly for errors.Is and the package's runnable Example tests
+		return err
+	}
+
+	opName := OpTruncate.String()
+
+	switch {
+	case size < 0:
+		return &fs.PathError{Op: opName, Path: f.name, Err: ErrInvalid}
+	case f.node.Mode.IsDir():
+		return &fs.PathError{Op: opName, Path: f.name, Err: ErrIsDir}
+	case f.writeMode == writeModeReadOnly:
+		return &fs.PathError{Op: opName, Path: f.name, Err: ErrPermission}
+	}
+
+	unlock := f.lockInode(true)
+	defer unlock()
+	if f.fsys != nil {
+		if err := f.fsys.checkGrowth(opName, f.name, size-f.node.size(), f.fsys.pathsOf(f.node)...); err != nil {
+			return err
+		}
+	}
+
+	f.beginVersion()
+	f.node.truncate(size)
+	f.notify(EventWrite)
+	return nil
+}
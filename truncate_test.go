@@ -0,0 +1,226 @@
+package mockfs_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+func TestMockFS_Truncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []mockfs.FsOption
+		path     string
+		size     int64
+		wantData []byte
+		wantErr  error
+	}{
+		{name: "shrink", path: "wal.log", size: 4, wantData: []byte("reco")},
+		{name: "shrink to zero", path: "wal.log", size: 0, wantData: []byte{}},
+		{name: "same size", path: "wal.log", size: 6, wantData: []byte("record")},
+		{name: "zero-extend", path: "wal.log", size: 9, wantData: []byte("record\x00\x00\x00")},
+		{name: "follows symlink", path: "link", size: 3, wantData: []byte("rec")},
+		{name: "negative size", path: "wal.log", size: -1, wantErr: mockfs.ErrInvalid},
+		{name: "directory", path: "dir", size: 0, wantErr: mockfs.ErrIsDir},
+		{name: "missing file", path: "missing", size: 0, wantErr: mockfs.ErrNotExist},
+		{name: "invalid path", path: "../wal.log", size: 0, wantErr: mockfs.ErrInvalid},
+		{
+			name:    "read-only filesystem",
+			opts:    []mockfs.FsOption{mockfs.WithReadOnly()},
+			path:    "wal.log",
+			size:    0,
+			wantErr: mockfs.ErrPermission,
+		},
+		{
+			name:    "no write permission",
+			opts:    []mockfs.FsOption{mockfs.WithPermissionChecks()},
+			path:    "sealed.log",
+			size:    0,
+			wantErr: mockfs.ErrPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]mockfs.FsOption{
+				mockfs.File("wal.log", "record"),
+				mockfs.File("sealed.log", "sealed", 0o444),
+				mockfs.Dir("dir"),
+				mockfs.Symlink("link", "wal.log"),
+			}, tt.opts...)
+			mfs := mockfs.MustNewMockFS(opts...)
+
+			if tt.wantErr != nil {
+				assertError(t, mfs.Truncate(tt.path, tt.size), tt.wantErr)
+				return
+			}
+
+			requireNoError(t, mfs.Chtimes("wal.log", time.Time{}, time.Unix(0, 0)))
+			requireNoError(t, mfs.Truncate(tt.path, tt.size))
+
+			if got := mustReadFile(t, mfs, "wal.log"); !bytes.Equal(got, tt.wantData) {
+				t.Errorf("data = %q, want %q", got, tt.wantData)
+			}
+			info, err := mfs.Stat("wal.log")
+			requireNoError(t, err)
+			if info.ModTime().Equal(time.Unix(0, 0)) {
+				t.Error("ModTime not updated by Truncate")
+			}
+		})
+	}
+}
+
+func TestMockFS_TruncateStats(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("wal.log", "record"))
+
+	requireNoError(t, mfs.Truncate("wal.log", 2))
+	assertAnyError(t, mfs.Truncate("wal.log", -1))
+
+	mfs.Stats().Expect().
+		Count(mockfs.OpTruncate, 2).
+		Failure(mockfs.OpTruncate, 1).
+		Assert(t)
+}
+
+func TestMockFS_TruncateLarge(t *testing.T) {
+	t.Parallel()
+
+	const size = 1 << 40
+	mfs := mockfs.MustNewMockFS(mockfs.File("disk.img", "head"))
+
+	// Growing far past the data allocates none of the gap
+	requireNoError(t, mfs.Truncate("disk.img", size))
+	info, err := mfs.Stat("disk.img")
+	requireNoError(t, err)
+	if info.Size() != size {
+		t.Errorf("size = %d, want %d", info.Size(), size)
+	}
+
+	f, err := mfs.OpenFile("disk.img", os.O_RDWR, 0)
+	requireNoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteAt([]byte("tail"), size-4)
+	requireNoError(t, err)
+	for off, want := range map[int64]string{0: "head", 1 << 30: "\x00\x00\x00\x00", size - 4: "tail"} {
+		buf := make([]byte, 4)
+		_, err := f.ReadAt(buf, off)
+		requireNoError(t, err)
+		if string(buf) != want {
+			t.Errorf("ReadAt(%d) = %q, want %q", off, buf, want)
+		}
+	}
+
+	// Writing far past the end does not allocate the gap either
+	g, err := mfs.OpenFile("sparse", os.O_RDWR|os.O_CREATE, 0o644)
+	requireNoError(t, err)
+	defer g.Close()
+	_, err = g.WriteAt([]byte("x"), size)
+	requireNoError(t, err)
+	if info, err := g.Stat(); err != nil || info.Size() != size+1 {
+		t.Errorf("Stat = %v, %v, want size %d", info, err, size+1)
+	}
+}
+
+func TestMockFile_Truncate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shrink keeps offset", func(t *testing.T) {
+		t.Parallel()
+
+		mfs := mockfs.MustNewMockFS(mockfs.File("wal.log", "header|torn"))
+		f, err := mfs.OpenFile("wal.log", os.O_RDWR, 0)
+		requireNoError(t, err)
+		mf := f.(*mockfs.MockFile)
+
+		_, err = mf.Seek(7, io.SeekStart)
+		requireNoError(t, err)
+		requireNoError(t, mf.Truncate(6))
+
+		// The offset is past the end now, so reads hit EOF
+		_, err = mf.Read(make([]byte, 4))
+		assertError(t, err, io.EOF)
+		requireNoError(t, mf.Close())
+
+		if got := string(mustReadFile(t, mfs, "wal.log")); got != "header" {
+			t.Errorf("data = %q, want %q", got, "header")
+		}
+
+		mf.Stats().Expect().Count(mockfs.OpTruncate, 1).Assert(t)
+	})
+
+	t.Run("zero-extend", func(t *testing.T) {
+		t.Parallel()
+
+		f := mockfs.NewMockFileFromString("file.txt", "ab")
+		requireNoError(t, f.Truncate(4))
+
+		got, err := io.ReadAll(f)
+		requireNoError(t, err)
+		if !bytes.Equal(got, []byte("ab\x00\x00")) {
+			t.Errorf("data = %q, want %q", got, "ab\x00\x00")
+		}
+	})
+
+	tests := []struct {
+		name    string
+		file    func() *mockfs.MockFile
+		size    int64
+		wantErr error
+	}{
+		{
+			name:    "negative size",
+			file:    func() *mockfs.MockFile { return mockfs.NewMockFileFromString("file.txt", "data") },
+			size:    -1,
+			wantErr: mockfs.ErrInvalid,
+		},
+		{
+			name: "read-only handle",
+			file: func() *mockfs.MockFile {
+				return mockfs.NewMockFileFromString("file.txt", "data", mockfs.WithFileReadOnly())
+			},
+			wantErr: mockfs.ErrPermission,
+		},
+		{
+			name:    "directory",
+			file:    func() *mockfs.MockFile { return mockfs.NewMockDir("dir", nil) },
+			wantErr: mockfs.ErrIsDir,
+		},
+		{
+			name: "injected error",
+			file: func() *mockfs.MockFile {
+				f := mockfs.NewMockFileFromString("file.txt", "data")
+				_ = f.ErrorInjector().AddExact(mockfs.OpTruncate, "file.txt", mockfs.ErrDiskFull, mockfs.ErrorModeAlways, 0)
+				return f
+			},
+			wantErr: mockfs.ErrDiskFull,
+		},
+		{
+			name: "closed file",
+			file: func() *mockfs.MockFile {
+				f := mockfs.NewMockFileFromString("file.txt", "data")
+				_ = f.Close()
+				return f
+			},
+			wantErr: fs.ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assertError(t, tt.file().Truncate(tt.size), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package mockfs
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventOp is the set of changes an Event reports, as in fsnotify.
+type EventOp uint32
+
+// Changes reported by a Watcher.
+const (
+	EventCreate EventOp = 1 << iota // An entry was created, or replaced by AddFile or Rename.
+	EventWrite                      // A file's content was written or truncated.
+	EventRemove                     // An entry was removed.
+	EventRename                     // An entry was renamed away; its new name gets EventCreate.
+	EventChmod                      // A file's mode, times, or owner changed.
+)
+
+// eventOpNames are the names of the EventOp bits, in bit order.
+var eventOpNames = []string{"CREATE", "WRITE", "REMOVE", "RENAME", "CHMOD"}
+
+// Has reports whether op includes every change in other.
+func (op EventOp) Has(other EventOp) bool {
+	return op&other == other
+}
+
+// String returns the names of the changes in op, such as "CREATE|WRITE".
+func (op EventOp) String() string {
+	var names []string
+	for i, name := range eventOpNames {
+		if op&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "[no events]"
+	}
+	return strings.Join(names, "|")
+}
+
+// Event is a change to an entry of a watched MockFS.
+type Event struct {
+	Name string  // Cleaned path of the entry, relative to the root of the filesystem.
+	Op   EventOp // Changes to the entry; several with WithCoalescing.
+}
+
+// String returns the changes and the path of the event, such as `WRITE "a/b"`.
+func (e Event) String() string {
+	return fmt.Sprintf("%s %q", e.Op, e.Name)
+}
+
+// watchOptions holds the configuration of a Watcher.
+type watchOptions struct {
+	buffer   int           // Events held at most before overflowing; 0 for no limit.
+	coalesce bool          // Whether an event merges into a pending event for the same path.
+	delay    time.Duration // Time between a change and the delivery of its event.
+}
+
+// WatchOption is a function type for configuring MockFS.Watch.
+type WatchOption func(*watchOptions) error
+
+// WithEventBuffer makes the Watcher hold at most n undelivered events, like
+// the event queue of inotify. Once it is full, further events are dropped
+// and ErrEventOverflow is sent on the Errors channel, once until it is read.
+// Without it, no event is dropped.
+// Returns an error wrapping ErrUsage from Watch if n is not positive.
+func WithEventBuffer(n int) WatchOption {
+	return func(o *watchOptions) error {
+		if n <= 0 {
+			return fmt.Errorf("WithEventBuffer: non-positive size %d", n)
+		}
+		o.buffer = n
+		return nil
+	}
+}
+
+// WithCoalescing makes an event for a path that already has an undelivered
+// event merge into the latest such event, so it reports both changes, as
+// event queues do under load. A merged event keeps the position of the
+// event it merged into. Changes after a removal or rename of the path are
+// not merged into the event reporting it, but queued after it.
+func WithCoalescing() WatchOption {
+	return func(o *watchOptions) error {
+		o.coalesce = true
+		return nil
+	}
+}
+
+// WithEventDelay delays the delivery of every event by d after the change,
+// so code that expects events promptly can be tested against a slow watcher.
+// Together with WithCoalescing, it gives changes in quick succession time to
+// merge.
+// Returns an error wrapping ErrUsage from Watch if d is negative.
+func WithEventDelay(d time.Duration) WatchOption {
+	return func(o *watchOptions) error {
+		if d < 0 {
+			return fmt.Errorf("WithEventDelay: negative delay %v", d)
+		}
+		o.delay = d
+		return nil
+	}
+}
+
+// Watch starts watching the named file or directory for changes, as fsnotify
+// does. A watched directory reports changes to itself and to its entries,
+// and with recursive, to every entry below it, including entries created
+// later. A symbolic link is followed, and events name the entries by their
+// paths through the link's target.
+//
+// Events are emitted by Create, OpenFile, WriteFile, Mkdir, MkdirAll,
+// Remove, RemoveAll, Rename, Truncate, Chmod, Chtimes, Chown, Symlink, Link,
+// AddFile, AddDir, and RemoveEntry, and by Write, WriteAt, Truncate, and
+// metadata changes through a MockFile, named by the path the handle was
+// opened at, with symbolic links resolved. Events are named by the path at
+// the time of the change, so an event queued before a Rename keeps the old
+// path, and a handle keeps naming the path it was opened at. Creating a file
+// with content emits EventCreate and then EventWrite. Rename emits
+// EventRename for the old path and EventCreate for the new one. RemoveAll
+// and RemoveEntry emit EventRemove for every entry, deepest first. Builder
+// options and Crash emit no events.
+//
+// Events are delivered in order on the Watcher's Events channel, which is
+// never full: changes do not wait for the test to read events. Close the
+// Watcher when done with it.
+//
+// Returns an error wrapping ErrUsage if an option is invalid, and a
+// *PathError if name is invalid or does not exist.
+func (m *MockFS) Watch(name string, recursive bool, opts ...WatchOption) (*Watcher, error) {
+	var o watchOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(&o); err != nil {
+			return nil, fmt.Errorf("mockfs: %w: failed to apply watch option: %w", ErrUsage, err)
+		}
+	}
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "Watch", Path: name, Err: ErrInvalid}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resolved, _, err := m.lookup("Watch", name, path.Clean(name), true)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newWatcher(resolved, recursive, o)
+	w.unwatch = func() { m.watchers.remove(w) }
+	m.watchers.add(w)
+	go w.run()
+
+	return w, nil
+}
+
+// notify reports op on the entry at cleanPath to the watchers.
+func (m *MockFS) notify(cleanPath string, op EventOp) {
+	m.watchers.notify(Event{Name: cleanPath, Op: op})
+}
+
+// notify reports op on the file to the watchers of the owning filesystem.
+func (f *MockFile) notify(op EventOp) {
+	if f.fsys != nil {
+		f.fsys.notify(f.path, op)
+	}
+}
+
+// --- Watcher ---
+
+// Watcher delivers the events of a watched MockFS path. Create it with
+// MockFS.Watch.
+type Watcher struct {
+	Events <-chan Event // Changes, in order; closed by Close.
+	Errors <-chan error // ErrEventOverflow when events were dropped; closed by Close.
+
+	root      string        // Watched cleaned path.
+	recursive bool          // Whether entries below direct children are watched.
+	opts      watchOptions  // Configuration.
+	events    chan Event    // Sending side of Events.
+	errors    chan error    // Sending side of Errors.
+	unwatch   func()        // Unregisters the watcher from its filesystem.
+	wake      chan struct{} // Signals the delivery goroutine of a new event.
+	done      chan struct{} // Closed by Close.
+	closeOnce sync.Once
+
+	mu    sync.Mutex    // Guards queue.
+	queue []queuedEvent // Undelivered events, in order.
+}
+
+// queuedEvent is an undelivered event and the time it is due.
+type queuedEvent struct {
+	Event
+	due time.Time
+}
+
+// newWatcher creates a watcher of root. Its delivery goroutine is not started.
+func newWatcher(root string, recursive bool, o watchOptions) *Watcher {
+	w := &Watcher{
+		root:      root,
+		recursive: recursive,
+		opts:      o,
+		events:    make(chan Event),
+		errors:    make(chan error, 1),
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	w.Events, w.Errors = w.events, w.errors
+	return w
+}
+
+// Close stops watching and closes the Events and Errors channels. Events
+// not delivered yet are dropped. Close is idempotent and always returns nil.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() {
+		// Once unregistered, nothing sends on errors any more
+		w.unwatch()
+		close(w.errors)
+		close(w.done)
+	})
+	return nil
+}
+
+// watches reports whether the watcher reports changes to the entry at p.
+func (w *Watcher) watches(p string) bool {
+	if p == w.root {
+		return true
+	}
+
+	rel, ok := relativeTo(p, w.root)
+	return ok && (w.recursive || !strings.Contains(rel, "/"))
+}
+
+// push queues ev for delivery, merging or dropping it as configured.
+func (w *Watcher) push(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Only the latest pending event for the path can take the change, and
+	// not once the entry is gone, so the changes to a path keep their order
+	if w.opts.coalesce {
+		for i := len(w.queue) - 1; i >= 0; i-- {
+			if w.queue[i].Name != ev.Name {
+				continue
+			}
+			if w.queue[i].Op&(EventRemove|EventRename) == 0 {
+				w.queue[i].Op |= ev.Op
+				return
+			}
+			break
+		}
+	}
+
+	if w.opts.buffer > 0 && len(w.queue) >= w.opts.buffer {
+		select {
+		case w.errors <- ErrEventOverflow:
+		default:
+		}
+		return
+	}
+
+	w.queue = append(w.queue, queuedEvent{Event: ev, due: time.Now().Add(w.opts.delay)})
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers the queued events when they are due, until Close.
+func (w *Watcher) run() {
+	defer close(w.events)
+
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-w.wake:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+		due := w.queue[0].due
+		w.mu.Unlock()
+
+		if wait := time.Until(due); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-w.done:
+				timer.Stop()
+				return
+			}
+		}
+
+		// The event stays queued until now, so that later events can merge into it
+		w.mu.Lock()
+		ev := w.queue[0].Event
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+
+		select {
+		case w.events <- ev:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// watchRegistry holds the watchers of a MockFS.
+type watchRegistry struct {
+	mu       sync.Mutex
+	watchers []*Watcher
+}
+
+// add registers w.
+func (r *watchRegistry) add(w *Watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.watchers = append(r.watchers, w)
+}
+
+// remove unregisters w.
+func (r *watchRegistry) remove(w *Watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, registered := range r.watchers {
+		if registered == w {
+			r.watchers = append(r.watchers[:i:i], r.watchers[i+1:]...)
+			return
+		}
+	}
+}
+
+// notify queues ev on every watcher of its path.
+func (r *watchRegistry) notify(ev Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.watchers {
+		if w.watches(ev.Name) {
+			w.push(ev)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package mockfs_test
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/balinomad/go-mockfs/v2"
+)
+
+// drain returns the events w delivers once every goroutine of the bubble is
+// blocked. Call it inside a synctest bubble.
+func drain(w *mockfs.Watcher) []mockfs.Event {
+	var events []mockfs.Event
+	for {
+		synctest.Wait()
+		select {
+		case ev := <-w.Events:
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+// assertEvents fails the test if got and want differ.
+func assertEvents(t *testing.T, got, want []mockfs.Event) {
+	t.Helper()
+
+	if !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestMockFS_Watch_Events(t *testing.T) {
+	t.Parallel()
+
+	ev := func(op mockfs.EventOp, name string) mockfs.Event { return mockfs.Event{Name: name, Op: op} }
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, m *mockfs.MockFS)
+		want   []mockfs.Event
+	}{
+		{
+			name:   "write file",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.WriteFile("a", []byte("new"), 0o644)) },
+			want:   []mockfs.Event{ev(mockfs.EventWrite, "a")},
+		},
+		{
+			name:   "write file creating",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.WriteFile("b", []byte("new"), 0o644)) },
+			want:   []mockfs.Event{ev(mockfs.EventCreate, "b"), ev(mockfs.EventWrite, "b")},
+		},
+		{
+			name: "handle write and truncate",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				f, err := m.OpenFile("a", os.O_WRONLY, 0)
+				requireNoError(t, err)
+				_, err = f.Write([]byte("x"))
+				requireNoError(t, err)
+				requireNoError(t, f.(*mockfs.MockFile).Truncate(1))
+				requireNoError(t, f.Close())
+			},
+			want: []mockfs.Event{ev(mockfs.EventWrite, "a"), ev(mockfs.EventWrite, "a")},
+		},
+		{
+			name: "open file creating",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				f, err := m.Create("c")
+				requireNoError(t, err)
+				requireNoError(t, f.Close())
+			},
+			want: []mockfs.Event{ev(mockfs.EventCreate, "c")},
+		},
+		{
+			name:   "mkdir all",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.MkdirAll("x/y", 0o755)) },
+			want:   []mockfs.Event{ev(mockfs.EventCreate, "x"), ev(mockfs.EventCreate, "x/y")},
+		},
+		{
+			name:   "remove",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.Remove("a")) },
+			want:   []mockfs.Event{ev(mockfs.EventRemove, "a")},
+		},
+		{
+			name:   "remove all",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.RemoveAll("dir")) },
+			want:   []mockfs.Event{ev(mockfs.EventRemove, "dir/sub/g"), ev(mockfs.EventRemove, "dir/sub"), ev(mockfs.EventRemove, "dir/f"), ev(mockfs.EventRemove, "dir")},
+		},
+		{
+			name:   "remove entry",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.RemoveEntry("dir/sub")) },
+			want:   []mockfs.Event{ev(mockfs.EventRemove, "dir/sub/g"), ev(mockfs.EventRemove, "dir/sub")},
+		},
+		{
+			name:   "rename over an existing file",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.Rename("a", "dir/f")) },
+			want:   []mockfs.Event{ev(mockfs.EventRename, "a"), ev(mockfs.EventCreate, "dir/f")},
+		},
+		{
+			name:   "add file",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.AddFile("p/q", "data")) },
+			want:   []mockfs.Event{ev(mockfs.EventCreate, "p"), ev(mockfs.EventCreate, "p/q")},
+		},
+		{
+			name:   "chmod",
+			change: func(t *testing.T, m *mockfs.MockFS) { requireNoError(t, m.Chmod("a", 0o600)) },
+			want:   []mockfs.Event{ev(mockfs.EventChmod, "a")},
+		},
+		{
+			name: "reads",
+			change: func(t *testing.T, m *mockfs.MockFS) {
+				_ = mustReadFile(t, m, "a")
+				_, err := m.Stat("dir")
+				requireNoError(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			synctest.Test(t, func(t *testing.T) {
+				mfs := mockfs.MustNewMockFS(
+					mockfs.WithCreateIfMissing(true),
+					mockfs.File("a", "data"),
+					mockfs.Dir("dir", mockfs.File("f", "data"), mockfs.Dir("sub", mockfs.File("g", "data"))),
+				)
+				w, err := mfs.Watch(".", true)
+				requireNoError(t, err)
+				defer w.Close()
+
+				tt.change(t, mfs)
+				assertEvents(t, drain(w), tt.want)
+			})
+		})
+	}
+}
+
+func TestMockFS_Watch_Scope(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		mfs := mockfs.MustNewMockFS(
+			mockfs.File("a", "data"),
+			mockfs.Dir("dir", mockfs.File("f", "data"), mockfs.Dir("sub", mockfs.File("g", "data"))),
+			mockfs.Symlink("link", "dir"),
+		)
+
+		dir, err := mfs.Watch("link", false)
+		requireNoError(t, err)
+		defer dir.Close()
+		file, err := mfs.Watch("a", false)
+		requireNoError(t, err)
+		defer file.Close()
+
+		for _, name := range []string{"a", "dir/f", "dir/sub/g", "dir/sub"} {
+			requireNoError(t, mfs.Chmod(name, 0o700))
+		}
+
+		// A directory watch covers the directory and its entries only
+		assertEvents(t, drain(dir), []mockfs.Event{
+			{Name: "dir/f", Op: mockfs.EventChmod},
+			{Name: "dir/sub", Op: mockfs.EventChmod},
+		})
+		assertEvents(t, drain(file), []mockfs.Event{{Name: "a", Op: mockfs.EventChmod}})
+
+		// A closed watcher closes its channels and reports nothing more
+		requireNoError(t, dir.Close())
+		requireNoError(t, dir.Close())
+		requireNoError(t, mfs.Chmod("dir/f", 0o600))
+		if _, ok := <-dir.Events; ok {
+			t.Error("Events delivered an event after Close")
+		}
+		if _, ok := <-dir.Errors; ok {
+			t.Error("Errors delivered an error after Close")
+		}
+	})
+}
+
+func TestMockFS_Watch_HandleThroughSymlink(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		mfs := mockfs.MustNewMockFS(mockfs.File("t", "data"), mockfs.Symlink("l", "t"))
+		w, err := mfs.Watch("t", false)
+		requireNoError(t, err)
+		defer w.Close()
+
+		f, err := mfs.OpenFile("l", os.O_WRONLY, 0)
+		requireNoError(t, err)
+		_, err = f.Write([]byte("x"))
+		requireNoError(t, err)
+		requireNoError(t, f.Close())
+
+		// Handle events name the link's target, not the link
+		assertEvents(t, drain(w), []mockfs.Event{{Name: "t", Op: mockfs.EventWrite}})
+	})
+}
+
+func TestMockFS_Watch_Lossy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []mockfs.WatchOption
+		want     []mockfs.Event
+		overflow bool
+	}{
+		{
+			name: "every event",
+			want: []mockfs.Event{
+				{Name: "a", Op: mockfs.EventWrite},
+				{Name: "b", Op: mockfs.EventCreate},
+				{Name: "b", Op: mockfs.EventWrite},
+				{Name: "a", Op: mockfs.EventWrite},
+				{Name: "a", Op: mockfs.EventRemove},
+				{Name: "a", Op: mockfs.EventCreate},
+				{Name: "a", Op: mockfs.EventWrite},
+			},
+		},
+		{
+			name: "coalesced",
+			opts: []mockfs.WatchOption{mockfs.WithCoalescing(), mockfs.WithEventDelay(time.Second)},
+			want: []mockfs.Event{
+				{Name: "a", Op: mockfs.EventWrite | mockfs.EventRemove},
+				{Name: "b", Op: mockfs.EventCreate | mockfs.EventWrite},
+				{Name: "a", Op: mockfs.EventCreate | mockfs.EventWrite},
+			},
+		},
+		{
+			name: "overflowing",
+			opts: []mockfs.WatchOption{mockfs.WithEventBuffer(2), mockfs.WithEventDelay(time.Second)},
+			want: []mockfs.Event{
+				{Name: "a", Op: mockfs.EventWrite},
+				{Name: "b", Op: mockfs.EventCreate},
+			},
+			overflow: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			synctest.Test(t, func(t *testing.T) {
+				mfs := mockfs.MustNewMockFS(mockfs.WithCreateIfMissing(true), mockfs.File("a", "data"))
+				w, err := mfs.Watch(".", false, tt.opts...)
+				requireNoError(t, err)
+				defer w.Close()
+
+				start := time.Now()
+				requireNoError(t, mfs.WriteFile("a", []byte("1"), 0o644))
+				requireNoError(t, mfs.WriteFile("b", []byte("2"), 0o644))
+				requireNoError(t, mfs.WriteFile("a", []byte("3"), 0o644))
+				requireNoError(t, mfs.Remove("a"))
+				requireNoError(t, mfs.WriteFile("a", []byte("4"), 0o644))
+
+				// Nothing is delivered before the delay
+				time.Sleep(time.Second)
+				got := drain(w)
+				if len(tt.opts) > 0 && time.Since(start) < time.Second {
+					t.Errorf("events delivered after %v, before the delay", time.Since(start))
+				}
+				assertEvents(t, got, tt.want)
+
+				select {
+				case err := <-w.Errors:
+					if !tt.overflow {
+						t.Errorf("Errors = %v, want none", err)
+					}
+					assertError(t, err, mockfs.ErrEventOverflow)
+				default:
+					if tt.overflow {
+						t.Error("no overflow reported")
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestMockFS_Watch_Errors(t *testing.T) {
+	t.Parallel()
+
+	mfs := mockfs.MustNewMockFS(mockfs.File("a", "data"))
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    []mockfs.WatchOption
+		wantErr error
+	}{
+		{name: "missing path", path: "missing", wantErr: mockfs.ErrNotExist},
+		{name: "invalid path", path: "/a", wantErr: mockfs.ErrInvalid},
+		{name: "non-positive buffer", path: "a", opts: []mockfs.WatchOption{mockfs.WithEventBuffer(0)}, wantErr: mockfs.ErrUsage},
+		{name: "negative delay", path: "a", opts: []mockfs.WatchOption{mockfs.WithEventDelay(-time.Second)}, wantErr: mockfs.ErrUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w, err := mfs.Watch(tt.path, false, tt.opts...)
+			assertError(t, err, tt.wantErr)
+			if w != nil {
+				t.Errorf("Watch = %v, want nil", w)
+			}
+		})
+	}
+}
+
+func TestEvent_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ev   mockfs.Event
+		want string
+	}{
+		{ev: mockfs.Event{Name: "a/b", Op: mockfs.EventCreate | mockfs.EventWrite}, want: `CREATE|WRITE "a/b"`},
+		{ev: mockfs.Event{Name: "c", Op: mockfs.EventRemove}, want: `REMOVE "c"`},
+		{ev: mockfs.Event{Name: "c"}, want: `[no events] "c"`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ev.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+	if op := mockfs.EventCreate | mockfs.EventChmod; !op.Has(mockfs.EventChmod) || op.Has(mockfs.EventRename) {
+		t.Errorf("Has is wrong for %v", op)
+	}
+}